@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+type OpCode byte
+
+// Operands follow the opcode in the code stream. Const, LoadGlobal,
+// StoreGlobal, LoadLocal, StoreLocal, the jumps, Call, Trace and Echo take
+// one 16 bit operand, Convert takes one byte.
+const (
+	OpCode_Const OpCode = iota
+	OpCode_Pop
+	OpCode_Dup
+	OpCode_LoadGlobal
+	OpCode_StoreGlobal
+	OpCode_LoadLocal
+	OpCode_StoreLocal
+	OpCode_Add
+	OpCode_Sub
+	OpCode_Mul
+	OpCode_Div
+	OpCode_Mod
+	OpCode_LT
+	OpCode_LE
+	OpCode_GT
+	OpCode_GE
+	OpCode_EQ
+	OpCode_NE
+	OpCode_Neg
+	OpCode_Not
+	OpCode_Convert
+	OpCode_Jump
+	OpCode_JumpIfFalse
+	OpCode_JumpIfTrue
+	OpCode_Call
+	OpCode_Return
+	OpCode_Trace
+	OpCode_Echo
+	OpCode_Print
+	OpCode_Halt
+)
+
+var opCodeNames = [...]string{
+	OpCode_Const:       "CONST",
+	OpCode_Pop:         "POP",
+	OpCode_Dup:         "DUP",
+	OpCode_LoadGlobal:  "LOAD_GLOBAL",
+	OpCode_StoreGlobal: "STORE_GLOBAL",
+	OpCode_LoadLocal:   "LOAD_LOCAL",
+	OpCode_StoreLocal:  "STORE_LOCAL",
+	OpCode_Add:         "ADD",
+	OpCode_Sub:         "SUB",
+	OpCode_Mul:         "MUL",
+	OpCode_Div:         "DIV",
+	OpCode_Mod:         "MOD",
+	OpCode_LT:          "LT",
+	OpCode_LE:          "LE",
+	OpCode_GT:          "GT",
+	OpCode_GE:          "GE",
+	OpCode_EQ:          "EQ",
+	OpCode_NE:          "NE",
+	OpCode_Neg:         "NEG",
+	OpCode_Not:         "NOT",
+	OpCode_Convert:     "CONVERT",
+	OpCode_Jump:        "JUMP",
+	OpCode_JumpIfFalse: "JUMP_IF_FALSE",
+	OpCode_JumpIfTrue:  "JUMP_IF_TRUE",
+	OpCode_Call:        "CALL",
+	OpCode_Return:      "RETURN",
+	OpCode_Trace:       "TRACE",
+	OpCode_Echo:        "ECHO",
+	OpCode_Print:       "PRINT",
+	OpCode_Halt:        "HALT",
+}
+
+func (op OpCode) String() string {
+	if int(op) < len(opCodeNames) {
+		return opCodeNames[op]
+	}
+	return fmt.Sprintf("OP(%d)", byte(op))
+}
+
+// binaryOperators maps the arithmetic and comparison opcodes to the operators
+// of binaryOp, and back.
+var binaryOperators = map[OpCode]string{
+	OpCode_Add: "+", OpCode_Sub: "-", OpCode_Mul: "*", OpCode_Div: "/", OpCode_Mod: "%",
+	OpCode_LT: "<", OpCode_LE: "<=", OpCode_GT: ">", OpCode_GE: ">=", OpCode_EQ: "==", OpCode_NE: "!=",
+}
+
+var binaryOpCodes = func() map[string]OpCode {
+	codes := make(map[string]OpCode)
+	for op, operator := range binaryOperators {
+		codes[operator] = op
+	}
+	return codes
+}()
+
+// conversionTypes numbers the target types of OpCode_Convert.
+var conversionTypes = []DataType{DataType_Int, DataType_Float, DataType_Bool, DataType_String}
+
+// operandSize is the number of operand bytes following op.
+func operandSize(op OpCode) int {
+	switch op {
+	case OpCode_Const, OpCode_LoadGlobal, OpCode_StoreGlobal, OpCode_LoadLocal, OpCode_StoreLocal,
+		OpCode_Jump, OpCode_JumpIfFalse, OpCode_JumpIfTrue, OpCode_Call, OpCode_Trace, OpCode_Echo:
+		return 2
+	case OpCode_Convert:
+		return 1
+	}
+	return 0
+}
+
+// Chunk is the bytecode of one function, or of one piece of top-level code.
+// Spans holds the source span of the instruction each code byte belongs to,
+// for runtime errors.
+type Chunk struct {
+	Name      string
+	Code      []byte
+	Spans     []Span
+	Constants []Value
+	// Params and Locals are the number of arguments and frame slots.
+	Params int
+	Locals int
+}
+
+func (c *Chunk) emit(op OpCode, span Span) int {
+	c.Code = append(c.Code, byte(op))
+	c.Spans = append(c.Spans, span)
+	return len(c.Code) - 1
+}
+
+func (c *Chunk) emit8(operand byte) {
+	c.Code = append(c.Code, operand)
+	c.Spans = append(c.Spans, c.Spans[len(c.Spans)-1])
+}
+
+func (c *Chunk) emit16(operand int) {
+	c.emit8(byte(operand >> 8))
+	c.emit8(byte(operand))
+}
+
+func (c *Chunk) read16(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
+
+func (c *Chunk) patch16(offset int, operand int) {
+	c.Code[offset] = byte(operand >> 8)
+	c.Code[offset+1] = byte(operand)
+}
+
+// addConstant returns the index of v in the constant pool, adding it if
+// needed.
+func (c *Chunk) addConstant(v Value) int {
+	for i, constant := range c.Constants {
+		if constant == v {
+			return i
+		}
+	}
+	c.Constants = append(c.Constants, v)
+	return len(c.Constants) - 1
+}
+
+// Disassemble prints the instructions of chunk, one per line, with the
+// offset, the source position and the decoded operand. Call operands are
+// printed with the name of the callee found in functions.
+func Disassemble(w io.Writer, chunk *Chunk, functions []*Chunk) {
+	fmt.Fprintf(w, "== %s (params %d, locals %d) ==\n", chunk.Name, chunk.Params, chunk.Locals)
+	for offset := 0; offset < len(chunk.Code); {
+		offset = disassembleInstruction(w, chunk, functions, offset)
+	}
+}
+
+func disassembleInstruction(w io.Writer, chunk *Chunk, functions []*Chunk, offset int) int {
+	op := OpCode(chunk.Code[offset])
+	fmt.Fprintf(w, "%04d %6s  %-14s", offset, chunk.Spans[offset].Start, op)
+	switch op {
+	case OpCode_Const, OpCode_Trace, OpCode_Echo:
+		index := chunk.read16(offset + 1)
+		fmt.Fprintf(w, "%4d (%s)", index, quoteValue(chunk.Constants[index]))
+	case OpCode_LoadGlobal, OpCode_StoreGlobal, OpCode_LoadLocal, OpCode_StoreLocal, OpCode_Jump, OpCode_JumpIfFalse, OpCode_JumpIfTrue:
+		fmt.Fprintf(w, "%4d", chunk.read16(offset+1))
+	case OpCode_Call:
+		index := chunk.read16(offset + 1)
+		name := "?"
+		if index < len(functions) && functions[index] != nil {
+			name = functions[index].Name
+		}
+		fmt.Fprintf(w, "%4d (%s)", index, name)
+	case OpCode_Convert:
+		fmt.Fprintf(w, "%4s", conversionTypes[chunk.Code[offset+1]])
+	}
+	fmt.Fprintln(w)
+	return offset + 1 + operandSize(op)
+}
+
+func quoteValue(v Value) string {
+	if v.Type == DataType_String {
+		return fmt.Sprintf("%q", v.Str)
+	}
+	return fmt.Sprintf("%s %s", v.Type, v)
+}
@@ -0,0 +1,285 @@
+package main
+
+import "fmt"
+
+// loopLabels collects the jumps of the break and continue statements of one
+// loop, patched once the loop has been compiled.
+type loopLabels struct {
+	breaks    []int
+	continues []int
+}
+
+// BytecodeCompiler lowers an AST annotated by a Resolver and a TypeChecker to
+// bytecode for the VM. Functions are numbered in the order they are compiled
+// and are kept between calls to Compile, like the globals of the Resolver.
+type BytecodeCompiler struct {
+	Functions []*Chunk
+	// Echo emits the tracing of declarations and top-level results done by
+	// SimpleScript in the REPL.
+	Echo  bool
+	index map[*Symbol]int
+	chunk *Chunk
+	loops []*loopLabels
+}
+
+func NewBytecodeCompiler() *BytecodeCompiler {
+	return &BytecodeCompiler{Echo: true, index: make(map[*Symbol]int)}
+}
+
+// Compile returns the chunk running the top-level statements of root. The
+// functions declared by root are added to Functions.
+func (c *BytecodeCompiler) Compile(root ASTNoder) (chunk *Chunk, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			chunk, err = nil, d
+		}
+	}()
+	c.loops = nil
+	var functions []ASTNoder
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			c.index[child.GetSymbol()] = len(c.Functions)
+			c.Functions = append(c.Functions, nil)
+			functions = append(functions, child)
+		}
+	}
+	for _, function := range functions {
+		c.Functions[c.index[function.GetSymbol()]] = c.function(function)
+	}
+	c.chunk = &Chunk{Name: "<script>"}
+	for _, child := range root.GetChildren() {
+		c.statement(child, c.Echo)
+	}
+	c.chunk.emit(OpCode_Halt, root.GetSpan())
+	return c.chunk, nil
+}
+
+func (c *BytecodeCompiler) fail(node ASTNoder, message string) {
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_CodeTooLarge, message, node.GetSpan()))
+}
+
+func (c *BytecodeCompiler) function(node ASTNoder) *Chunk {
+	symbol := node.GetSymbol()
+	c.chunk = &Chunk{Name: symbol.Name, Params: len(symbol.Params), Locals: symbol.Locals}
+	children := node.GetChildren()
+	body := children[len(children)-1]
+	c.statement(body, false)
+	c.constant(body, zeroValue(symbol.Type))
+	c.chunk.emit(OpCode_Return, body.GetSpan())
+	return c.chunk
+}
+
+func (c *BytecodeCompiler) emit16(node ASTNoder, op OpCode, operand int) int {
+	if operand > 0xffff {
+		c.fail(node, fmt.Sprintf("%s operand %d does not fit in 16 bits", op, operand))
+	}
+	offset := c.chunk.emit(op, node.GetSpan())
+	c.chunk.emit16(operand)
+	return offset
+}
+
+func (c *BytecodeCompiler) constant(node ASTNoder, v Value) {
+	c.emit16(node, OpCode_Const, c.chunk.addConstant(v))
+}
+
+// jump emits a jump whose target is patched later, returning its offset.
+func (c *BytecodeCompiler) jump(node ASTNoder, op OpCode) int {
+	return c.emit16(node, op, 0)
+}
+
+// patch sets the target of the jumps at offsets to the current end of code.
+func (c *BytecodeCompiler) patch(node ASTNoder, offsets ...int) {
+	c.patchTo(node, len(c.chunk.Code), offsets...)
+}
+
+func (c *BytecodeCompiler) patchTo(node ASTNoder, target int, offsets ...int) {
+	if target > 0xffff {
+		c.fail(node, "too much code to jump over")
+	}
+	for _, offset := range offsets {
+		c.chunk.patch16(offset+1, target)
+	}
+}
+
+func (c *BytecodeCompiler) load(node ASTNoder) {
+	symbol := node.GetSymbol()
+	if symbol.IsGlobal() {
+		c.emit16(node, OpCode_LoadGlobal, symbol.Index)
+	} else {
+		c.emit16(node, OpCode_LoadLocal, symbol.Index)
+	}
+}
+
+func (c *BytecodeCompiler) store(node ASTNoder) {
+	symbol := node.GetSymbol()
+	if symbol.IsGlobal() {
+		c.emit16(node, OpCode_StoreGlobal, symbol.Index)
+	} else {
+		c.emit16(node, OpCode_StoreLocal, symbol.Index)
+	}
+}
+
+// storeEcho stores the value on top of the stack into the variable of node.
+// At the top level the value is echoed too, as SimpleScript does.
+func (c *BytecodeCompiler) storeEcho(node ASTNoder, top bool) {
+	if top {
+		c.chunk.emit(OpCode_Dup, node.GetSpan())
+	}
+	c.store(node)
+	if top {
+		c.emit16(node, OpCode_Echo, c.chunk.addConstant(StringValue(node.GetText())))
+	}
+}
+
+// statement compiles node so that it leaves the stack as it was. Top-level
+// statements echo their result.
+func (c *BytecodeCompiler) statement(node ASTNoder, top bool) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_FunctionDeclaration, ASTNodeType_Empty:
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		if len(children) > 0 {
+			c.expression(children[0])
+		} else {
+			c.constant(node, zeroValue(declaredType(node)))
+		}
+		if c.Echo {
+			c.emit16(node, OpCode_Trace, c.chunk.addConstant(StringValue(node.GetText())))
+		}
+		c.storeEcho(node, top)
+	case ASTNodeType_Assignment:
+		c.expression(children[0])
+		c.storeEcho(node, top)
+	case ASTNodeType_Block:
+		for _, child := range children {
+			c.statement(child, false)
+		}
+	case ASTNodeType_If:
+		c.expression(children[0])
+		toElse := c.jump(node, OpCode_JumpIfFalse)
+		c.statement(children[1], false)
+		if len(children) > 2 {
+			toEnd := c.jump(node, OpCode_Jump)
+			c.patch(node, toElse)
+			c.statement(children[2], false)
+			c.patch(node, toEnd)
+		} else {
+			c.patch(node, toElse)
+		}
+	case ASTNodeType_While:
+		start := len(c.chunk.Code)
+		c.expression(children[0])
+		toEnd := c.jump(node, OpCode_JumpIfFalse)
+		labels := c.loopBody(children[1])
+		c.patchTo(node, start, c.jump(node, OpCode_Jump))
+		c.patchTo(node, start, labels.continues...)
+		c.patch(node, append(labels.breaks, toEnd)...)
+	case ASTNodeType_For:
+		c.statement(children[0], false)
+		start := len(c.chunk.Code)
+		toEnd := -1
+		if children[1].GetType() != ASTNodeType_Empty {
+			c.expression(children[1])
+			toEnd = c.jump(node, OpCode_JumpIfFalse)
+		}
+		labels := c.loopBody(children[3])
+		c.patch(node, labels.continues...)
+		c.statement(children[2], false)
+		c.patchTo(node, start, c.jump(node, OpCode_Jump))
+		if toEnd >= 0 {
+			labels.breaks = append(labels.breaks, toEnd)
+		}
+		c.patch(node, labels.breaks...)
+	case ASTNodeType_Break:
+		labels := c.loops[len(c.loops)-1]
+		labels.breaks = append(labels.breaks, c.jump(node, OpCode_Jump))
+	case ASTNodeType_Continue:
+		labels := c.loops[len(c.loops)-1]
+		labels.continues = append(labels.continues, c.jump(node, OpCode_Jump))
+	case ASTNodeType_Return:
+		if len(children) > 0 {
+			c.expression(children[0])
+		} else {
+			c.constant(node, zeroValue(DataType_Void))
+		}
+		c.chunk.emit(OpCode_Return, node.GetSpan())
+	case ASTNodeType_Print:
+		c.expression(children[0])
+		c.chunk.emit(OpCode_Print, node.GetSpan())
+	case ASTNodeType_Error:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, "cannot compile a statement with syntax errors", node.GetSpan()))
+	default:
+		c.expression(node)
+		if top {
+			c.emit16(node, OpCode_Echo, c.chunk.addConstant(StringValue("")))
+		} else {
+			c.chunk.emit(OpCode_Pop, node.GetSpan())
+		}
+	}
+}
+
+func (c *BytecodeCompiler) loopBody(node ASTNoder) *loopLabels {
+	labels := &loopLabels{}
+	c.loops = append(c.loops, labels)
+	c.statement(node, false)
+	c.loops = c.loops[:len(c.loops)-1]
+	return labels
+}
+
+// expression compiles node so that it pushes exactly one value. A call of a
+// void function pushes a void value.
+func (c *BytecodeCompiler) expression(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		c.constant(node, node.GetValue())
+	case ASTNodeType_Identifier:
+		c.load(node)
+	case ASTNodeType_Assignment:
+		c.expression(children[0])
+		c.chunk.emit(OpCode_Dup, node.GetSpan())
+		c.store(node)
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		c.expression(children[0])
+		c.expression(children[1])
+		c.chunk.emit(binaryOpCodes[node.GetText()], node.GetSpan())
+	case ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		c.expression(children[0])
+		c.chunk.emit(OpCode_Dup, node.GetSpan())
+		op := OpCode_JumpIfFalse
+		if node.GetType() == ASTNodeType_LogicalOr {
+			op = OpCode_JumpIfTrue
+		}
+		toEnd := c.jump(node, op)
+		c.chunk.emit(OpCode_Pop, node.GetSpan())
+		c.expression(children[1])
+		c.patch(node, toEnd)
+	case ASTNodeType_Unary:
+		c.expression(children[0])
+		switch node.GetText() {
+		case "-":
+			c.chunk.emit(OpCode_Neg, node.GetSpan())
+		case "!":
+			c.chunk.emit(OpCode_Not, node.GetSpan())
+		}
+	case ASTNodeType_Conversion:
+		c.expression(children[0])
+		for i, t := range conversionTypes {
+			if t == node.GetDataType() {
+				c.chunk.emit(OpCode_Convert, node.GetSpan())
+				c.chunk.emit8(byte(i))
+			}
+		}
+	case ASTNodeType_FunctionCall:
+		for _, arg := range children {
+			c.expression(arg)
+		}
+		c.emit16(node, OpCode_Call, c.index[node.GetSymbol()])
+	default:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, fmt.Sprintf("cannot compile %s as an expression", node.GetType()), node.GetSpan()))
+	}
+}
@@ -1,15 +1,12 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-)
+import "fmt"
 
 type SimpleCalculator struct {
 }
 
 func (s *SimpleCalculator) prog(tokens TokenReader) *ASTNoder {
-	noder := NewASTNoder(ASTNodeType_Program, "Calculator")
+	noder := NewASTNoder(ASTNodeType_Program, "Calculator", Span{Start: NewPosition()})
 	child := s.intDeclare(tokens)
 	if child != nil {
 		noder.AddChild(*child)
@@ -21,10 +18,10 @@ func (s *SimpleCalculator) intDeclare(reader TokenReader) *ASTNoder {
 	var node ASTNoder
 	token := reader.Peek()
 	if token != nil && token.Type == TokenType_Int {
-		reader.Read()
+		start := reader.Read().Span
 		if reader.Peek().Type == TokenType_Id {
 			token := reader.Read()
-			node = NewASTNoder(ASTNodeType_IntDeclaration, token.Text)
+			node = NewASTNoder(ASTNodeType_IntDeclaration, token.Text, joinSpan(start, token.Span))
 			token = reader.Peek()
 			if token != nil && token.Type == TokenType_Assignment {
 				reader.Read()
@@ -60,7 +57,7 @@ func (s *SimpleCalculator) addtive(reader TokenReader) *ASTNoder {
 				token := reader.Read()
 				child2 := s.multiplicative(reader)
 				if child2 != nil {
-					node = NewASTNoder(ASTNodeType_AddtiveExp, token.Text)
+					node = NewASTNoder(ASTNodeType_AddtiveExp, token.Text, joinSpan((*child1).GetSpan(), (*child2).GetSpan()))
 					node.AddChild(*child1)
 					node.AddChild(*child2)
 					*child1 = node
@@ -85,7 +82,7 @@ func (s *SimpleCalculator) multiplicative(reader TokenReader) *ASTNoder {
 			token := reader.Read()
 			child2 := s.primary(reader)
 			if child2 != nil {
-				node = NewASTNoder(ASTNodeType_Multiplicative, token.Text)
+				node = NewASTNoder(ASTNodeType_Multiplicative, token.Text, joinSpan((*child1).GetSpan(), (*child2).GetSpan()))
 				node.AddChild(*child1)
 				node.AddChild(*child2)
 			} else {
@@ -106,10 +103,11 @@ func (s *SimpleCalculator) primary(reader TokenReader) *ASTNoder {
 		switch token.Type {
 		case TokenType_IntLiteral:
 			reader.Read()
-			node = NewASTNoder(ASTNodeType_IntLiteral, token.Text)
+			node = NewASTNoder(ASTNodeType_IntLiteral, token.Text, token.Span)
+			node.SetValue(token.Value)
 		case TokenType_Id:
 			token := reader.Read()
-			node = NewASTNoder(ASTNodeType_IntDeclaration, token.Text)
+			node = NewASTNoder(ASTNodeType_IntDeclaration, token.Text, token.Span)
 		case TokenType_Left_Paren:
 			reader.Read()
 			node := s.addtive(reader)
@@ -159,7 +157,7 @@ func (s *SimpleCalculator) evaluate(node ASTNoder, indent string) int {
 			result = value1 - value2
 		}
 	case ASTNodeType_IntLiteral:
-		result, _ = strconv.Atoi(node.GetText())
+		result = node.GetValue().Int
 	case ASTNodeType_Multiplicative:
 		child1 := node.GetChildren()[0]
 		value1 := s.evaluate(child1, indent+"\t")
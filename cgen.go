@@ -0,0 +1,513 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Precedence levels of C operators, higher binds tighter.
+const (
+	cPrecedenceOr             = 4
+	cPrecedenceAnd            = 5
+	cPrecedenceEquality       = 9
+	cPrecedenceRelational     = 10
+	cPrecedenceAdditive       = 12
+	cPrecedenceMultiplicative = 13
+	cPrecedenceUnary          = 14
+	cPrecedencePrimary        = 16
+)
+
+var cBinaryPrecedence = map[ASTNodeType]int{
+	ASTNodeType_LogicalOr:      cPrecedenceOr,
+	ASTNodeType_LogicalAnd:     cPrecedenceAnd,
+	ASTNodeType_Equality:       cPrecedenceEquality,
+	ASTNodeType_Relational:     cPrecedenceRelational,
+	ASTNodeType_AddtiveExp:     cPrecedenceAdditive,
+	ASTNodeType_Multiplicative: cPrecedenceMultiplicative,
+}
+
+// cWrapping are the prelude functions of the int operators that overflow.
+var cWrapping = map[string]string{"+": "ss_add", "-": "ss_sub", "*": "ss_mul"}
+
+// cReserved are the C keywords and the library names used by the generated
+// code, which script names must not collide with.
+var cReserved = map[string]bool{
+	"auto": true, "case": true, "char": true, "const": true, "default": true, "do": true, "double": true,
+	"enum": true, "extern": true, "goto": true, "inline": true, "long": true, "register": true,
+	"restrict": true, "short": true, "signed": true, "sizeof": true, "static": true, "struct": true,
+	"switch": true, "typedef": true, "union": true, "unsigned": true, "void": true, "volatile": true,
+	"main": true, "printf": true, "puts": true, "fputs": true, "fflush": true, "exit": true,
+	"stdout": true, "stderr": true,
+}
+
+// CGenerator translates an AST annotated by a Resolver and a TypeChecker to
+// a standalone C99 file. Ints are long long, floats double and bools bool;
+// strings are not supported. Operators are parenthesized from their
+// precedence, not from the shape of the tree, so the output has only the
+// parentheses C needs. Int negation, addition, subtraction and
+// multiplication call prelude functions computing in unsigned long long, so
+// that overflow wraps around as in the interpreter.
+//
+// Calls count the call depth and fail past MaxCallDepth, which is checked
+// before the arguments are evaluated; a MaxCallDepth of 0 leaves the depth
+// to the C stack.
+type CGenerator struct {
+	MaxCallDepth int
+	out          strings.Builder
+	indent       string
+}
+
+func NewCGenerator() *CGenerator {
+	return &CGenerator{MaxCallDepth: DefaultMaxCallDepth}
+}
+
+// Generate returns the C source of the whole program root.
+func (g *CGenerator) Generate(root ASTNoder) (source string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			source, err = "", d
+		}
+	}()
+	g.out.Reset()
+	g.indent = ""
+	g.out.WriteString(cPrelude)
+	if g.MaxCallDepth > 0 {
+		fmt.Fprintf(&g.out, cDepthPrelude, g.MaxCallDepth)
+	}
+
+	var functions, statements []ASTNoder
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			functions = append(functions, child)
+		} else {
+			statements = append(statements, child)
+		}
+	}
+	if len(functions) > 0 {
+		g.line("")
+		for _, function := range functions {
+			g.line("%s;", g.signature(function))
+		}
+	}
+	var globals []ASTNoder
+	for _, statement := range statements {
+		if isVariableDeclaration(statement.GetType()) {
+			globals = append(globals, statement)
+		}
+	}
+	if len(globals) > 0 {
+		g.line("")
+		for _, global := range globals {
+			g.line("static %s %s;", g.typeName(global, declaredType(global)), cName(global.GetText()))
+		}
+	}
+	for _, function := range functions {
+		g.line("")
+		g.line("%s {", g.signature(function))
+		children := function.GetChildren()
+		body := children[len(children)-1]
+		g.statements(body)
+		// Falling off the end returns the zero value, as in the interpreter.
+		statements := body.GetChildren()
+		if t := function.GetSymbol().Type; t != DataType_Void &&
+			(len(statements) == 0 || statements[len(statements)-1].GetType() != ASTNodeType_Return) {
+			zero, _ := g.literal(function, zeroValue(t))
+			g.line("\treturn %s;", zero)
+		}
+		g.line("}")
+	}
+	g.line("")
+	g.line("int main(void) {")
+	g.indent = "\t"
+	for _, statement := range statements {
+		if isVariableDeclaration(statement.GetType()) {
+			if children := statement.GetChildren(); len(children) > 0 {
+				g.line("%s = %s;", cName(statement.GetText()), g.operand(children[0], 0))
+			}
+		} else {
+			g.statement(statement)
+		}
+	}
+	g.line("return 0;")
+	g.indent = ""
+	g.line("}")
+	return g.out.String(), nil
+}
+
+func (g *CGenerator) line(format string, args ...interface{}) {
+	if format == "" {
+		g.out.WriteString("\n")
+		return
+	}
+	fmt.Fprintf(&g.out, g.indent+format+"\n", args...)
+}
+
+func (g *CGenerator) typeName(node ASTNoder, t DataType) string {
+	switch t {
+	case DataType_Int:
+		return "long long"
+	case DataType_Float:
+		return "double"
+	case DataType_Bool:
+		return "bool"
+	case DataType_Void:
+		return "void"
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_Unsupported, fmt.Sprintf("the C backend does not support %s values", t), node.GetSpan()))
+}
+
+// cName turns a script name into a C identifier, renaming reserved words and
+// writing other than ASCII characters as universal character names.
+func cName(name string) string {
+	if cReserved[name] || strings.HasPrefix(name, "ss_") || strings.HasSuffix(name, "_") {
+		return name + "_"
+	}
+	if utf8.ValidString(name) && strings.IndexFunc(name, func(r rune) bool { return r >= utf8.RuneSelf }) < 0 {
+		return name
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+		} else if r > 0xffff {
+			fmt.Fprintf(&b, "\\U%08X", r)
+		} else {
+			fmt.Fprintf(&b, "\\u%04X", r)
+		}
+	}
+	return b.String()
+}
+
+// cString quotes s as a C string literal.
+func cString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch {
+		case c == '\n':
+			b.WriteString("\\n")
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (g *CGenerator) signature(node ASTNoder) string {
+	symbol := node.GetSymbol()
+	params := make([]string, len(symbol.Params))
+	for i, param := range symbol.Params {
+		params[i] = g.typeName(param.Decl, param.Type) + " " + cName(param.Name)
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	return fmt.Sprintf("static %s %s(%s)", g.typeName(node, symbol.Type), cName(symbol.Name), strings.Join(params, ", "))
+}
+
+// statements emits the children of a block one level deeper.
+func (g *CGenerator) statements(node ASTNoder) {
+	indent := g.indent
+	g.indent += "\t"
+	for _, child := range node.GetChildren() {
+		g.statement(child)
+	}
+	g.indent = indent
+}
+
+// body emits the statement controlled by if, else or a loop, which is
+// always put in braces.
+func (g *CGenerator) body(node ASTNoder) {
+	if node.GetType() == ASTNodeType_Block {
+		g.statements(node)
+		return
+	}
+	indent := g.indent
+	g.indent += "\t"
+	g.statement(node)
+	g.indent = indent
+}
+
+func (g *CGenerator) statement(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_Empty:
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		g.line("%s;", g.declaration(node))
+	case ASTNodeType_Block:
+		g.line("{")
+		g.statements(node)
+		g.line("}")
+	case ASTNodeType_If:
+		g.line("if (%s) {", g.operand(children[0], 0))
+		g.body(children[1])
+		for len(children) > 2 {
+			next := children[2]
+			if next.GetType() != ASTNodeType_If {
+				g.line("} else {")
+				g.body(next)
+				break
+			}
+			children = next.GetChildren()
+			g.line("} else if (%s) {", g.operand(children[0], 0))
+			g.body(children[1])
+		}
+		g.line("}")
+	case ASTNodeType_While:
+		g.line("while (%s) {", g.operand(children[0], 0))
+		g.body(children[1])
+		g.line("}")
+	case ASTNodeType_For:
+		var init, condition, step string
+		switch children[0].GetType() {
+		case ASTNodeType_Empty:
+		case ASTNodeType_Assignment:
+			init = g.operand(children[0], 0)
+		default:
+			init = g.declaration(children[0])
+		}
+		if children[1].GetType() != ASTNodeType_Empty {
+			condition = " " + g.operand(children[1], 0)
+		}
+		if children[2].GetType() != ASTNodeType_Empty {
+			step = " " + g.operand(children[2], 0)
+		}
+		g.line("for (%s;%s;%s) {", init, condition, step)
+		g.body(children[3])
+		g.line("}")
+	case ASTNodeType_Break:
+		g.line("break;")
+	case ASTNodeType_Continue:
+		g.line("continue;")
+	case ASTNodeType_Return:
+		if len(children) > 0 {
+			g.line("return %s;", g.operand(children[0], 0))
+		} else {
+			g.line("return;")
+		}
+	case ASTNodeType_Print:
+		value := g.operand(children[0], 0)
+		switch t := children[0].GetDataType(); t {
+		case DataType_Int:
+			g.line("printf(\"%%lld\\n\", %s);", value)
+		case DataType_Float:
+			g.line("printf(\"%%g\\n\", %s);", value)
+		case DataType_Bool:
+			g.line("puts(%s ? \"true\" : \"false\");", g.operand(children[0], cPrecedenceOr))
+		default:
+			g.typeName(children[0], t)
+		}
+	case ASTNodeType_Error:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, "cannot compile a statement with syntax errors", node.GetSpan()))
+	case ASTNodeType_Assignment, ASTNodeType_FunctionCall:
+		g.line("%s;", g.operand(node, 0))
+	default:
+		g.line("(void)(%s);", g.operand(node, 0))
+	}
+}
+
+func (g *CGenerator) declaration(node ASTNoder) string {
+	declaration := g.typeName(node, declaredType(node)) + " " + cName(node.GetText())
+	if children := node.GetChildren(); len(children) > 0 {
+		declaration += " = " + g.operand(children[0], 0)
+	}
+	return declaration
+}
+
+// operand returns the C expression of node, parenthesized if its operator
+// binds less tightly than precedence.
+func (g *CGenerator) operand(node ASTNoder, precedence int) string {
+	text, actual := g.expression(node)
+	if actual < precedence {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// expression returns the C expression of node and its precedence.
+func (g *CGenerator) expression(node ASTNoder) (string, int) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		return g.literal(node, node.GetValue())
+	case ASTNodeType_Identifier:
+		return cName(node.GetText()), cPrecedencePrimary
+	case ASTNodeType_Assignment:
+		return cName(node.GetText()) + " = " + g.operand(children[0], 0), 2
+	case ASTNodeType_FunctionCall:
+		args := make([]string, len(children))
+		for i, arg := range children {
+			args[i] = g.operand(arg, 0)
+		}
+		call := cName(node.GetText()) + "(" + strings.Join(args, ", ") + ")"
+		if g.MaxCallDepth <= 0 {
+			return call, cPrecedencePrimary
+		}
+		message := cString(NewDiagnostic(Severity_Error, DiagnosticCode_StackOverflow,
+			fmt.Sprintf("stack overflow: call depth exceeds %d", g.MaxCallDepth), node.GetSpan()).Error() + "\n")
+		switch t := node.GetDataType(); t {
+		case DataType_Void:
+			return fmt.Sprintf("(ss_enter(%s), %s, ss_leave())", message, call), cPrecedencePrimary
+		default:
+			return fmt.Sprintf("ss_leave_%s((ss_enter(%s), %s))", t, message, call), cPrecedencePrimary
+		}
+	case ASTNodeType_Unary:
+		if node.GetText() == "-" && node.GetDataType() == DataType_Int {
+			return "ss_neg(" + g.operand(children[0], 0) + ")", cPrecedencePrimary
+		}
+		operand := g.operand(children[0], cPrecedenceUnary)
+		if node.GetText() != "!" && strings.HasPrefix(operand, node.GetText()) {
+			operand = " " + operand
+		}
+		return node.GetText() + operand, cPrecedenceUnary
+	case ASTNodeType_Conversion:
+		child := children[0]
+		from, to := child.GetDataType(), node.GetDataType()
+		if from == to {
+			return g.expression(child)
+		}
+		g.typeName(child, from)
+		return "(" + g.typeName(node, to) + ")" + g.operand(child, cPrecedenceUnary), cPrecedenceUnary
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality, ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		return g.binary(node)
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, fmt.Sprintf("cannot compile %s as an expression", node.GetType()), node.GetSpan()))
+}
+
+func (g *CGenerator) literal(node ASTNoder, v Value) (string, int) {
+	switch v.Type {
+	case DataType_Int:
+		// The minimum is the negation of a literal C has no type for.
+		if v.Int == math.MinInt64 {
+			return "(-9223372036854775807LL - 1)", cPrecedencePrimary
+		}
+		return v.String() + "LL", cPrecedencePrimary
+	case DataType_Float:
+		text := strconv.FormatFloat(v.Float, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".e") {
+			text += ".0"
+		}
+		return text, cPrecedencePrimary
+	case DataType_Bool:
+		return v.String(), cPrecedencePrimary
+	}
+	g.typeName(node, v.Type)
+	return "", cPrecedencePrimary
+}
+
+func (g *CGenerator) binary(node ASTNoder) (string, int) {
+	children := node.GetChildren()
+	operator := node.GetText()
+	if function, ok := cWrapping[operator]; ok && node.GetDataType() == DataType_Int {
+		return fmt.Sprintf("%s(%s, %s)", function, g.operand(children[0], 0), g.operand(children[1], 0)), cPrecedencePrimary
+	}
+	if (operator == "/" || operator == "%") && node.GetDataType() == DataType_Int {
+		function := "ss_div"
+		if operator == "%" {
+			function = "ss_mod"
+		}
+		message := NewDiagnostic(Severity_Error, DiagnosticCode_DivisionByZero, errDivisionByZero.Error(), node.GetSpan()).Error() + "\n"
+		return fmt.Sprintf("%s(%s, %s, %s)", function, g.operand(children[0], 0), g.operand(children[1], 0), cString(message)), cPrecedencePrimary
+	}
+	precedence := cBinaryPrecedence[node.GetType()]
+	left, right := precedence, precedence+1
+	// Keep the parentheses compilers warn about without.
+	switch node.GetType() {
+	case ASTNodeType_LogicalOr:
+		left, right = cPrecedenceAnd+1, cPrecedenceAnd+1
+	case ASTNodeType_Equality:
+		left, right = cPrecedenceRelational+1, cPrecedenceRelational+1
+	}
+	g.typeName(children[0], children[0].GetDataType())
+	return g.operand(children[0], left) + " " + operator + " " + g.operand(children[1], right), precedence
+}
+
+// cPrelude is the start of every generated file: the headers and the
+// runtime support.
+const cPrelude = `/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+`
+
+// cDepthPrelude is the runtime support for the call depth check, formatted
+// with the maximum depth.
+const cDepthPrelude = `
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= %d)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+`
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// cFlags compile the generated C so that undefined behavior, like signed
+// overflow, aborts the program.
+var cFlags = []string{"-std=c99", "-O2", "-Wall", "-Werror", "-Wno-infinite-recursion", "-fsanitize=undefined", "-fno-sanitize-recover"}
+
+// compileC compiles the C source with cc and returns the path of the binary.
+func compileC(t *testing.T, cc string, source []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	file, binary := filepath.Join(dir, "prog.c"), filepath.Join(dir, "prog")
+	if err := os.WriteFile(file, source, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(cc, append(cFlags, file, "-o", binary)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s failed: %v\n%s", cc, err, out)
+	}
+	return binary
+}
+
+// sanitizes reports whether cc builds programs with cFlags, which needs the
+// sanitizer runtime.
+func sanitizes(t *testing.T, cc string) bool {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "probe.c")
+	if err := os.WriteFile(file, []byte("int main(void) { return 0; }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return exec.Command(cc, append(cFlags, file, "-o", filepath.Join(dir, "probe"))...).Run() == nil
+}
+
+func TestCGolden(t *testing.T) {
+	cc, _ := exec.LookPath("gcc")
+	if cc != "" && !sanitizes(t, cc) {
+		t.Log("gcc cannot build with -fsanitize=undefined, the programs are not run")
+		cc = ""
+	}
+	for _, file := range samples(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			golden(t, file, ".c", build(t, "c", file))
+			if cc == "" {
+				return
+			}
+			want := runProgram(t, file)
+			for _, level := range []string{"-O0", "-O2"} {
+				checkBinary(t, level, compileC(t, cc, build(t, "c", file, level)), want)
+			}
+		})
+	}
+}
+
+func TestCIntLiterals(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"int m = 9223372036854775807;", "m = 9223372036854775807LL;"},
+		{"int m = -9223372036854775807 - 1;", "m = (-9223372036854775807LL - 1);"},
+		{"int m = -9223372036854775807 - 2;", "m = 9223372036854775807LL;"},
+		{"int m = 0 - 5;", "m = -5LL;"},
+	}
+	for _, test := range tests {
+		t.Run(test.script, func(t *testing.T) {
+			source := build(t, "c", writeScript(t, test.script), "-O2")
+			if !bytes.Contains(source, []byte(test.want)) {
+				t.Errorf("no %q in\n%s", test.want, source)
+			}
+		})
+	}
+}
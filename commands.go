@@ -0,0 +1,352 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// parseCommandLine parses the flags of a subcommand, which may come before or
+// after its file arguments, and returns the file arguments.
+func parseCommandLine(flags *flag.FlagSet, args []string) []string {
+	var files []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return files
+		}
+		files = append(files, args[0])
+		args = args[1:]
+	}
+}
+
+// loadProgram parses, resolves and type checks the script in path, printing
+// the diagnostics on the standard error. The AST is nil if there were errors.
+func loadProgram(path string) (ASTNoder, string) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, ""
+	}
+	parser := SimpleParser{Origin: NewPosition()}
+	root, err := parser.Parse(string(source))
+	if err != nil {
+		fmt.Fprint(os.Stderr, RenderError(err, string(source)))
+		return nil, string(source)
+	}
+	diagnostics := NewResolver().Resolve(*root)
+	if !diagnostics.HasErrors() {
+		diagnostics = append(diagnostics, NewTypeChecker().Check(*root)...)
+	}
+	fmt.Fprint(os.Stderr, diagnostics.Render(string(source)))
+	if diagnostics.HasErrors() {
+		return nil, string(source)
+	}
+	return *root, string(source)
+}
+
+// runCommand runs a script without the tracing of the REPL, so that only
+// what it prints is written to the standard output:
+//
+//	compiler run [-backend=tree|vm|ir] [-O0|-O1|-O2] [-print-passes] prog.ss
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	backend := flags.String("backend", "tree", "tree to walk the AST, vm to compile to bytecode, ir to interpret the SSA form")
+	maxCallDepth := flags.Int("maxdepth", DefaultMaxCallDepth, "maximum function call depth")
+	optimizer := NewOptimizer(0)
+	optimizationFlags(flags, &optimizer.Level)
+	printPasses := flags.Bool("print-passes", false, "print the tree or IR before and after each optimization pass on the standard error")
+	files := parseCommandLine(flags, args)
+	if *printPasses {
+		optimizer.Trace = os.Stderr
+	}
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: compiler run [-backend=tree|vm|ir] [-O0|-O1|-O2] [-print-passes] prog.ss")
+		return 2
+	}
+	root, source := loadProgram(files[0])
+	if root == nil {
+		return 1
+	}
+	optimizer.OptimizeAST(root)
+	var err error
+	switch *backend {
+	case "tree":
+		script := NewSimpleScript(false)
+		script.Echo = false
+		script.MaxCallDepth = *maxCallDepth
+		_, err = script.Evaluate(root, "")
+	case "vm":
+		bytecode := NewBytecodeCompiler()
+		bytecode.Echo = false
+		var chunk *Chunk
+		if chunk, err = bytecode.Compile(root); err == nil {
+			vm := NewVM(false)
+			vm.MaxCallDepth = *maxCallDepth
+			_, err = vm.Run(chunk, bytecode.Functions)
+		}
+	case "ir":
+		var program *IRProgram
+		if program, err = buildIR(root, optimizer); err == nil {
+			interpreter := NewIRInterpreter()
+			interpreter.MaxCallDepth = *maxCallDepth
+			err = interpreter.Run(program)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "unknown backend: "+*backend)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, RenderError(err, source))
+		return 1
+	}
+	return 0
+}
+
+// buildCommand compiles a script. The x86 target makes a native executable
+// with the GNU assembler and linker, the llvm target writes LLVM IR, the wasm
+// target a WebAssembly module, the c target a C99 file and the ir target the
+// text form of the SSA IR. From -O1 on, the x86 target is compiled from the
+// IR with its registers allocated to machine registers:
+//
+//	compiler build [-target=x86|llvm|wasm|c|ir] [-O0|-O1|-O2] [-print-passes] prog.ss [-o prog] [-S]
+func buildCommand(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	target := flags.String("target", "x86", "x86 for a native executable, llvm for LLVM IR, wasm for WebAssembly, c for C99, ir for the SSA IR")
+	output := flags.String("o", "", "output file, the script name without extension by default")
+	assembly := flags.Bool("S", false, "write the assembly instead of an executable")
+	maxCallDepth := flags.Int("maxdepth", DefaultMaxCallDepth, "maximum function call depth")
+	optimizer := NewOptimizer(0)
+	optimizationFlags(flags, &optimizer.Level)
+	printPasses := flags.Bool("print-passes", false, "print the tree or IR before and after each optimization pass on the standard error")
+	files := parseCommandLine(flags, args)
+	if *printPasses {
+		optimizer.Trace = os.Stderr
+	}
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: compiler build [-target=x86|llvm|wasm|c|ir] [-O0|-O1|-O2] [-print-passes] prog.ss [-o prog] [-S]")
+		return 2
+	}
+	extensions := map[string]string{"x86": "", "llvm": ".ll", "wasm": ".wasm", "c": ".c", "ir": ".ir"}
+	extension, ok := extensions[*target]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown target: "+*target)
+		return 2
+	}
+	if *assembly && *target == "x86" {
+		extension = ".s"
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(files[0], filepath.Ext(files[0])) + extension
+	}
+	root, source := loadProgram(files[0])
+	if root == nil {
+		return 1
+	}
+	optimizer.OptimizeAST(root)
+	var asm string
+	var err error
+	switch *target {
+	case "x86":
+		if optimizer.Level == 0 {
+			generator := NewX86Generator()
+			generator.MaxCallDepth = *maxCallDepth
+			asm, err = generator.Generate(root)
+			break
+		}
+		var program *IRProgram
+		if program, err = buildIR(root, optimizer); err == nil {
+			generator := NewX86IRGenerator()
+			generator.MaxCallDepth = *maxCallDepth
+			asm, err = generator.Generate(program)
+		}
+	case "llvm":
+		generator := NewLLVMGenerator()
+		generator.MaxCallDepth = *maxCallDepth
+		asm, err = generator.Generate(root)
+	case "wasm":
+		var module []byte
+		if module, err = NewWasmGenerator().Generate(root); err == nil {
+			if _, invalid := DecodeWasm(module); invalid != nil {
+				fmt.Fprintln(os.Stderr, "internal error: invalid wasm module:", invalid)
+				return 1
+			}
+			asm = string(module)
+		}
+	case "c":
+		generator := NewCGenerator()
+		generator.MaxCallDepth = *maxCallDepth
+		asm, err = generator.Generate(root)
+	case "ir":
+		var program *IRProgram
+		if program, err = buildIR(root, optimizer); err == nil {
+			var text strings.Builder
+			program.Dump(&text)
+			asm = text.String()
+		}
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, RenderError(err, source))
+		return 1
+	}
+	if *assembly || *target != "x86" {
+		if err := os.WriteFile(*output, []byte(asm), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	if err := assemble(asm, *output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// buildIR lowers root to the IR, optimizes it and verifies it. A program
+// that fails the verification is a bug of the IRBuilder or of a pass,
+// reported as an internal error.
+func buildIR(root ASTNoder, optimizer *Optimizer) (*IRProgram, error) {
+	program, err := NewIRBuilder().Build(root)
+	if err != nil {
+		return nil, err
+	}
+	optimizer.OptimizeIR(program)
+	if err := program.Verify(); err != nil {
+		return nil, fmt.Errorf("internal error: invalid IR: %v", err)
+	}
+	return program, nil
+}
+
+// inspectCommand decodes and validates a WebAssembly module and prints its
+// sections:
+//
+//	compiler inspect prog.wasm
+func inspectCommand(args []string) int {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	files := parseCommandLine(flags, args)
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: compiler inspect prog.wasm")
+		return 2
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	module, err := DecodeWasm(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, files[0]+": "+err.Error())
+		return 1
+	}
+	module.Dump(os.Stdout)
+	return 0
+}
+
+// dfaCommand writes the DFA of the lexer in the dot language of Graphviz:
+//
+//	compiler dfa [-o lexer.dot]
+func dfaCommand(args []string) int {
+	flags := flag.NewFlagSet("dfa", flag.ExitOnError)
+	output := flags.String("o", "", "output file, the standard output by default")
+	if files := parseCommandLine(flags, args); len(files) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: compiler dfa [-o lexer.dot]")
+		return 2
+	}
+	if *output == "" {
+		simpleDFA.WriteDot(os.Stdout)
+		return 0
+	}
+	var text strings.Builder
+	simpleDFA.WriteDot(&text)
+	if err := os.WriteFile(*output, []byte(text.String()), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// ll1Command reads the grammar in a file, which may be the Go source
+// documenting it in a comment, and writes a parser for it, or with -sets its
+// FIRST and FOLLOW sets. Conflicts are reported as warnings:
+//
+//	compiler ll1 [-o parser.go] [-type Parser] [-package main] [-sets] grammar
+func ll1Command(args []string) int {
+	flags := flag.NewFlagSet("ll1", flag.ExitOnError)
+	output := flags.String("o", "", "output file, the standard output by default")
+	typeName := flags.String("type", "GrammarParser", "name of the parser type")
+	pkg := flags.String("package", "main", "package of the parser")
+	sets := flags.Bool("sets", false, "print the FIRST and FOLLOW sets instead of a parser")
+	files := parseCommandLine(flags, args)
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: compiler ll1 [-o parser.go] [-type Parser] [-package main] [-sets] grammar")
+		return 2
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	source := string(data)
+	grammar, diagnostics := ReadGrammar(source)
+	if grammar == nil {
+		fmt.Fprint(os.Stderr, diagnostics.Render(source))
+		return 1
+	}
+	if *sets {
+		analysis, diagnostics := grammar.Analyze()
+		fmt.Fprint(os.Stderr, diagnostics.Render(source))
+		for _, rule := range grammar.Rules {
+			nullable := ""
+			if analysis.Nullable[rule.Name] {
+				nullable = " (may be empty)"
+			}
+			fmt.Printf("%s%s\n  FIRST:  %s\n  FOLLOW: %s\n", rule.Name, nullable, analysis.First[rule.Name], analysis.Follow[rule.Name])
+		}
+		if diagnostics.HasErrors() {
+			return 1
+		}
+		return 0
+	}
+	generator := ParserGenerator{Package: *pkg, TypeName: *typeName, Source: filepath.Base(files[0])}
+	parser, diagnostics := generator.Generate(grammar)
+	fmt.Fprint(os.Stderr, diagnostics.Render(source))
+	if parser == nil {
+		return 1
+	}
+	if *output == "" {
+		os.Stdout.Write(parser)
+		return 0
+	}
+	if err := os.WriteFile(*output, parser, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// assemble turns asm into the executable output with as and ld.
+func assemble(asm string, output string) error {
+	dir, err := os.MkdirTemp("", "compiler")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "prog.s")
+	object := filepath.Join(dir, "prog.o")
+	if err := os.WriteFile(source, []byte(asm), 0644); err != nil {
+		return err
+	}
+	for _, command := range [][]string{{"as", "-o", object, source}, {"ld", "-o", output, object}} {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %v", command[0], err)
+		}
+	}
+	return nil
+}
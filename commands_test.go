@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// samples are the programs of testdata.
+func samples(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", "*.ss"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no sample programs: %v", err)
+	}
+	return files
+}
+
+// writeScript writes script to a file of the test and returns its path.
+func writeScript(t *testing.T, script string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "prog.ss")
+	if err := os.WriteFile(file, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// capture runs f with the standard output and error redirected, and returns
+// what was written to each.
+func capture(t *testing.T, f func()) (stdout, stderr string) {
+	t.Helper()
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out, errs bytes.Buffer
+	done := make(chan bool)
+	go func() {
+		io.Copy(&out, outReader)
+		done <- true
+	}()
+	go func() {
+		io.Copy(&errs, errReader)
+		done <- true
+	}()
+	savedOut, savedErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outWriter, errWriter
+	defer func() {
+		os.Stdout, os.Stderr = savedOut, savedErr
+	}()
+	f()
+	outWriter.Close()
+	errWriter.Close()
+	<-done
+	<-done
+	return out.String(), errs.String()
+}
+
+// result is what running a program showed.
+type result struct {
+	stdout, stderr string
+	status         int
+}
+
+func runProgram(t *testing.T, args ...string) result {
+	t.Helper()
+	var r result
+	r.stdout, r.stderr = capture(t, func() {
+		r.status = runCommand(args)
+	})
+	return r
+}
+
+func TestBackendsAgree(t *testing.T) {
+	for _, file := range samples(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			want := runProgram(t, "-backend=tree", "-O0", file)
+			if want.stdout == "" {
+				t.Fatalf("the tree walker printed nothing: %s", want.stderr)
+			}
+			for _, backend := range []string{"tree", "vm", "ir"} {
+				for _, level := range []string{"-O0", "-O1", "-O2"} {
+					got := runProgram(t, "-backend="+backend, level, file)
+					if got != want {
+						t.Errorf("-backend=%s %s:\n%+v\nwant\n%+v", backend, level, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DFA is a deterministic automaton recognizing the tokens of a set of
+// rules. Runes are grouped in classes that no pattern tells apart: class i
+// holds the runes from bounds[i] up to the next bound. next is the
+// transition table by state and class, where -1 is the dead state; state 0
+// is the start. accept is the rule accepted in each state, -1 if none.
+type DFA struct {
+	Rules  []LexRule
+	bounds []rune
+	ascii  [utf8.RuneSelf]int
+	next   [][]int
+	accept []int
+}
+
+// CompileLexer builds the minimal DFA of rules.
+func CompileLexer(rules []LexRule) (*DFA, error) {
+	nfa, err := BuildNFA(rules)
+	if err != nil {
+		return nil, err
+	}
+	return nfa.Determinize(rules).Minimize(), nil
+}
+
+// MustCompileLexer is like CompileLexer but panics on an invalid pattern.
+func MustCompileLexer(rules []LexRule) *DFA {
+	d, err := CompileLexer(rules)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// class returns the class of ch.
+func (d *DFA) class(ch rune) int {
+	if ch >= 0 && ch < utf8.RuneSelf {
+		return d.ascii[ch]
+	}
+	return d.searchClass(ch)
+}
+
+func (d *DFA) searchClass(ch rune) int {
+	return sort.Search(len(d.bounds), func(i int) bool {
+		return d.bounds[i] > ch
+	}) - 1
+}
+
+func (d *DFA) setBounds(bounds []rune) {
+	d.bounds = bounds
+	for ch := rune(0); ch < utf8.RuneSelf; ch++ {
+		d.ascii[ch] = d.searchClass(ch)
+	}
+}
+
+// closure adds to set the states reached from it by epsilon transitions and
+// returns it sorted.
+func (n *NFA) closure(set []int) []int {
+	seen := make(map[int]bool, len(set))
+	work := append([]int(nil), set...)
+	set = set[:0]
+	for len(work) > 0 {
+		s := work[len(work)-1]
+		work = work[:len(work)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		set = append(set, s)
+		work = append(work, n.states[s].epsilon...)
+	}
+	sort.Ints(set)
+	return set
+}
+
+// Determinize builds the DFA of n by the subset construction. A state of
+// the DFA accepts the rule of highest priority among its NFA states.
+func (n *NFA) Determinize(rules []LexRule) *DFA {
+	d := &DFA{Rules: rules}
+	bounds := []rune{0}
+	for _, s := range n.states {
+		for _, r := range s.ranges {
+			bounds = append(bounds, r.lo)
+			if r.hi < unicode.MaxRune {
+				bounds = append(bounds, r.hi+1)
+			}
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+	unique := bounds[:1]
+	for _, b := range bounds[1:] {
+		if b != unique[len(unique)-1] {
+			unique = append(unique, b)
+		}
+	}
+	d.setBounds(unique)
+
+	ids := make(map[string]int)
+	var sets [][]int
+	state := func(set []int) int {
+		key := fmt.Sprint(set)
+		if id, ok := ids[key]; ok {
+			return id
+		}
+		ids[key] = len(sets)
+		sets = append(sets, set)
+		rule := -1
+		for _, s := range set {
+			if r := n.states[s].rule; r >= 0 && (rule < 0 || rules[r].Priority > rules[rule].Priority || rules[r].Priority == rules[rule].Priority && r < rule) {
+				rule = r
+			}
+		}
+		d.accept = append(d.accept, rule)
+		return ids[key]
+	}
+	state(n.closure([]int{n.start}))
+	for i := 0; i < len(sets); i++ {
+		moves := make([][]int, len(d.bounds))
+		for _, s := range sets[i] {
+			for _, r := range n.states[s].ranges {
+				for c := d.class(r.lo); c < len(d.bounds) && d.bounds[c] <= r.hi; c++ {
+					moves[c] = append(moves[c], n.states[s].next)
+				}
+			}
+		}
+		row := make([]int, len(d.bounds))
+		targets := make(map[string]int)
+		for c, move := range moves {
+			row[c] = -1
+			if len(move) == 0 {
+				continue
+			}
+			if c > 0 && sameInts(move, moves[c-1]) {
+				row[c] = row[c-1]
+				continue
+			}
+			key := fmt.Sprint(move)
+			target, ok := targets[key]
+			if !ok {
+				target = state(n.closure(move))
+				targets[key] = target
+			}
+			row[c] = target
+		}
+		d.next = append(d.next, row)
+	}
+	return d
+}
+
+func sameInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Minimize returns the minimal DFA equivalent to d with the algorithm of
+// Moore: states are split by the rule they accept, then as long as two
+// states of a group move to different groups on some class. Neighboring
+// classes that no state tells apart are merged before and after.
+func (d *DFA) Minimize() *DFA {
+	d.mergeClasses()
+	group := make([]int, len(d.next))
+	groups := make(map[int]int)
+	for s, rule := range d.accept {
+		if _, ok := groups[rule]; !ok {
+			groups[rule] = len(groups)
+		}
+		group[s] = groups[rule]
+	}
+	count := len(groups)
+	for {
+		ids := make(map[string]int)
+		split := make([]int, len(d.next))
+		var key []byte
+		for s, row := range d.next {
+			key = strconv.AppendInt(key[:0], int64(group[s]), 10)
+			for _, t := range row {
+				if t >= 0 {
+					t = group[t]
+				}
+				key = strconv.AppendInt(append(key, ','), int64(t), 10)
+			}
+			id, ok := ids[string(key)]
+			if !ok {
+				id = len(ids)
+				ids[string(key)] = id
+			}
+			split[s] = id
+		}
+		group = split
+		if len(ids) == count {
+			break
+		}
+		count = len(ids)
+	}
+
+	m := &DFA{Rules: d.Rules, bounds: d.bounds, ascii: d.ascii}
+	m.next = make([][]int, count)
+	m.accept = make([]int, count)
+	for s, row := range d.next {
+		g := group[s]
+		if m.next[g] != nil {
+			continue
+		}
+		m.accept[g] = d.accept[s]
+		m.next[g] = make([]int, len(row))
+		for c, t := range row {
+			m.next[g][c] = -1
+			if t >= 0 {
+				m.next[g][c] = group[t]
+			}
+		}
+	}
+	m.mergeClasses()
+	return m
+}
+
+// mergeClasses merges the neighboring classes on which every state moves to
+// the same state.
+func (d *DFA) mergeClasses() {
+	same := func(a, b int) bool {
+		for _, row := range d.next {
+			if row[a] != row[b] {
+				return false
+			}
+		}
+		return true
+	}
+	var bounds []rune
+	var columns []int
+	for c, b := range d.bounds {
+		if c > 0 && same(c, columns[len(columns)-1]) {
+			continue
+		}
+		bounds = append(bounds, b)
+		columns = append(columns, c)
+	}
+	for s, row := range d.next {
+		merged := make([]int, len(columns))
+		for i, c := range columns {
+			merged[i] = row[c]
+		}
+		d.next[s] = merged
+	}
+	d.setBounds(bounds)
+}
+
+// States is the number of states of d.
+func (d *DFA) States() int {
+	return len(d.next)
+}
+
+// longestMatch runs d on the runes peek returns from index 0 until it
+// returns false. It returns the length in runes of the longest token at the
+// start and its rule, or rule -1 if there is none.
+func (d *DFA) longestMatch(peek func(i int) (rune, bool)) (end int, rule int) {
+	rule = -1
+	state := 0
+	for i := 0; ; i++ {
+		ch, ok := peek(i)
+		if !ok {
+			break
+		}
+		if state = d.next[state][d.class(ch)]; state < 0 {
+			break
+		}
+		if d.accept[state] >= 0 {
+			end, rule = i+1, d.accept[state]
+		}
+	}
+	return end, rule
+}
+
+// WriteDot writes d in the dot language of Graphviz. Accepting states are
+// double circles labeled with their token type and edges with the runes
+// they are taken on.
+func (d *DFA) WriteDot(w io.Writer) {
+	fmt.Fprintln(w, "digraph dfa {")
+	fmt.Fprintln(w, "\trankdir=LR;")
+	fmt.Fprintln(w, "\tnode [shape=circle];")
+	for s := range d.next {
+		if rule := d.accept[s]; rule >= 0 {
+			fmt.Fprintf(w, "\t%d [shape=doublecircle, label=%q];\n", s, fmt.Sprintf("%d\n%s", s, d.Rules[rule].Type))
+		} else {
+			fmt.Fprintf(w, "\t%d;\n", s)
+		}
+	}
+	for s, row := range d.next {
+		var targets []int
+		labels := make(map[int][]string)
+		for c, t := range row {
+			if t < 0 {
+				continue
+			}
+			if labels[t] == nil {
+				targets = append(targets, t)
+			}
+			hi := rune(unicode.MaxRune)
+			if c+1 < len(d.bounds) {
+				hi = d.bounds[c+1] - 1
+			}
+			labels[t] = append(labels[t], dotRange(d.bounds[c], hi))
+		}
+		for _, t := range targets {
+			fmt.Fprintf(w, "\t%d -> %d [label=%q];\n", s, t, strings.Join(labels[t], " "))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func dotRange(lo, hi rune) string {
+	quote := func(ch rune) string {
+		s := strconv.QuoteRuneToASCII(ch)
+		return s[1 : len(s)-1]
+	}
+	if lo == hi {
+		return quote(lo)
+	}
+	return quote(lo) + "-" + quote(hi)
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+// match returns the rule d matches on the whole of s, or -1.
+func match(d *DFA, s string) int {
+	runes := []rune(s)
+	end, rule := d.longestMatch(func(i int) (rune, bool) {
+		if i < len(runes) {
+			return runes[i], true
+		}
+		return 0, false
+	})
+	if end != len(runes) {
+		return -1
+	}
+	return rule
+}
+
+func TestMinimize(t *testing.T) {
+	// The subset construction of (a|b)*abb gives 5 states, two of which
+	// are equivalent.
+	rules := []LexRule{{TokenType("ABB"), `(a|b)*abb`, 0}}
+	nfa, err := BuildNFA(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := nfa.Determinize(rules)
+	m := d.Minimize()
+	if d.States() != 5 || m.States() != 4 {
+		t.Errorf("%d states minimized to %d, want 5 to 4", d.States(), m.States())
+	}
+	for _, s := range []string{"abb", "aabb", "babb", "ababb", "bbbabb"} {
+		if match(m, s) != 0 {
+			t.Errorf("%q is not matched", s)
+		}
+	}
+	for _, s := range []string{"", "ab", "abba", "bb", "abc"} {
+		if match(m, s) != -1 {
+			t.Errorf("%q is matched", s)
+		}
+	}
+}
+
+func TestMinimizeKeepsRules(t *testing.T) {
+	// The states after a and after b move alike but accept different rules.
+	rules := []LexRule{{TokenType("A"), `a`, 0}, {TokenType("B"), `b`, 0}, {TokenType("AB"), `[ab]c`, 0}}
+	m := MustCompileLexer(rules)
+	if m.States() != 4 {
+		t.Errorf("%d states, want 4", m.States())
+	}
+	for s, want := range map[string]int{"a": 0, "b": 1, "ac": 2, "bc": 2, "c": -1} {
+		if got := match(m, s); got != want {
+			t.Errorf("%q matches rule %d, want %d", s, got, want)
+		}
+	}
+}
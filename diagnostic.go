@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	Severity_Error   = Severity("error")
+	Severity_Warning = Severity("warning")
+	Severity_Note    = Severity("note")
+)
+
+type DiagnosticCode string
+
+const (
+	DiagnosticCode_UnknownCharacter    = DiagnosticCode("E0001")
+	DiagnosticCode_UnterminatedLiteral = DiagnosticCode("E0002")
+	DiagnosticCode_UnterminatedComment = DiagnosticCode("E0003")
+	DiagnosticCode_InvalidEscape       = DiagnosticCode("E0004")
+	DiagnosticCode_InvalidCharLiteral  = DiagnosticCode("E0005")
+	DiagnosticCode_InvalidNumber       = DiagnosticCode("E0006")
+	DiagnosticCode_NumberOutOfRange    = DiagnosticCode("E0007")
+
+	DiagnosticCode_UnknownStatement   = DiagnosticCode("E0101")
+	DiagnosticCode_ExpectedExpression = DiagnosticCode("E0102")
+	DiagnosticCode_ExpectedName       = DiagnosticCode("E0103")
+	DiagnosticCode_ExpectedSemiColon  = DiagnosticCode("E0104")
+	DiagnosticCode_ExpectedRightParen = DiagnosticCode("E0105")
+	DiagnosticCode_ExpectedLeftParen  = DiagnosticCode("E0106")
+	DiagnosticCode_ExpectedRightBrace = DiagnosticCode("E0107")
+	DiagnosticCode_ExpectedStatement  = DiagnosticCode("E0108")
+	DiagnosticCode_JumpOutsideLoop    = DiagnosticCode("E0109")
+	DiagnosticCode_ReturnOutsideFunc  = DiagnosticCode("E0110")
+	DiagnosticCode_NestedFunction     = DiagnosticCode("E0111")
+	DiagnosticCode_ExpectedType       = DiagnosticCode("E0112")
+	DiagnosticCode_BacktrackLimit     = DiagnosticCode("E0113")
+	DiagnosticCode_UnexpectedToken    = DiagnosticCode("E0114")
+
+	DiagnosticCode_UnknownVariable = DiagnosticCode("E0201")
+	DiagnosticCode_DivisionByZero  = DiagnosticCode("E0202")
+	DiagnosticCode_InvalidNode     = DiagnosticCode("E0203")
+	DiagnosticCode_UnknownFunction = DiagnosticCode("E0204")
+	DiagnosticCode_StackOverflow   = DiagnosticCode("E0205")
+	DiagnosticCode_ArgumentCount   = DiagnosticCode("E0206")
+	DiagnosticCode_ConversionError = DiagnosticCode("E0207")
+
+	DiagnosticCode_Undeclared    = DiagnosticCode("E0301")
+	DiagnosticCode_Redeclaration = DiagnosticCode("E0302")
+	DiagnosticCode_NotAFunction  = DiagnosticCode("E0303")
+	DiagnosticCode_NotAVariable  = DiagnosticCode("E0304")
+	DiagnosticCode_Shadowing     = DiagnosticCode("W0301")
+
+	DiagnosticCode_TypeMismatch      = DiagnosticCode("E0401")
+	DiagnosticCode_InvalidOperand    = DiagnosticCode("E0402")
+	DiagnosticCode_InvalidConversion = DiagnosticCode("E0403")
+	DiagnosticCode_VoidValue         = DiagnosticCode("E0404")
+	DiagnosticCode_InvalidReturn     = DiagnosticCode("E0405")
+	DiagnosticCode_MissingReturn     = DiagnosticCode("E0406")
+
+	DiagnosticCode_CodeTooLarge = DiagnosticCode("E0501")
+	DiagnosticCode_Unsupported  = DiagnosticCode("E0502")
+
+	DiagnosticCode_GrammarSyntax   = DiagnosticCode("E0601")
+	DiagnosticCode_UndefinedRule   = DiagnosticCode("E0602")
+	DiagnosticCode_LeftRecursion   = DiagnosticCode("E0603")
+	DiagnosticCode_EmptyRepetition = DiagnosticCode("E0604")
+	DiagnosticCode_UnknownTerminal = DiagnosticCode("E0605")
+	DiagnosticCode_GrammarConflict = DiagnosticCode("W0601")
+)
+
+type Note struct {
+	Message string
+	Span    Span
+}
+
+// Diagnostic is a problem found in a script. It is returned as an error by
+// SimpleParser.Parse, SimpleScript.Evaluate, BytecodeCompiler.Compile and
+// VM.Run.
+type Diagnostic struct {
+	Severity Severity
+	Code     DiagnosticCode
+	Message  string
+	Span     Span
+	Notes    []Note
+}
+
+func NewDiagnostic(severity Severity, code DiagnosticCode, message string, span Span) *Diagnostic {
+	return &Diagnostic{Severity: severity, Code: code, Message: message, Span: span}
+}
+
+func (d *Diagnostic) AddNote(message string, span Span) *Diagnostic {
+	d.Notes = append(d.Notes, Note{Message: message, Span: span})
+	return d
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Span.Start, d.Severity, d.Code, d.Message)
+}
+
+// Render formats the diagnostic together with the offending source line and
+// a caret underline below the span.
+func (d *Diagnostic) Render(source string) string {
+	var b strings.Builder
+	b.WriteString(d.Error())
+	b.WriteString("\n")
+	renderSnippet(&b, source, d.Span)
+	for _, note := range d.Notes {
+		fmt.Fprintf(&b, "%s: %s: %s\n", note.Span.Start, Severity_Note, note.Message)
+		renderSnippet(&b, source, note.Span)
+	}
+	return b.String()
+}
+
+func renderSnippet(b *strings.Builder, source string, span Span) {
+	start := span.Start.Offset
+	if span.Start.Line == 0 || start > len(source) {
+		return
+	}
+	lineStart := strings.LastIndexByte(source[:start], '\n') + 1
+	lineEnd := strings.IndexByte(source[start:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(source)
+	} else {
+		lineEnd += start
+	}
+	line := strings.TrimRight(source[lineStart:lineEnd], "\r")
+	gutter := fmt.Sprintf("%d", span.Start.Line)
+	fmt.Fprintf(b, " %s | %s\n", gutter, line)
+
+	fmt.Fprintf(b, " %s | ", strings.Repeat(" ", len(gutter)))
+	for _, ch := range source[lineStart:start] {
+		if ch == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	width := 1
+	if span.End.Line == span.Start.Line && span.End.Column > span.Start.Column {
+		width = span.End.Column - span.Start.Column
+	} else if span.End.Line > span.Start.Line {
+		width = len([]rune(source[start:lineEnd]))
+		if width == 0 {
+			width = 1
+		}
+	}
+	b.WriteString(strings.Repeat("^", width))
+	b.WriteString("\n")
+}
+
+// Diagnostics collects every problem found in one pass over a script.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	messages := make([]string, len(ds))
+	for i, d := range ds {
+		messages[i] = d.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == Severity_Error {
+			return true
+		}
+	}
+	return false
+}
+
+func (ds Diagnostics) Render(source string) string {
+	var b strings.Builder
+	for _, d := range ds {
+		b.WriteString(d.Render(source))
+	}
+	return b.String()
+}
+
+// RenderError renders err against source if it is a diagnostic, and falls
+// back to its plain message otherwise.
+func RenderError(err error, source string) string {
+	switch e := err.(type) {
+	case *Diagnostic:
+		return e.Render(source)
+	case Diagnostics:
+		return e.Render(source)
+	}
+	return err.Error() + "\n"
+}
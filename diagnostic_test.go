@@ -0,0 +1,10 @@
+package main
+
+import "testing"
+
+func TestRenderWithoutPosition(t *testing.T) {
+	d := NewDiagnostic(Severity_Warning, DiagnosticCode_GrammarConflict, "conflict", Span{})
+	if got, want := d.Render("a -> b\n"), d.Error()+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,621 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type GrammarExprKind int
+
+const (
+	GrammarExpr_Terminal GrammarExprKind = iota
+	GrammarExpr_Rule
+	GrammarExpr_Sequence
+	GrammarExpr_Choice
+	GrammarExpr_Optional
+	GrammarExpr_Repeat
+	GrammarExpr_RepeatOne
+)
+
+// GrammarExpr is a part of the body of a rule. Terminals and rules have a
+// Name: a quoted token like 'if' or a token type like Id for the former, a
+// rule name starting with a lower case letter for the latter. The other
+// kinds have Items, a single one for the repetitions and Optional.
+type GrammarExpr struct {
+	Kind  GrammarExprKind
+	Name  string
+	Items []*GrammarExpr
+	Span  Span
+}
+
+type GrammarRule struct {
+	Name string
+	Body *GrammarExpr
+	Span Span
+}
+
+// Grammar is a grammar in EBNF. Its first rule is the start rule.
+type Grammar struct {
+	Rules []*GrammarRule
+	rules map[string]*GrammarRule
+}
+
+// grammarEnd is the terminal that follows the start rule.
+const grammarEnd = "EOF"
+
+var grammarRuleStart = regexp.MustCompile(`^\s*[A-Za-z_]\w*\s*->`)
+
+// grammarText blanks everything in src but its grammar, so that positions
+// in the result are those of src. The grammar starts at the first line of
+// the form name -> ... and ends at the end of the comment it is in, if any.
+// The leading * or // of comment lines are blanked too, which lets a
+// grammar be read from the comment documenting a parser. Each blanked byte
+// becomes a space to keep the offsets.
+func grammarText(src string) string {
+	lines := strings.SplitAfter(src, "\n")
+	blank := func(s string) string {
+		b := []byte(s)
+		for i := range b {
+			if b[i] != '\n' && b[i] != '\t' {
+				b[i] = ' '
+			}
+		}
+		return string(b)
+	}
+	started, ended := false, false
+	for i, line := range lines {
+		decoration := 0
+		if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "//") {
+			decoration = len(line) - len(trimmed) + 2
+		} else if strings.HasPrefix(trimmed, "*") && !strings.HasPrefix(trimmed, "*/") {
+			decoration = len(line) - len(trimmed) + 1
+		}
+		if !started && grammarRuleStart.MatchString(line[decoration:]) {
+			started = true
+		}
+		if end := strings.Index(line, "*/"); started && !ended && end >= 0 {
+			ended = true
+			lines[i] = blank(line[:decoration]) + line[decoration:end] + blank(line[end:])
+			continue
+		}
+		if !started || ended {
+			lines[i] = blank(line)
+		} else {
+			lines[i] = blank(line[:decoration]) + line[decoration:]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// grammarToken is a token of the EBNF notation: a name, a quoted terminal,
+// -> or one of | ( ) * + ?.
+type grammarToken struct {
+	text string
+	span Span
+}
+
+func scanGrammar(src string) ([]grammarToken, Diagnostics) {
+	var tokens []grammarToken
+	var errors Diagnostics
+	pos := NewPosition()
+	for i := 0; i < len(src); {
+		start := pos
+		ch, n := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(ch):
+			pos = pos.advance(ch, n)
+			i += n
+			continue
+		case unicode.IsLetter(ch) || ch == '_':
+			for i+n < len(src) {
+				next, size := utf8.DecodeRuneInString(src[i+n:])
+				if !unicode.IsLetter(next) && !unicode.IsDigit(next) && next != '_' {
+					break
+				}
+				n += size
+			}
+		case ch == '\'' || ch == '"':
+			for i+n < len(src) && src[i+n] != byte(ch) && src[i+n] != '\n' {
+				n++
+			}
+			if i+n == len(src) || src[i+n] != byte(ch) || n == 1 {
+				end := start.endOf(src[i : i+n])
+				errors = append(errors, NewDiagnostic(Severity_Error, DiagnosticCode_GrammarSyntax, "unterminated or empty terminal", Span{start, end}))
+			}
+			n++
+		case ch == '-' && i+1 < len(src) && src[i+1] == '>':
+			n = 2
+		case strings.ContainsRune("|()*+?", ch):
+		case ch == utf8.RuneError && n == 1:
+			errors = append(errors, NewDiagnostic(Severity_Error, DiagnosticCode_GrammarSyntax, fmt.Sprintf("invalid UTF-8 byte %#x in grammar", src[i]), Span{start, start.advance(ch, n)}))
+		default:
+			errors = append(errors, NewDiagnostic(Severity_Error, DiagnosticCode_GrammarSyntax, fmt.Sprintf("unexpected %q in grammar", ch), Span{start, start.advance(ch, n)}))
+		}
+		if i+n > len(src) {
+			n = len(src) - i
+		}
+		text := src[i : i+n]
+		pos = pos.endOf(text)
+		tokens = append(tokens, grammarToken{text, Span{start, pos}})
+		i += n
+	}
+	return tokens, errors
+}
+
+// grammarParser reads the EBNF notation:
+//
+//	grammar  = rule { rule }
+//	rule     = name "->" choice
+//	choice   = sequence { "|" sequence }
+//	sequence = { item }
+//	item     = ( name | terminal | "(" choice ")" ) [ "*" | "+" | "?" ]
+//
+// A sequence ends before the name of the next rule.
+type grammarParser struct {
+	tokens []grammarToken
+	pos    int
+}
+
+func (p *grammarParser) peek(offset int) string {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset].text
+	}
+	return ""
+}
+
+func (p *grammarParser) fail(message string) {
+	span := Span{}
+	if p.pos < len(p.tokens) {
+		span = p.tokens[p.pos].span
+	} else if len(p.tokens) > 0 {
+		end := p.tokens[len(p.tokens)-1].span.End
+		span = Span{end, end}
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_GrammarSyntax, message, span))
+}
+
+func isGrammarName(text string) bool {
+	ch := []rune(text + " ")[0]
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func (p *grammarParser) rule() *GrammarRule {
+	name := p.tokens[p.pos]
+	if !isGrammarName(name.text) || p.peek(1) != "->" {
+		p.fail("expecting a rule name and ->")
+	}
+	p.pos += 2
+	body := p.choice()
+	return &GrammarRule{Name: name.text, Body: body, Span: joinSpan(name.span, body.Span)}
+}
+
+func (p *grammarParser) choice() *GrammarExpr {
+	first := p.sequence()
+	if p.peek(0) != "|" {
+		return first
+	}
+	choice := &GrammarExpr{Kind: GrammarExpr_Choice, Items: []*GrammarExpr{first}, Span: first.Span}
+	for p.peek(0) == "|" {
+		p.pos++
+		next := p.sequence()
+		choice.Items = append(choice.Items, next)
+		choice.Span = joinSpan(choice.Span, next.Span)
+	}
+	return choice
+}
+
+func (p *grammarParser) sequence() *GrammarExpr {
+	// An empty sequence is at the next token, or at the end of the last one
+	// at the end of the grammar.
+	sequence := &GrammarExpr{Kind: GrammarExpr_Sequence}
+	if p.pos < len(p.tokens) {
+		start := p.tokens[p.pos].span.Start
+		sequence.Span = Span{start, start}
+	} else if p.pos > 0 {
+		end := p.tokens[p.pos-1].span.End
+		sequence.Span = Span{end, end}
+	}
+	for p.pos < len(p.tokens) {
+		text := p.peek(0)
+		if text == "|" || text == ")" || isGrammarName(text) && p.peek(1) == "->" {
+			break
+		}
+		item := p.item()
+		if len(sequence.Items) == 0 {
+			sequence.Span = item.Span
+		}
+		sequence.Items = append(sequence.Items, item)
+		sequence.Span = joinSpan(sequence.Span, item.Span)
+	}
+	if len(sequence.Items) == 1 {
+		return sequence.Items[0]
+	}
+	return sequence
+}
+
+func (p *grammarParser) item() *GrammarExpr {
+	token := p.tokens[p.pos]
+	var item *GrammarExpr
+	switch {
+	case token.text == "(":
+		p.pos++
+		item = p.choice()
+		if p.peek(0) != ")" {
+			p.fail("expecting )")
+		}
+		item.Span = joinSpan(token.span, p.tokens[p.pos].span)
+	case token.text[0] == '\'' || token.text[0] == '"':
+		item = &GrammarExpr{Kind: GrammarExpr_Terminal, Name: "'" + token.text[1:len(token.text)-1] + "'", Span: token.span}
+	case isGrammarName(token.text) && unicode.IsUpper([]rune(token.text)[0]):
+		item = &GrammarExpr{Kind: GrammarExpr_Terminal, Name: token.text, Span: token.span}
+	case isGrammarName(token.text):
+		item = &GrammarExpr{Kind: GrammarExpr_Rule, Name: token.text, Span: token.span}
+	default:
+		p.fail("unexpected " + token.text)
+	}
+	p.pos++
+	for {
+		kind := GrammarExpr_Optional
+		switch p.peek(0) {
+		case "*":
+			kind = GrammarExpr_Repeat
+		case "+":
+			kind = GrammarExpr_RepeatOne
+		case "?":
+		default:
+			return item
+		}
+		item = &GrammarExpr{Kind: kind, Items: []*GrammarExpr{item}, Span: joinSpan(item.Span, p.tokens[p.pos].span)}
+		p.pos++
+	}
+}
+
+// ReadGrammar reads the grammar in src, which may be the source of a
+// program documenting its grammar in a comment, as told by grammarText.
+func ReadGrammar(src string) (g *Grammar, errors Diagnostics) {
+	tokens, errors := scanGrammar(grammarText(src))
+	if len(errors) > 0 {
+		return nil, errors
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			g, errors = nil, Diagnostics{d}
+		}
+	}()
+	p := &grammarParser{tokens: tokens}
+	g = &Grammar{rules: make(map[string]*GrammarRule)}
+	if len(tokens) == 0 {
+		p.fail("no grammar rule found")
+	}
+	for p.pos < len(tokens) {
+		rule := p.rule()
+		if previous, ok := g.rules[rule.Name]; ok {
+			d := NewDiagnostic(Severity_Error, DiagnosticCode_GrammarSyntax, "rule "+rule.Name+" is defined twice", rule.Span)
+			panic(d.AddNote("first defined here", previous.Span))
+		}
+		g.Rules = append(g.Rules, rule)
+		g.rules[rule.Name] = rule
+	}
+	return g, nil
+}
+
+// grammarSet is a set of terminals.
+type grammarSet map[string]bool
+
+func (s grammarSet) add(other grammarSet) bool {
+	changed := false
+	for t := range other {
+		if !s[t] {
+			s[t] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s grammarSet) intersect(other grammarSet) grammarSet {
+	common := make(grammarSet)
+	for t := range s {
+		if other[t] {
+			common[t] = true
+		}
+	}
+	return common
+}
+
+func (s grammarSet) sorted() []string {
+	terminals := make([]string, 0, len(s))
+	for t := range s {
+		terminals = append(terminals, t)
+	}
+	sort.Strings(terminals)
+	return terminals
+}
+
+func (s grammarSet) String() string {
+	return strings.Join(s.sorted(), " ")
+}
+
+// GrammarAnalysis holds the sets computed on a grammar. Predict gives, for
+// each choice, the terminals that select each alternative, and for each
+// optional or repeated part the ones that enter it. Conflicts are the
+// choices whose alternatives, or the parts whose entry and exit, share a
+// terminal.
+type GrammarAnalysis struct {
+	Nullable  map[string]bool
+	First     map[string]grammarSet
+	Follow    map[string]grammarSet
+	Predict   map[*GrammarExpr][]grammarSet
+	Conflicts map[*GrammarExpr]bool
+}
+
+func (a *GrammarAnalysis) nullable(e *GrammarExpr) bool {
+	switch e.Kind {
+	case GrammarExpr_Terminal:
+		return false
+	case GrammarExpr_Rule:
+		return a.Nullable[e.Name]
+	case GrammarExpr_Sequence:
+		for _, item := range e.Items {
+			if !a.nullable(item) {
+				return false
+			}
+		}
+		return true
+	case GrammarExpr_Choice:
+		for _, item := range e.Items {
+			if a.nullable(item) {
+				return true
+			}
+		}
+		return false
+	case GrammarExpr_RepeatOne:
+		return a.nullable(e.Items[0])
+	}
+	return true
+}
+
+func (a *GrammarAnalysis) first(e *GrammarExpr) grammarSet {
+	set := make(grammarSet)
+	switch e.Kind {
+	case GrammarExpr_Terminal:
+		set[e.Name] = true
+	case GrammarExpr_Rule:
+		set.add(a.First[e.Name])
+	case GrammarExpr_Sequence:
+		for _, item := range e.Items {
+			set.add(a.first(item))
+			if !a.nullable(item) {
+				break
+			}
+		}
+	default:
+		for _, item := range e.Items {
+			set.add(a.first(item))
+		}
+	}
+	return set
+}
+
+// follow adds after, the terminals that may follow e, to the FOLLOW sets of
+// the rules in e and, if predict is true, records the predict sets of the
+// choices and optional parts of e. It reports whether a FOLLOW set grew.
+func (a *GrammarAnalysis) follow(e *GrammarExpr, after grammarSet, predict bool) bool {
+	changed := false
+	switch e.Kind {
+	case GrammarExpr_Terminal:
+	case GrammarExpr_Rule:
+		changed = a.Follow[e.Name].add(after)
+	case GrammarExpr_Sequence:
+		for i := len(e.Items) - 1; i >= 0; i-- {
+			item := e.Items[i]
+			if a.follow(item, after, predict) {
+				changed = true
+			}
+			if a.nullable(item) {
+				next := a.first(item)
+				next.add(after)
+				after = next
+			} else {
+				after = a.first(item)
+			}
+		}
+	case GrammarExpr_Choice:
+		var sets []grammarSet
+		for _, item := range e.Items {
+			if a.follow(item, after, predict) {
+				changed = true
+			}
+			set := a.first(item)
+			if a.nullable(item) {
+				set.add(after)
+			}
+			sets = append(sets, set)
+		}
+		if predict {
+			a.Predict[e] = sets
+		}
+	default:
+		body := e.Items[0]
+		inner := after
+		if e.Kind != GrammarExpr_Optional {
+			inner = a.first(body)
+			inner.add(after)
+		}
+		changed = a.follow(body, inner, predict)
+		if predict {
+			a.Predict[e] = []grammarSet{a.first(body), after}
+		}
+	}
+	return changed
+}
+
+// Analyze computes the nullable rules and the FIRST, FOLLOW and predict
+// sets of g. It reports undefined rules, left recursion and repetitions of
+// parts that may be empty as errors, and LL(1) conflicts as warnings.
+func (g *Grammar) Analyze() (*GrammarAnalysis, Diagnostics) {
+	a := &GrammarAnalysis{
+		Nullable:  make(map[string]bool),
+		First:     make(map[string]grammarSet),
+		Follow:    make(map[string]grammarSet),
+		Predict:   make(map[*GrammarExpr][]grammarSet),
+		Conflicts: make(map[*GrammarExpr]bool),
+	}
+	var diagnostics Diagnostics
+	for _, rule := range g.Rules {
+		a.First[rule.Name] = make(grammarSet)
+		a.Follow[rule.Name] = make(grammarSet)
+	}
+	// Undefined rules are reported and then taken as matching nothing.
+	g.walk(func(rule *GrammarRule, e *GrammarExpr) {
+		if e.Kind == GrammarExpr_Rule && g.rules[e.Name] == nil {
+			diagnostics = append(diagnostics, NewDiagnostic(Severity_Error, DiagnosticCode_UndefinedRule, "undefined rule "+e.Name, e.Span))
+			a.First[e.Name] = make(grammarSet)
+			a.Follow[e.Name] = make(grammarSet)
+		}
+	})
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.Rules {
+			if !a.Nullable[rule.Name] && a.nullable(rule.Body) {
+				a.Nullable[rule.Name] = true
+				changed = true
+			}
+			if a.First[rule.Name].add(a.first(rule.Body)) {
+				changed = true
+			}
+		}
+	}
+	a.Follow[g.Rules[0].Name][grammarEnd] = true
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.Rules {
+			if a.follow(rule.Body, a.Follow[rule.Name], false) {
+				changed = true
+			}
+		}
+	}
+	for _, rule := range g.Rules {
+		a.follow(rule.Body, a.Follow[rule.Name], true)
+	}
+
+	diagnostics = append(diagnostics, g.leftRecursion(a)...)
+	g.walk(func(rule *GrammarRule, e *GrammarExpr) {
+		sets := a.Predict[e]
+		switch e.Kind {
+		case GrammarExpr_Choice:
+			for i := range sets {
+				for j := i + 1; j < len(sets); j++ {
+					if common := sets[i].intersect(sets[j]); len(common) > 0 {
+						a.Conflicts[e] = true
+						d := NewDiagnostic(Severity_Warning, DiagnosticCode_GrammarConflict, fmt.Sprintf("LL(1) conflict in %s: alternatives %d and %d both start with %s", rule.Name, i+1, j+1, common), e.Items[j].Span)
+						diagnostics = append(diagnostics, d.AddNote(fmt.Sprintf("alternative %d", i+1), e.Items[i].Span))
+					}
+				}
+			}
+		case GrammarExpr_Optional, GrammarExpr_Repeat, GrammarExpr_RepeatOne:
+			if e.Kind != GrammarExpr_Optional && a.nullable(e.Items[0]) {
+				diagnostics = append(diagnostics, NewDiagnostic(Severity_Error, DiagnosticCode_EmptyRepetition, "repeated part of "+rule.Name+" may be empty", e.Span))
+			}
+			if common := sets[0].intersect(sets[1]); len(common) > 0 {
+				a.Conflicts[e] = true
+				diagnostics = append(diagnostics, NewDiagnostic(Severity_Warning, DiagnosticCode_GrammarConflict, fmt.Sprintf("LL(1) conflict in %s: %s may enter or follow this part", rule.Name, common), e.Span))
+			}
+		}
+	})
+	return a, diagnostics
+}
+
+// walk calls visit on every expression of g, parents first.
+func (g *Grammar) walk(visit func(rule *GrammarRule, e *GrammarExpr)) {
+	var walk func(rule *GrammarRule, e *GrammarExpr)
+	walk = func(rule *GrammarRule, e *GrammarExpr) {
+		visit(rule, e)
+		for _, item := range e.Items {
+			walk(rule, item)
+		}
+	}
+	for _, rule := range g.Rules {
+		walk(rule, rule.Body)
+	}
+}
+
+// leftmost adds to rules the rules that e may start with.
+func (a *GrammarAnalysis) leftmost(e *GrammarExpr, rules map[string]*GrammarExpr) {
+	switch e.Kind {
+	case GrammarExpr_Rule:
+		if rules[e.Name] == nil {
+			rules[e.Name] = e
+		}
+	case GrammarExpr_Sequence:
+		for _, item := range e.Items {
+			a.leftmost(item, rules)
+			if !a.nullable(item) {
+				break
+			}
+		}
+	case GrammarExpr_Terminal:
+	default:
+		for _, item := range e.Items {
+			a.leftmost(item, rules)
+		}
+	}
+}
+
+// leftRecursion reports each rule that may start with itself, directly or
+// through other rules, once per cycle.
+func (g *Grammar) leftRecursion(a *GrammarAnalysis) Diagnostics {
+	edges := make(map[string]map[string]*GrammarExpr)
+	for _, rule := range g.Rules {
+		edges[rule.Name] = make(map[string]*GrammarExpr)
+		a.leftmost(rule.Body, edges[rule.Name])
+	}
+	var diagnostics Diagnostics
+	reported := make(map[string]bool)
+	for _, rule := range g.Rules {
+		if reported[rule.Name] {
+			continue
+		}
+		// Search breadth first for the shortest path back to the rule.
+		from := map[string]string{}
+		queue := []string{rule.Name}
+		for len(queue) > 0 && from[rule.Name] == "" {
+			name := queue[0]
+			queue = queue[1:]
+			var targets []string
+			for target := range edges[name] {
+				targets = append(targets, target)
+			}
+			sort.Strings(targets)
+			for _, target := range targets {
+				if _, seen := from[target]; !seen {
+					from[target] = name
+					queue = append(queue, target)
+				}
+			}
+		}
+		if from[rule.Name] == "" {
+			continue
+		}
+		cycle := []string{rule.Name}
+		for name := from[rule.Name]; name != rule.Name; name = from[name] {
+			cycle = append([]string{name}, cycle...)
+		}
+		cycle = append([]string{rule.Name}, cycle...)
+		for _, name := range cycle {
+			reported[name] = true
+		}
+		last := cycle[len(cycle)-2]
+		diagnostics = append(diagnostics, NewDiagnostic(Severity_Error, DiagnosticCode_LeftRecursion, "left recursion: "+strings.Join(cycle, " -> "), edges[last][rule.Name].Span))
+	}
+	return diagnostics
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestEmptyAlternativeSpan(t *testing.T) {
+	tests := []struct {
+		grammar string
+		want    Position
+	}{
+		{"a -> c 'y'\nc -> 'y' | ", Position{Offset: 21, Line: 2, Column: 11}},
+		{"a -> c 'y'\nc -> 'y' |", Position{Offset: 21, Line: 2, Column: 11}},
+		{"a -> c 'y'\nc -> | 'y'", Position{Offset: 16, Line: 2, Column: 6}},
+	}
+	for _, test := range tests {
+		grammar, diagnostics := ReadGrammar(test.grammar)
+		if grammar == nil {
+			t.Fatalf("%q: %v", test.grammar, diagnostics)
+		}
+		_, diagnostics = grammar.Analyze()
+		if len(diagnostics) != 1 || diagnostics[0].Code != DiagnosticCode_GrammarConflict {
+			t.Fatalf("%q: got %v, want a conflict", test.grammar, diagnostics)
+		}
+		var empty Span
+		for _, span := range append([]Span{diagnostics[0].Span}, diagnostics[0].Notes[0].Span) {
+			if span.End == span.Start {
+				empty = span
+			}
+		}
+		if empty.Start != test.want {
+			t.Errorf("%q: empty alternative at %+v, want %+v", test.grammar, empty.Start, test.want)
+		}
+	}
+}
+
+func TestScanGrammarInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		grammar string
+		message string
+		want    Span
+	}{
+		{"a -> '\xff' b\nb -> 'y' $\n", "unexpected '$' in grammar", Span{Position{20, 2, 10}, Position{21, 2, 11}}},
+		{"a -> \xff b\nb -> 'y'\n", "invalid UTF-8 byte 0xff in grammar", Span{Position{5, 1, 6}, Position{6, 1, 7}}},
+		{"a -> 'é\xfe' \xe2\x82 b", "invalid UTF-8 byte 0xe2 in grammar", Span{Position{11, 1, 11}, Position{12, 1, 12}}},
+	}
+	for _, test := range tests {
+		_, diagnostics := ReadGrammar(test.grammar)
+		if len(diagnostics) == 0 || diagnostics[0].Message != test.message || diagnostics[0].Span != test.want {
+			t.Errorf("%q: got %v, want %s at %+v", test.grammar, diagnostics, test.message, test.want)
+		}
+	}
+
+	src := "é -> '\xff' ü | \"ö\xc3\" é\nü -> 'y'"
+	tokens, errors := scanGrammar(src)
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+	for _, token := range tokens {
+		start, end := token.span.Start.Offset, token.span.End.Offset
+		if src[start:end] != token.text {
+			t.Errorf("%q has offsets %d to %d", token.text, start, end)
+		}
+	}
+	if last := tokens[len(tokens)-1].span; last.End != (Position{len(src), 2, 9}) {
+		t.Errorf("last token ends at %+v", last.End)
+	}
+}
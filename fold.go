@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// foldAST replaces the expressions of root whose operands are all literals
+// by the literal of their value, and && and || with a literal left operand
+// by the operand that decides them. Operations that fail at run time, like a
+// division by zero, and floats that are not finite are left alone. It
+// reports whether root changed.
+func foldAST(root ASTNoder) bool {
+	changed := false
+	for i, child := range root.GetChildren() {
+		if foldAST(child) {
+			changed = true
+		}
+		if folded := foldExpression(child); folded != nil {
+			root.SetChild(i, folded)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func isLiteral(node ASTNoder) bool {
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		return true
+	}
+	return false
+}
+
+// foldExpression returns what replaces node, or nil to keep it.
+func foldExpression(node ASTNoder) ASTNoder {
+	children := node.GetChildren()
+	var v Value
+	var err error
+	switch node.GetType() {
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		if !isLiteral(children[0]) || !isLiteral(children[1]) {
+			return nil
+		}
+		v, err = binaryOp(node.GetText(), children[0].GetValue(), children[1].GetValue())
+	case ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		if !isLiteral(children[0]) {
+			return nil
+		}
+		if children[0].GetValue().Bool == (node.GetType() == ASTNodeType_LogicalOr) {
+			return children[0]
+		}
+		return children[1]
+	case ASTNodeType_Unary:
+		if node.GetText() == "+" {
+			return children[0]
+		}
+		if !isLiteral(children[0]) {
+			return nil
+		}
+		v = unaryOp(node.GetText(), children[0].GetValue())
+	case ASTNodeType_Conversion:
+		if !isLiteral(children[0]) {
+			return nil
+		}
+		v, err = convertValue(children[0].GetValue(), node.GetDataType())
+	default:
+		return nil
+	}
+	if err != nil || v.Type == DataType_Float && (math.IsInf(v.Float, 0) || math.IsNaN(v.Float)) {
+		return nil
+	}
+	literal := NewASTNoder(literalType(v.Type), literalText(v), node.GetSpan())
+	literal.SetDataType(v.Type)
+	literal.SetValue(v)
+	return literal
+}
+
+func literalType(t DataType) ASTNodeType {
+	switch t {
+	case DataType_Int:
+		return ASTNodeType_IntLiteral
+	case DataType_Float:
+		return ASTNodeType_FloatLiteral
+	case DataType_Bool:
+		return ASTNodeType_BoolLiteral
+	}
+	return ASTNodeType_StringLiteral
+}
+
+// literalText is the source text of a literal of v.
+func literalText(v Value) string {
+	switch v.Type {
+	case DataType_Float:
+		return strconv.FormatFloat(v.Float, 'g', -1, 64)
+	case DataType_String:
+		return quote(v.Str)
+	}
+	return v.String()
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestFoldAST(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+		changed    bool
+	}{
+		{"2 * 3 + 4", "10", true},
+		{"-(2 - 5) % 2", "1", true},
+		{"1 < 2 == true", "true", true},
+		{"\"a\" + \"b\"", `"ab"`, true},
+		{"int(2.5) + float(1) > 2.0", "true", true},
+		{"true || x > 1", "true", true},
+		{"false || x > 1", "(> x 1)", true},
+		{"x * (2 + 3)", "(* x 5)", true},
+		// The tree has no algebraic identities, only the IR.
+		{"x * 1 + 0", "(+ (* x 1) 0)", false},
+		// What fails at run time must still fail.
+		{"5 / 0", "(/ 5 0)", false},
+		{"5 % (2 - 2)", "(% 5 0)", true},
+		{"0 * (1 / z())", "(* 0 (/ 1 z))", false},
+		// Floats that are not finite have no literal.
+		{"1.0 / 0.0", "(/ 1.0 0.0)", false},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			root := checkScript(t, "int x = 1; int z() { return 0; } "+test.expression+";")
+			children := root.GetChildren()
+			changed := foldAST(root)
+			if got := sexpr(children[len(children)-1]); got != test.want || changed != test.changed {
+				t.Errorf("got %s, changed: %v, want %s, changed: %v", got, changed, test.want, test.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type IROp int
+
+// Dest is the register an instruction defines, nil for the ones without a
+// result. Binary and Unary apply Operator, LoadGlobal and StoreGlobal access
+// Global, Call calls Callee, and Jump, Branch and Phi refer to Blocks: the
+// targets of a jump or branch and, parallel to Args, the predecessors a phi
+// takes its incoming values from. Jump, Branch and Return end a block.
+const (
+	IROp_Copy IROp = iota
+	IROp_Binary
+	IROp_Unary
+	IROp_Convert
+	IROp_LoadGlobal
+	IROp_StoreGlobal
+	IROp_Call
+	IROp_Print
+	IROp_Phi
+	IROp_Jump
+	IROp_Branch
+	IROp_Return
+)
+
+var irOpNames = [...]string{
+	IROp_Copy:        "copy",
+	IROp_Binary:      "binary",
+	IROp_Unary:       "unary",
+	IROp_Convert:     "convert",
+	IROp_LoadGlobal:  "load",
+	IROp_StoreGlobal: "store",
+	IROp_Call:        "call",
+	IROp_Print:       "print",
+	IROp_Phi:         "phi",
+	IROp_Jump:        "jump",
+	IROp_Branch:      "branch",
+	IROp_Return:      "return",
+}
+
+func (op IROp) String() string {
+	if int(op) < len(irOpNames) {
+		return irOpNames[op]
+	}
+	return fmt.Sprintf("IROp(%d)", int(op))
+}
+
+func (op IROp) isTerminator() bool {
+	return op == IROp_Jump || op == IROp_Branch || op == IROp_Return
+}
+
+// irOperatorNames are the mnemonics of the Binary and Unary operators in
+// the text format.
+var irOperatorNames = map[string]string{
+	"+": "add", "-": "sub", "*": "mul", "/": "div", "%": "mod",
+	"<": "lt", "<=": "le", ">": "gt", ">=": "ge", "==": "eq", "!=": "ne",
+}
+
+var irUnaryNames = map[string]string{"-": "neg", "!": "not"}
+
+// IRValue is an operand of an instruction: a register or a constant.
+type IRValue interface {
+	GetDataType() DataType
+	String() string
+}
+
+// IRReg is a virtual register. Before SSA construction the registers of
+// variables are assigned by several instructions; afterwards every register
+// has a single definition.
+type IRReg struct {
+	Name string
+	Type DataType
+}
+
+func (r *IRReg) GetDataType() DataType {
+	return r.Type
+}
+
+func (r *IRReg) String() string {
+	return "%" + r.Name
+}
+
+type IRConst struct {
+	Value Value
+}
+
+func (c IRConst) GetDataType() DataType {
+	return c.Value.Type
+}
+
+func (c IRConst) String() string {
+	switch c.Value.Type {
+	case DataType_Float:
+		text := strconv.FormatFloat(c.Value.Float, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".eIN") {
+			text += ".0"
+		}
+		return text
+	case DataType_String:
+		return strconv.Quote(c.Value.Str)
+	}
+	return c.Value.String()
+}
+
+type IRInstr struct {
+	Op       IROp
+	Dest     *IRReg
+	Args     []IRValue
+	Operator string
+	Global   *Symbol
+	Callee   *Symbol
+	Blocks   []*IRBlock
+	Span     Span
+}
+
+// IRBlock is a basic block. Phis come first and the last instruction is the
+// only terminator. Idom is the immediate dominator, nil for the entry.
+type IRBlock struct {
+	ID     int
+	Instrs []*IRInstr
+	Preds  []*IRBlock
+	Succs  []*IRBlock
+	Idom   *IRBlock
+}
+
+func (b *IRBlock) String() string {
+	return "b" + strconv.Itoa(b.ID)
+}
+
+func (b *IRBlock) Terminator() *IRInstr {
+	if len(b.Instrs) == 0 {
+		return nil
+	}
+	if last := b.Instrs[len(b.Instrs)-1]; last.Op.isTerminator() {
+		return last
+	}
+	return nil
+}
+
+// IRFunction is one function, or the top-level statements of a script when
+// Symbol is nil. Blocks[0] is the entry.
+type IRFunction struct {
+	Name   string
+	Symbol *Symbol
+	Type   DataType
+	Params []*IRReg
+	Blocks []*IRBlock
+	temps  int
+}
+
+func (f *IRFunction) newTemp(t DataType) *IRReg {
+	r := &IRReg{Name: strconv.Itoa(f.temps), Type: t}
+	f.temps++
+	return r
+}
+
+func (f *IRFunction) newBlock() *IRBlock {
+	b := &IRBlock{ID: len(f.Blocks)}
+	f.Blocks = append(f.Blocks, b)
+	return b
+}
+
+// IRProgram is a lowered script. Main runs the top-level statements and
+// Globals are the global variables by slot.
+type IRProgram struct {
+	Functions []*IRFunction
+	Main      *IRFunction
+	Globals   []*Symbol
+}
+
+// Function returns the function of symbol.
+func (p *IRProgram) Function(symbol *Symbol) *IRFunction {
+	for _, f := range p.Functions {
+		if f.Symbol == symbol {
+			return f
+		}
+	}
+	return nil
+}
+
+// globalName names a global in the text format, with its slot if another
+// global has the same name.
+func (p *IRProgram) globalName(symbol *Symbol) string {
+	for _, other := range p.Globals {
+		if other != symbol && other.Name == symbol.Name {
+			return fmt.Sprintf("@%s.%d", symbol.Name, symbol.Index)
+		}
+	}
+	return "@" + symbol.Name
+}
+
+// Dump writes p in the text format of the IR.
+func (p *IRProgram) Dump(w io.Writer) {
+	for _, global := range p.Globals {
+		fmt.Fprintf(w, "global %s %s\n", p.globalName(global), global.Type)
+	}
+	for i, f := range append(append([]*IRFunction(nil), p.Functions...), p.Main) {
+		if i > 0 || len(p.Globals) > 0 {
+			fmt.Fprintln(w)
+		}
+		p.dumpFunction(w, f)
+	}
+}
+
+func (p *IRProgram) dumpFunction(w io.Writer, f *IRFunction) {
+	params := make([]string, len(f.Params))
+	for i, param := range f.Params {
+		params[i] = param.String() + " " + string(param.Type)
+	}
+	fmt.Fprintf(w, "func %s(%s) %s {\n", f.Name, strings.Join(params, ", "), f.Type)
+	for _, b := range f.Blocks {
+		fmt.Fprintf(w, "%s:", b)
+		if len(b.Preds) > 0 {
+			preds := make([]string, len(b.Preds))
+			for i, pred := range b.Preds {
+				preds[i] = pred.String()
+			}
+			fmt.Fprintf(w, "\t; preds %s", strings.Join(preds, ", "))
+		}
+		fmt.Fprintln(w)
+		for _, instr := range b.Instrs {
+			fmt.Fprintf(w, "\t%s\n", p.formatInstr(instr))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func (p *IRProgram) formatInstr(instr *IRInstr) string {
+	var b strings.Builder
+	if instr.Dest != nil {
+		fmt.Fprintf(&b, "%s %s = ", instr.Dest, instr.Dest.Type)
+	}
+	args := make([]string, len(instr.Args))
+	for i, arg := range instr.Args {
+		args[i] = arg.String()
+	}
+	switch instr.Op {
+	case IROp_Binary:
+		fmt.Fprintf(&b, "%s %s", irOperatorNames[instr.Operator], strings.Join(args, ", "))
+	case IROp_Unary:
+		fmt.Fprintf(&b, "%s %s", irUnaryNames[instr.Operator], args[0])
+	case IROp_LoadGlobal:
+		fmt.Fprintf(&b, "load %s", p.globalName(instr.Global))
+	case IROp_StoreGlobal:
+		fmt.Fprintf(&b, "store %s, %s", p.globalName(instr.Global), args[0])
+	case IROp_Call:
+		fmt.Fprintf(&b, "call %s(%s)", instr.Callee.Name, strings.Join(args, ", "))
+	case IROp_Phi:
+		incoming := make([]string, len(args))
+		for i, arg := range args {
+			incoming[i] = fmt.Sprintf("[%s, %s]", instr.Blocks[i], arg)
+		}
+		fmt.Fprintf(&b, "phi %s", strings.Join(incoming, ", "))
+	case IROp_Jump:
+		fmt.Fprintf(&b, "jump %s", instr.Blocks[0])
+	case IROp_Branch:
+		fmt.Fprintf(&b, "branch %s, %s, %s", args[0], instr.Blocks[0], instr.Blocks[1])
+	default:
+		b.WriteString(instr.Op.String())
+		if len(args) > 0 {
+			b.WriteString(" " + strings.Join(args, ", "))
+		}
+	}
+	return b.String()
+}
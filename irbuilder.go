@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type irLoop struct {
+	breakTo    *IRBlock
+	continueTo *IRBlock
+}
+
+// IRBuilder lowers an AST annotated by a Resolver and a TypeChecker to the
+// IR in SSA form. Local variables become registers and globals are loaded
+// and stored; && and || become branches.
+type IRBuilder struct {
+	program  *IRProgram
+	function *IRFunction
+	block    *IRBlock
+	vars     map[*Symbol]*IRReg
+	names    map[string]int
+	globals  map[*Symbol]bool
+	loops    []irLoop
+}
+
+func NewIRBuilder() *IRBuilder {
+	return &IRBuilder{}
+}
+
+// Build returns the program of root with its functions in SSA form.
+func (b *IRBuilder) Build(root ASTNoder) (program *IRProgram, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			program, err = nil, d
+		}
+	}()
+	b.program = &IRProgram{}
+	b.globals = make(map[*Symbol]bool)
+	b.loops = nil
+	var functions []ASTNoder
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			symbol := child.GetSymbol()
+			b.program.Functions = append(b.program.Functions, &IRFunction{Name: symbol.Name, Symbol: symbol, Type: symbol.Type})
+			functions = append(functions, child)
+		}
+	}
+	for i, node := range functions {
+		f := b.program.Functions[i]
+		b.begin(f)
+		for _, param := range f.Symbol.Params {
+			f.Params = append(f.Params, b.variable(param))
+		}
+		children := node.GetChildren()
+		body := children[len(children)-1]
+		b.statement(body)
+		if f.Type == DataType_Void {
+			b.emit(&IRInstr{Op: IROp_Return, Span: body.GetSpan()})
+		} else {
+			b.emit(&IRInstr{Op: IROp_Return, Args: []IRValue{IRConst{zeroValue(f.Type)}}, Span: body.GetSpan()})
+		}
+		b.finish()
+	}
+	b.program.Main = &IRFunction{Name: "<script>", Type: DataType_Void}
+	b.begin(b.program.Main)
+	for _, child := range root.GetChildren() {
+		b.statement(child)
+	}
+	b.emit(&IRInstr{Op: IROp_Return, Span: root.GetSpan()})
+	b.finish()
+	for global := range b.globals {
+		b.program.Globals = append(b.program.Globals, global)
+	}
+	sort.Slice(b.program.Globals, func(i, j int) bool {
+		return b.program.Globals[i].Index < b.program.Globals[j].Index
+	})
+	return b.program, nil
+}
+
+func (b *IRBuilder) begin(f *IRFunction) {
+	b.function = f
+	b.block = f.newBlock()
+	b.vars = make(map[*Symbol]*IRReg)
+	b.names = make(map[string]int)
+}
+
+func (b *IRBuilder) finish() {
+	removeUnreachable(b.function)
+	constructSSA(b.function)
+}
+
+func (b *IRBuilder) emit(instr *IRInstr) *IRInstr {
+	b.block.Instrs = append(b.block.Instrs, instr)
+	if instr.Op.isTerminator() {
+		// Code after a jump or return goes to a block nothing jumps to,
+		// removed by finish.
+		b.block = b.function.newBlock()
+	}
+	return instr
+}
+
+func (b *IRBuilder) jump(node ASTNoder, target *IRBlock) {
+	b.emit(&IRInstr{Op: IROp_Jump, Blocks: []*IRBlock{target}, Span: node.GetSpan()})
+}
+
+func (b *IRBuilder) branch(node ASTNoder, condition IRValue, then, otherwise *IRBlock) {
+	b.emit(&IRInstr{Op: IROp_Branch, Args: []IRValue{condition}, Blocks: []*IRBlock{then, otherwise}, Span: node.GetSpan()})
+}
+
+// variable returns the register of a local variable or parameter. Variables
+// of the same name in one function are told apart by a suffix.
+func (b *IRBuilder) variable(symbol *Symbol) *IRReg {
+	if r, ok := b.vars[symbol]; ok {
+		return r
+	}
+	name := symbol.Name
+	if n := b.names[name]; n > 0 {
+		name = fmt.Sprintf("%s#%d", name, n+1)
+	}
+	b.names[symbol.Name]++
+	r := &IRReg{Name: name, Type: symbol.Type}
+	b.vars[symbol] = r
+	return r
+}
+
+func (b *IRBuilder) assign(node ASTNoder, v IRValue) {
+	symbol := node.GetSymbol()
+	if symbol.IsGlobal() {
+		b.globals[symbol] = true
+		b.emit(&IRInstr{Op: IROp_StoreGlobal, Args: []IRValue{v}, Global: symbol, Span: node.GetSpan()})
+	} else {
+		b.emit(&IRInstr{Op: IROp_Copy, Dest: b.variable(symbol), Args: []IRValue{v}, Span: node.GetSpan()})
+	}
+}
+
+func (b *IRBuilder) statement(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_FunctionDeclaration, ASTNodeType_Empty:
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		var v IRValue = IRConst{zeroValue(declaredType(node))}
+		if len(children) > 0 {
+			v = b.expression(children[0])
+		}
+		b.assign(node, v)
+	case ASTNodeType_Block:
+		for _, child := range children {
+			b.statement(child)
+		}
+	case ASTNodeType_If:
+		then, end := b.function.newBlock(), b.function.newBlock()
+		otherwise := end
+		if len(children) > 2 {
+			otherwise = b.function.newBlock()
+		}
+		b.branch(node, b.expression(children[0]), then, otherwise)
+		b.block = then
+		b.statement(children[1])
+		b.jump(node, end)
+		if len(children) > 2 {
+			b.block = otherwise
+			b.statement(children[2])
+			b.jump(node, end)
+		}
+		b.block = end
+	case ASTNodeType_While:
+		header, body, end := b.function.newBlock(), b.function.newBlock(), b.function.newBlock()
+		b.jump(node, header)
+		b.block = header
+		b.branch(node, b.expression(children[0]), body, end)
+		b.block = body
+		b.loopBody(children[1], end, header)
+		b.jump(node, header)
+		b.block = end
+	case ASTNodeType_For:
+		b.statement(children[0])
+		header, body, step, end := b.function.newBlock(), b.function.newBlock(), b.function.newBlock(), b.function.newBlock()
+		b.jump(node, header)
+		b.block = header
+		if children[1].GetType() != ASTNodeType_Empty {
+			b.branch(node, b.expression(children[1]), body, end)
+		} else {
+			b.jump(node, body)
+		}
+		b.block = body
+		b.loopBody(children[3], end, step)
+		b.jump(node, step)
+		b.block = step
+		b.statement(children[2])
+		b.jump(node, header)
+		b.block = end
+	case ASTNodeType_Break:
+		b.jump(node, b.loops[len(b.loops)-1].breakTo)
+	case ASTNodeType_Continue:
+		b.jump(node, b.loops[len(b.loops)-1].continueTo)
+	case ASTNodeType_Return:
+		instr := &IRInstr{Op: IROp_Return, Span: node.GetSpan()}
+		if len(children) > 0 {
+			v := b.expression(children[0])
+			if b.function.Type != DataType_Void {
+				instr.Args = []IRValue{v}
+			}
+		}
+		b.emit(instr)
+	case ASTNodeType_Print:
+		b.emit(&IRInstr{Op: IROp_Print, Args: []IRValue{b.expression(children[0])}, Span: node.GetSpan()})
+	case ASTNodeType_Error:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, "cannot compile a statement with syntax errors", node.GetSpan()))
+	default:
+		b.expression(node)
+	}
+}
+
+func (b *IRBuilder) loopBody(node ASTNoder, breakTo, continueTo *IRBlock) {
+	b.loops = append(b.loops, irLoop{breakTo: breakTo, continueTo: continueTo})
+	b.statement(node)
+	b.loops = b.loops[:len(b.loops)-1]
+}
+
+// temp emits an instruction defining a new temporary and returns it.
+func (b *IRBuilder) temp(node ASTNoder, op IROp, t DataType, args ...IRValue) *IRReg {
+	dest := b.function.newTemp(t)
+	b.emit(&IRInstr{Op: op, Dest: dest, Args: args, Operator: node.GetText(), Span: node.GetSpan()})
+	return dest
+}
+
+func (b *IRBuilder) expression(node ASTNoder) IRValue {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		return IRConst{node.GetValue()}
+	case ASTNodeType_Identifier:
+		symbol := node.GetSymbol()
+		if !symbol.IsGlobal() {
+			return b.variable(symbol)
+		}
+		b.globals[symbol] = true
+		dest := b.function.newTemp(symbol.Type)
+		b.emit(&IRInstr{Op: IROp_LoadGlobal, Dest: dest, Global: symbol, Span: node.GetSpan()})
+		return dest
+	case ASTNodeType_Assignment:
+		v := b.expression(children[0])
+		b.assign(node, v)
+		if symbol := node.GetSymbol(); !symbol.IsGlobal() {
+			return b.variable(symbol)
+		}
+		return v
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		left := b.expression(children[0])
+		return b.temp(node, IROp_Binary, node.GetDataType(), left, b.expression(children[1]))
+	case ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		// The result is a register assigned on both paths, joined by a phi
+		// when the function is put in SSA form.
+		result := b.function.newTemp(DataType_Bool)
+		b.emit(&IRInstr{Op: IROp_Copy, Dest: result, Args: []IRValue{b.expression(children[0])}, Span: node.GetSpan()})
+		right, end := b.function.newBlock(), b.function.newBlock()
+		if node.GetType() == ASTNodeType_LogicalAnd {
+			b.branch(node, result, right, end)
+		} else {
+			b.branch(node, result, end, right)
+		}
+		b.block = right
+		b.emit(&IRInstr{Op: IROp_Copy, Dest: result, Args: []IRValue{b.expression(children[1])}, Span: node.GetSpan()})
+		b.jump(node, end)
+		b.block = end
+		return result
+	case ASTNodeType_Unary:
+		operand := b.expression(children[0])
+		if node.GetText() == "+" {
+			return operand
+		}
+		return b.temp(node, IROp_Unary, node.GetDataType(), operand)
+	case ASTNodeType_Conversion:
+		operand := b.expression(children[0])
+		if operand.GetDataType() == node.GetDataType() {
+			return operand
+		}
+		return b.temp(node, IROp_Convert, node.GetDataType(), operand)
+	case ASTNodeType_FunctionCall:
+		symbol := node.GetSymbol()
+		instr := &IRInstr{Op: IROp_Call, Callee: symbol, Span: node.GetSpan()}
+		for _, arg := range children {
+			instr.Args = append(instr.Args, b.expression(arg))
+		}
+		if symbol.Type != DataType_Void {
+			instr.Dest = b.function.newTemp(symbol.Type)
+		}
+		b.emit(instr)
+		if instr.Dest == nil {
+			return IRConst{zeroValue(DataType_Void)}
+		}
+		return instr.Dest
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, fmt.Sprintf("cannot compile %s as an expression", node.GetType()), node.GetSpan()))
+}
@@ -0,0 +1,127 @@
+package main
+
+import "fmt"
+
+// IRInterpreter runs an IRProgram. It follows the semantics of the VM, so
+// lowering and the passes on the IR can be checked against the other
+// backends.
+type IRInterpreter struct {
+	MaxCallDepth int
+	program      *IRProgram
+	globals      map[*Symbol]Value
+	depth        int
+}
+
+func NewIRInterpreter() *IRInterpreter {
+	return &IRInterpreter{MaxCallDepth: DefaultMaxCallDepth}
+}
+
+func (in *IRInterpreter) Run(program *IRProgram) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			err = d
+		}
+	}()
+	in.program = program
+	in.globals = make(map[*Symbol]Value)
+	in.depth = 0
+	in.call(program.Main, nil)
+	return nil
+}
+
+func (in *IRInterpreter) fail(instr *IRInstr, code DiagnosticCode, message string) {
+	panic(NewDiagnostic(Severity_Error, code, message, instr.Span))
+}
+
+func (in *IRInterpreter) call(f *IRFunction, args []Value) Value {
+	regs := make(map[*IRReg]Value)
+	for i, param := range f.Params {
+		regs[param] = args[i]
+	}
+	value := func(v IRValue) Value {
+		if r, ok := v.(*IRReg); ok {
+			return regs[r]
+		}
+		return v.(IRConst).Value
+	}
+	var pred *IRBlock
+	b := f.Blocks[0]
+	for {
+		// The phis of a block read their incoming values at once.
+		var phis []Value
+		for _, instr := range b.Instrs {
+			if instr.Op != IROp_Phi {
+				break
+			}
+			for i, from := range instr.Blocks {
+				if from == pred {
+					phis = append(phis, value(instr.Args[i]))
+				}
+			}
+		}
+		for i, v := range phis {
+			regs[b.Instrs[i].Dest] = v
+		}
+		for _, instr := range b.Instrs[len(phis):] {
+			switch instr.Op {
+			case IROp_Copy:
+				regs[instr.Dest] = value(instr.Args[0])
+			case IROp_Binary:
+				v, err := binaryOp(instr.Operator, value(instr.Args[0]), value(instr.Args[1]))
+				if err == errDivisionByZero {
+					in.fail(instr, DiagnosticCode_DivisionByZero, err.Error())
+				} else if err != nil {
+					in.fail(instr, DiagnosticCode_InvalidNode, err.Error())
+				}
+				regs[instr.Dest] = v
+			case IROp_Unary:
+				regs[instr.Dest] = unaryOp(instr.Operator, value(instr.Args[0]))
+			case IROp_Convert:
+				v, err := convertValue(value(instr.Args[0]), instr.Dest.Type)
+				if err != nil {
+					in.fail(instr, DiagnosticCode_ConversionError, err.Error())
+				}
+				regs[instr.Dest] = v
+			case IROp_LoadGlobal:
+				regs[instr.Dest] = in.globals[instr.Global]
+			case IROp_StoreGlobal:
+				in.globals[instr.Global] = value(instr.Args[0])
+			case IROp_Call:
+				if in.depth >= in.MaxCallDepth {
+					in.fail(instr, DiagnosticCode_StackOverflow, fmt.Sprintf("stack overflow: call depth exceeds %d", in.MaxCallDepth))
+				}
+				args := make([]Value, len(instr.Args))
+				for i, arg := range instr.Args {
+					args[i] = value(arg)
+				}
+				in.depth++
+				v := in.call(in.program.Function(instr.Callee), args)
+				in.depth--
+				if instr.Dest != nil {
+					regs[instr.Dest] = v
+				}
+			case IROp_Print:
+				fmt.Println(value(instr.Args[0]))
+			case IROp_Jump:
+				pred, b = b, instr.Blocks[0]
+			case IROp_Branch:
+				if value(instr.Args[0]).Bool {
+					pred, b = b, instr.Blocks[0]
+				} else {
+					pred, b = b, instr.Blocks[1]
+				}
+			case IROp_Return:
+				if len(instr.Args) > 0 {
+					return value(instr.Args[0])
+				}
+				return zeroValue(DataType_Void)
+			default:
+				in.fail(instr, DiagnosticCode_InvalidNode, "cannot run "+instr.Op.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package main
+
+import "math"
+
+// irPass is an optimization of one function in SSA form, which reports
+// whether it changed the function.
+type irPass struct {
+	name string
+	run  func(f *IRFunction) bool
+}
+
+var (
+	irFoldPass     = irPass{"fold", irFold}
+	irSimplifyPass = irPass{"simplify", irSimplify}
+	irCSEPass      = irPass{"cse", irCSE}
+	irDCEPass      = irPass{"dce", irDCE}
+	irMergePass    = irPass{"merge", irMergeBlocks}
+)
+
+// irReplacer maps registers to the values replacing them.
+type irReplacer map[*IRReg]IRValue
+
+func (repl irReplacer) resolve(v IRValue) IRValue {
+	for {
+		r, ok := v.(*IRReg)
+		if !ok {
+			return v
+		}
+		next, ok := repl[r]
+		if !ok {
+			return v
+		}
+		v = next
+	}
+}
+
+// apply replaces the uses of the registers of repl in f and drops the
+// instructions in dead.
+func (repl irReplacer) apply(f *IRFunction, dead map[*IRInstr]bool) {
+	for _, b := range f.Blocks {
+		instrs := b.Instrs[:0]
+		for _, instr := range b.Instrs {
+			if dead[instr] {
+				continue
+			}
+			for i, arg := range instr.Args {
+				instr.Args[i] = repl.resolve(arg)
+			}
+			instrs = append(instrs, instr)
+		}
+		for i := len(instrs); i < len(b.Instrs); i++ {
+			b.Instrs[i] = nil
+		}
+		b.Instrs = instrs
+	}
+}
+
+// removeIncoming drops the incoming values of the phis of b from pred.
+func removeIncoming(b, pred *IRBlock) {
+	for _, instr := range b.Instrs {
+		if instr.Op != IROp_Phi {
+			break
+		}
+		for i, from := range instr.Blocks {
+			if from == pred {
+				instr.Args = append(instr.Args[:i], instr.Args[i+1:]...)
+				instr.Blocks = append(instr.Blocks[:i], instr.Blocks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// evaluate computes an instruction whose operands are all constants. It
+// fails for the operations that fail at run time.
+func evaluate(instr *IRInstr, args []IRValue) (Value, bool) {
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		c, ok := arg.(IRConst)
+		if !ok {
+			return Value{}, false
+		}
+		values[i] = c.Value
+	}
+	switch instr.Op {
+	case IROp_Binary:
+		v, err := binaryOp(instr.Operator, values[0], values[1])
+		return v, err == nil
+	case IROp_Unary:
+		return unaryOp(instr.Operator, values[0]), true
+	case IROp_Convert:
+		v, err := convertValue(values[0], instr.Dest.Type)
+		return v, err == nil
+	}
+	return Value{}, false
+}
+
+// irFold propagates constants and copies: copies, phis with a single
+// incoming value and operations on constants are replaced by their value,
+// and branches on a constant become jumps.
+func irFold(f *IRFunction) bool {
+	changed := false
+	for {
+		repl := make(irReplacer)
+		dead := make(map[*IRInstr]bool)
+		jumps := false
+		for _, b := range f.Blocks {
+			for _, instr := range b.Instrs {
+				args := make([]IRValue, len(instr.Args))
+				for i, arg := range instr.Args {
+					args[i] = repl.resolve(arg)
+				}
+				var v IRValue
+				switch instr.Op {
+				case IROp_Copy:
+					v = args[0]
+				case IROp_Phi:
+					for _, arg := range args {
+						if arg == IRValue(instr.Dest) || arg == v {
+							continue
+						}
+						if v != nil {
+							v = nil
+							break
+						}
+						v = arg
+					}
+				case IROp_Binary, IROp_Unary, IROp_Convert:
+					if c, ok := evaluate(instr, args); ok {
+						v = IRConst{c}
+					}
+				case IROp_Branch:
+					if c, ok := args[0].(IRConst); ok {
+						target, other := instr.Blocks[0], instr.Blocks[1]
+						if !c.Value.Bool {
+							target, other = other, target
+						}
+						removeIncoming(other, b)
+						instr.Op, instr.Args, instr.Blocks = IROp_Jump, nil, []*IRBlock{target}
+						jumps = true
+					}
+				}
+				if v != nil {
+					repl[instr.Dest] = v
+					dead[instr] = true
+				}
+			}
+		}
+		if len(dead) == 0 && !jumps {
+			return changed
+		}
+		repl.apply(f, dead)
+		if jumps {
+			removeUnreachable(f)
+		}
+		changed = true
+	}
+}
+
+// irSimplify applies algebraic identities: adding or subtracting zero,
+// multiplying or dividing by one, multiplying an int by zero, comparing an
+// int or bool with itself, and negating twice. The float identities that
+// would change the sign of a zero or a NaN are not applied.
+func irSimplify(f *IRFunction) bool {
+	defs := make(map[*IRReg]*IRInstr)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Dest != nil {
+				defs[instr.Dest] = instr
+			}
+		}
+	}
+	isConst := func(v IRValue, i int, f float64) bool {
+		c, ok := v.(IRConst)
+		if !ok {
+			return false
+		}
+		switch c.Value.Type {
+		case DataType_Int:
+			return c.Value.Int == i
+		case DataType_Float:
+			return c.Value.Float == f && !math.Signbit(c.Value.Float)
+		}
+		return false
+	}
+	repl := make(irReplacer)
+	dead := make(map[*IRInstr]bool)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			var v IRValue
+			switch instr.Op {
+			case IROp_Binary:
+				x, y := instr.Args[0], instr.Args[1]
+				t := x.GetDataType()
+				exact := t == DataType_Int || t == DataType_Bool
+				switch instr.Operator {
+				case "+":
+					if t == DataType_Int && isConst(y, 0, 0) || t == DataType_String && y == IRValue(IRConst{StringValue("")}) {
+						v = x
+					} else if t == DataType_Int && isConst(x, 0, 0) || t == DataType_String && x == IRValue(IRConst{StringValue("")}) {
+						v = y
+					}
+				case "-":
+					if isConst(y, 0, 0) {
+						v = x
+					} else if t == DataType_Int && x == y {
+						v = IRConst{IntValue(0)}
+					}
+				case "*":
+					if isConst(y, 1, 1) {
+						v = x
+					} else if isConst(x, 1, 1) {
+						v = y
+					} else if t == DataType_Int && (isConst(x, 0, 0) || isConst(y, 0, 0)) {
+						v = IRConst{IntValue(0)}
+					}
+				case "/":
+					if isConst(y, 1, 1) {
+						v = x
+					}
+				case "%":
+					if isConst(y, 1, 1) {
+						v = IRConst{IntValue(0)}
+					}
+				case "==", "<=", ">=":
+					if exact && x == y {
+						v = IRConst{BoolValue(true)}
+					}
+				case "!=", "<", ">":
+					if exact && x == y {
+						v = IRConst{BoolValue(false)}
+					}
+				}
+			case IROp_Unary:
+				if r, ok := instr.Args[0].(*IRReg); ok {
+					if def := defs[r]; def != nil && def.Op == IROp_Unary && def.Operator == instr.Operator {
+						v = def.Args[0]
+					}
+				}
+			}
+			if v != nil {
+				repl[instr.Dest] = v
+				dead[instr] = true
+			}
+		}
+	}
+	if len(dead) == 0 {
+		return false
+	}
+	repl.apply(f, dead)
+	return true
+}
+
+type irExpression struct {
+	op       IROp
+	operator string
+	t        DataType
+	x, y     IRValue
+}
+
+// irCSE replaces an operation by the result of the same operation on the
+// same operands in a dominating block. An operation that can fail only
+// reaches the second one if the first one succeeded, so every operation
+// but loads and calls takes part.
+func irCSE(f *IRFunction) bool {
+	computeDominators(f)
+	children := dominatorTree(f)
+	available := make(map[irExpression]*IRReg)
+	repl := make(irReplacer)
+	dead := make(map[*IRInstr]bool)
+	var walk func(b *IRBlock)
+	walk = func(b *IRBlock) {
+		var added []irExpression
+		for _, instr := range b.Instrs {
+			for i, arg := range instr.Args {
+				instr.Args[i] = repl.resolve(arg)
+			}
+			if instr.Op != IROp_Binary && instr.Op != IROp_Unary && instr.Op != IROp_Convert {
+				continue
+			}
+			e := irExpression{op: instr.Op, operator: instr.Operator, t: instr.Dest.Type, x: instr.Args[0]}
+			if len(instr.Args) > 1 {
+				e.y = instr.Args[1]
+			}
+			if r, ok := available[e]; ok {
+				repl[instr.Dest] = r
+				dead[instr] = true
+				continue
+			}
+			available[e] = instr.Dest
+			added = append(added, e)
+		}
+		for _, child := range children[b] {
+			walk(child)
+		}
+		for _, e := range added {
+			delete(available, e)
+		}
+	}
+	walk(f.Blocks[0])
+	if len(dead) == 0 {
+		return false
+	}
+	repl.apply(f, dead)
+	return true
+}
+
+// hasEffect tells whether instr does more than define its register: it
+// prints, stores, calls, ends the block or may fail.
+func hasEffect(instr *IRInstr) bool {
+	switch instr.Op {
+	case IROp_Copy, IROp_Unary, IROp_LoadGlobal, IROp_Phi:
+		return false
+	case IROp_Binary:
+		if instr.Operator != "/" && instr.Operator != "%" || instr.Args[0].GetDataType() != DataType_Int {
+			return false
+		}
+		c, ok := instr.Args[1].(IRConst)
+		return !ok || c.Value.Int == 0
+	case IROp_Convert:
+		return instr.Args[0].GetDataType() == DataType_String
+	}
+	return true
+}
+
+// irDCE removes the instructions whose results are not needed by an
+// instruction with an effect, including cycles of phis.
+func irDCE(f *IRFunction) bool {
+	defs := make(map[*IRReg]*IRInstr)
+	var work []*IRInstr
+	live := make(map[*IRInstr]bool)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Dest != nil {
+				defs[instr.Dest] = instr
+			}
+			if hasEffect(instr) {
+				live[instr] = true
+				work = append(work, instr)
+			}
+		}
+	}
+	for len(work) > 0 {
+		instr := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, arg := range instr.Args {
+			if r, ok := arg.(*IRReg); ok {
+				if def := defs[r]; def != nil && !live[def] {
+					live[def] = true
+					work = append(work, def)
+				}
+			}
+		}
+	}
+	dead := make(map[*IRInstr]bool)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if !live[instr] {
+				dead[instr] = true
+			}
+		}
+	}
+	if len(dead) == 0 {
+		return false
+	}
+	irReplacer(nil).apply(f, dead)
+	return true
+}
+
+// irMergeBlocks simplifies the control flow graph: a block is merged into
+// its only predecessor when that one jumps to it, jumps to a block holding
+// only a jump go directly to its target, and branches to the same block on
+// both sides become jumps.
+func irMergeBlocks(f *IRFunction) bool {
+	changed := false
+	for {
+		if !mergeOnce(f) {
+			return changed
+		}
+		removeUnreachable(f)
+		changed = true
+	}
+}
+
+func mergeOnce(f *IRFunction) bool {
+	for _, b := range f.Blocks {
+		t := b.Terminator()
+		if t.Op == IROp_Branch && t.Blocks[0] == t.Blocks[1] {
+			t.Op, t.Args, t.Blocks = IROp_Jump, nil, t.Blocks[:1]
+			return true
+		}
+		if t.Op != IROp_Jump {
+			continue
+		}
+		s := t.Blocks[0]
+		if s != b && s != f.Blocks[0] && len(s.Preds) == 1 {
+			repl := make(irReplacer)
+			dead := make(map[*IRInstr]bool)
+			for _, instr := range s.Instrs {
+				if instr.Op == IROp_Phi {
+					repl[instr.Dest] = instr.Args[0]
+					dead[instr] = true
+				}
+			}
+			b.Instrs = append(b.Instrs[:len(b.Instrs)-1], s.Instrs...)
+			for _, succ := range s.Succs {
+				for _, instr := range succ.Instrs {
+					for i, from := range instr.Blocks {
+						if instr.Op == IROp_Phi && from == s {
+							instr.Blocks[i] = b
+						}
+					}
+				}
+			}
+			s.Instrs = nil
+			repl.apply(f, dead)
+			computeEdges(f)
+			return true
+		}
+		if len(b.Instrs) != 1 || b == f.Blocks[0] || s == b || hasPhis(s) {
+			continue
+		}
+		for _, pred := range b.Preds {
+			pt := pred.Terminator()
+			for i, target := range pt.Blocks {
+				if target == b {
+					pt.Blocks[i] = s
+				}
+			}
+		}
+		computeEdges(f)
+		return true
+	}
+	return false
+}
+
+func hasPhis(b *IRBlock) bool {
+	return len(b.Instrs) > 0 && b.Instrs[0].Op == IROp_Phi
+}
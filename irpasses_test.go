@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func TestIRPasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		pass   irPass
+		script string
+		want   string
+	}{
+		{"constant folding", irFoldPass, "int f(int a) { return 2 * 3 + 4 + a; }", `func f(%a int) int {
+b0:
+	%2 int = add 10, %a
+	return %2
+}
+`},
+		{"copy propagation", irFoldPass, "int f(int a) { int b = a; int c = b + 1; return c; }", `func f(%a int) int {
+b0:
+	%0 int = add %a, 1
+	return %0
+}
+`},
+		{"constant propagation into a branch", irFoldPass, "int f(int a) { int b = 1; if (b > 0) a = 2; return a; }", `func f(%a int) int {
+b0:
+	jump b1
+b1:	; preds b0
+	jump b2
+b2:	; preds b1
+	return 2
+}
+`},
+		{"dead code", irDCEPass, "int f(int a) { int b = a + 1; return a; }", `func f(%a int) int {
+b0:
+	return %a
+}
+`},
+		{"common subexpression", irCSEPass, "int f(int x, int a) { int b = x + a; int c = x + a; return b * c; }", `func f(%x int, %a int) int {
+b0:
+	%0 int = add %x, %a
+	%b int = copy %0
+	%c int = copy %0
+	%2 int = mul %b, %c
+	return %2
+}
+`},
+		{"algebraic identities", irSimplifyPass, "int f(int x) { return x * 1 + 0; }", `func f(%x int) int {
+b0:
+	return %x
+}
+`},
+		{"division by zero", irFoldPass, "int f() { return 5 / 0; }", `func f() int {
+b0:
+	%0 int = div 5, 0
+	return %0
+}
+`},
+		{"zero times a call", irSimplifyPass, "int z() { return 0; } int f() { return 0 * (1 / z()); }", `func f() int {
+b0:
+	%0 int = call z()
+	%1 int = div 1, %0
+	return 0
+}
+`},
+		{"kept division", irDCEPass, "int z() { return 0; } int f() { int d = 1 / z(); return 0; }", `func f() int {
+b0:
+	%0 int = call z()
+	%1 int = div 1, %0
+	return 0
+}
+`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program := lowerScript(t, test.script)
+			for _, f := range program.Functions {
+				if f.Name == "f" {
+					test.pass.run(f)
+				}
+			}
+			if err := program.Verify(); err != nil {
+				t.Fatal(err)
+			}
+			if got := dumpFunction(program, "f"); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
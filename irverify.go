@@ -0,0 +1,229 @@
+package main
+
+import "fmt"
+
+// Verify checks that every function of p is well formed and in SSA form:
+// blocks end in exactly one terminator, phis come first and have one value
+// for each predecessor, every register is defined once and its definition
+// dominates its uses, and operands have the types their instructions
+// expect. It returns the first problem found.
+func (p *IRProgram) Verify() error {
+	for _, f := range append(append([]*IRFunction(nil), p.Functions...), p.Main) {
+		if err := p.verifyFunction(f); err != nil {
+			return fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *IRProgram) verifyFunction(f *IRFunction) error {
+	if len(f.Blocks) == 0 {
+		return fmt.Errorf("no blocks")
+	}
+	blocks := make(map[*IRBlock]bool)
+	for _, b := range f.Blocks {
+		blocks[b] = true
+	}
+	for _, b := range f.Blocks {
+		t := b.Terminator()
+		if t == nil {
+			return fmt.Errorf("%s: no terminator", b)
+		}
+		if len(b.Succs) != len(t.Blocks) {
+			return fmt.Errorf("%s: successors do not match the terminator", b)
+		}
+		for i, s := range t.Blocks {
+			if !blocks[s] {
+				return fmt.Errorf("%s: jump to a block of another function", b)
+			}
+			if b.Succs[i] != s {
+				return fmt.Errorf("%s: successors do not match the terminator", b)
+			}
+			if countBlocks(s.Preds, b) != 1 {
+				return fmt.Errorf("%s: not once a predecessor of %s", b, s)
+			}
+		}
+		for _, pred := range b.Preds {
+			if countBlocks(pred.Succs, b) != 1 {
+				return fmt.Errorf("%s: not once a successor of %s", pred, b)
+			}
+		}
+	}
+	if len(f.Blocks[0].Preds) > 0 {
+		return fmt.Errorf("%s: the entry has predecessors", f.Blocks[0])
+	}
+	if n := len(reversePostorder(f)); n != len(f.Blocks) {
+		return fmt.Errorf("%d blocks are unreachable", len(f.Blocks)-n)
+	}
+	computeDominators(f)
+
+	type site struct {
+		block *IRBlock
+		index int
+	}
+	defs := make(map[*IRReg]site)
+	for _, param := range f.Params {
+		if _, ok := defs[param]; ok {
+			return fmt.Errorf("parameter %s defined twice", param)
+		}
+		defs[param] = site{f.Blocks[0], -1}
+	}
+	for _, b := range f.Blocks {
+		for i, instr := range b.Instrs {
+			if instr.Dest == nil {
+				continue
+			}
+			if _, ok := defs[instr.Dest]; ok {
+				return fmt.Errorf("%s: %s defined twice", b, instr.Dest)
+			}
+			defs[instr.Dest] = site{b, i}
+		}
+	}
+
+	for _, b := range f.Blocks {
+		phis := true
+		for i, instr := range b.Instrs {
+			if instr.Op.isTerminator() != (i == len(b.Instrs)-1) {
+				return fmt.Errorf("%s: %s in the middle of the block", b, instr.Op)
+			}
+			if instr.Op == IROp_Phi && !phis {
+				return fmt.Errorf("%s: phi after other instructions", b)
+			}
+			phis = instr.Op == IROp_Phi
+			for j, arg := range instr.Args {
+				r, ok := arg.(*IRReg)
+				if !ok {
+					continue
+				}
+				def, ok := defs[r]
+				if !ok {
+					return fmt.Errorf("%s: %s is not defined", b, r)
+				}
+				if instr.Op == IROp_Phi {
+					if !dominates(def.block, instr.Blocks[j]) {
+						return fmt.Errorf("%s: %s does not dominate the end of %s", b, r, instr.Blocks[j])
+					}
+				} else if def.block == b && def.index >= i || def.block != b && !dominates(def.block, b) {
+					return fmt.Errorf("%s: %s is used before its definition", b, r)
+				}
+			}
+			if err := p.verifyInstr(f, b, instr); err != nil {
+				return fmt.Errorf("%s: %s: %v", b, p.formatInstr(instr), err)
+			}
+		}
+	}
+	return nil
+}
+
+func countBlocks(blocks []*IRBlock, b *IRBlock) int {
+	n := 0
+	for _, other := range blocks {
+		if other == b {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *IRProgram) verifyInstr(f *IRFunction, b *IRBlock, instr *IRInstr) error {
+	args := instr.Args
+	arity := map[IROp]int{
+		IROp_Copy: 1, IROp_Binary: 2, IROp_Unary: 1, IROp_Convert: 1, IROp_LoadGlobal: 0,
+		IROp_StoreGlobal: 1, IROp_Print: 1, IROp_Jump: 0, IROp_Branch: 1,
+	}
+	if n, ok := arity[instr.Op]; ok && len(args) != n {
+		return fmt.Errorf("%d operands instead of %d", len(args), n)
+	}
+	hasDest := map[IROp]bool{IROp_Copy: true, IROp_Binary: true, IROp_Unary: true, IROp_Convert: true, IROp_LoadGlobal: true, IROp_Phi: true}
+	if hasDest[instr.Op] && instr.Dest == nil {
+		return fmt.Errorf("no destination")
+	}
+	if !hasDest[instr.Op] && instr.Op != IROp_Call && instr.Dest != nil {
+		return fmt.Errorf("unexpected destination")
+	}
+	switch instr.Op {
+	case IROp_Copy:
+		if args[0].GetDataType() != instr.Dest.Type {
+			return fmt.Errorf("copies %s to %s", args[0].GetDataType(), instr.Dest.Type)
+		}
+	case IROp_Binary:
+		t := args[0].GetDataType()
+		if args[1].GetDataType() != t {
+			return fmt.Errorf("operands of types %s and %s", t, args[1].GetDataType())
+		}
+		result := t
+		switch instr.Operator {
+		case "<", "<=", ">", ">=", "==", "!=":
+			result = DataType_Bool
+		case "+", "-", "*", "/", "%":
+		default:
+			return fmt.Errorf("unknown operator %q", instr.Operator)
+		}
+		if instr.Dest.Type != result {
+			return fmt.Errorf("result of type %s instead of %s", instr.Dest.Type, result)
+		}
+	case IROp_Unary:
+		t := args[0].GetDataType()
+		switch {
+		case instr.Operator == "!" && t == DataType_Bool, instr.Operator == "-" && t.isNumeric():
+		default:
+			return fmt.Errorf("operator %q on %s", instr.Operator, t)
+		}
+		if instr.Dest.Type != t {
+			return fmt.Errorf("result of type %s instead of %s", instr.Dest.Type, t)
+		}
+	case IROp_LoadGlobal, IROp_StoreGlobal:
+		if instr.Global == nil || !instr.Global.IsGlobal() {
+			return fmt.Errorf("not a global variable")
+		}
+		if instr.Op == IROp_LoadGlobal && instr.Dest.Type != instr.Global.Type ||
+			instr.Op == IROp_StoreGlobal && args[0].GetDataType() != instr.Global.Type {
+			return fmt.Errorf("global of type %s", instr.Global.Type)
+		}
+	case IROp_Call:
+		callee := p.Function(instr.Callee)
+		if callee == nil {
+			return fmt.Errorf("unknown function")
+		}
+		if len(args) != len(callee.Params) {
+			return fmt.Errorf("%d arguments instead of %d", len(args), len(callee.Params))
+		}
+		for i, arg := range args {
+			if arg.GetDataType() != callee.Params[i].Type {
+				return fmt.Errorf("argument %d of type %s instead of %s", i+1, arg.GetDataType(), callee.Params[i].Type)
+			}
+		}
+		if (instr.Dest == nil) != (callee.Type == DataType_Void) || instr.Dest != nil && instr.Dest.Type != callee.Type {
+			return fmt.Errorf("result does not match the %s function", callee.Type)
+		}
+	case IROp_Phi:
+		if len(args) != len(b.Preds) || len(instr.Blocks) != len(args) {
+			return fmt.Errorf("%d incoming values for %d predecessors", len(args), len(b.Preds))
+		}
+		for i, arg := range args {
+			if countBlocks(b.Preds, instr.Blocks[i]) != 1 || countBlocks(instr.Blocks, instr.Blocks[i]) != 1 {
+				return fmt.Errorf("incoming block %s is not a predecessor", instr.Blocks[i])
+			}
+			if arg.GetDataType() != instr.Dest.Type {
+				return fmt.Errorf("incoming value of type %s", arg.GetDataType())
+			}
+		}
+	case IROp_Jump:
+		if len(instr.Blocks) != 1 {
+			return fmt.Errorf("%d targets", len(instr.Blocks))
+		}
+	case IROp_Branch:
+		if len(instr.Blocks) != 2 || instr.Blocks[0] == instr.Blocks[1] {
+			return fmt.Errorf("targets are not two different blocks")
+		}
+		if args[0].GetDataType() != DataType_Bool {
+			return fmt.Errorf("condition of type %s", args[0].GetDataType())
+		}
+	case IROp_Return:
+		if f.Type == DataType_Void && len(args) != 0 ||
+			f.Type != DataType_Void && (len(args) != 1 || args[0].GetDataType() != f.Type) {
+			return fmt.Errorf("does not return %s", f.Type)
+		}
+	}
+	return nil
+}
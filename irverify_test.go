@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	if err := lowerScript(t, sumScript).Verify(); err != nil {
+		t.Fatalf("valid program: %v", err)
+	}
+	// The blocks of sum are those of TestConstructSSA.
+	tests := []struct {
+		name   string
+		mutate func(f *IRFunction, b []*IRBlock)
+		want   string
+	}{
+		{"phi without an incoming value", func(f *IRFunction, b []*IRBlock) {
+			phi := b[1].Instrs[0]
+			phi.Args, phi.Blocks = phi.Args[:1], phi.Blocks[:1]
+		}, "sum: b1: %i.2 int = phi [b0, %i.1]: 1 incoming values for 2 predecessors"},
+		{"use in a block the definition does not dominate", func(f *IRFunction, b []*IRBlock) {
+			b[2].Instrs[0].Args[0] = b[4].Instrs[1].Dest
+		}, "sum: b2: %s.3 is used before its definition"},
+		{"use before the definition in its block", func(f *IRFunction, b []*IRBlock) {
+			b[4].Instrs[0].Args[0] = b[4].Instrs[1].Dest
+		}, "sum: b4: %s.3 is used before its definition"},
+		{"phi value not available in the predecessor", func(f *IRFunction, b []*IRBlock) {
+			b[5].Instrs[0].Args[0] = b[4].Instrs[1].Dest
+		}, "sum: b5: %s.3 does not dominate the end of b3"},
+		{"register defined twice", func(f *IRFunction, b []*IRBlock) {
+			b[4].Instrs[1].Dest = b[4].Instrs[0].Dest
+		}, "sum: b4: %3 defined twice"},
+		{"undefined register", func(f *IRFunction, b []*IRBlock) {
+			b[2].Instrs[0].Args[0] = &IRReg{Name: "x", Type: DataType_Int}
+		}, "sum: b2: %x is not defined"},
+		{"block without a terminator", func(f *IRFunction, b []*IRBlock) {
+			b[2].Instrs = nil
+		}, "sum: b2: no terminator"},
+		{"terminator in the middle", func(f *IRFunction, b []*IRBlock) {
+			b[4].Instrs = append([]*IRInstr{b[4].Terminator()}, b[4].Instrs...)
+		}, "sum: b4: jump in the middle of the block"},
+		{"phi after another instruction", func(f *IRFunction, b []*IRBlock) {
+			b[1].Instrs[1], b[1].Instrs[2] = b[1].Instrs[2], b[1].Instrs[1]
+		}, "sum: b1: phi after other instructions"},
+		{"successors in the wrong order", func(f *IRFunction, b []*IRBlock) {
+			b[3].Succs[0], b[3].Succs[1] = b[3].Succs[1], b[3].Succs[0]
+		}, "sum: b3: successors do not match the terminator"},
+		{"unreachable block", func(f *IRFunction, b []*IRBlock) {
+			f.newBlock().Instrs = []*IRInstr{{Op: IROp_Return, Args: []IRValue{IRConst{IntValue(0)}}}}
+		}, "sum: 1 blocks are unreachable"},
+		{"operands of different types", func(f *IRFunction, b []*IRBlock) {
+			b[3].Instrs[0].Args[1] = IRConst{FloatValue(3)}
+		}, "sum: b3: %1 int = mod %i.2, 3.0: operands of types int and float"},
+		{"condition that is not a bool", func(f *IRFunction, b []*IRBlock) {
+			b[1].Instrs[3].Args[0] = b[1].Instrs[0].Dest
+		}, "sum: b1: branch %i.2, b3, b2: condition of type int"},
+		{"return without a value", func(f *IRFunction, b []*IRBlock) {
+			b[2].Instrs[0].Args = nil
+		}, "sum: b2: return: does not return int"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program := lowerScript(t, sumScript)
+			f := program.Functions[0]
+			test.mutate(f, f.Blocks)
+			err := program.Verify()
+			if err == nil || err.Error() != test.want {
+				t.Errorf("got %v, want %s", err, test.want)
+			}
+		})
+	}
+}
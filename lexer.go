@@ -1,31 +1,12 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
 	"fmt"
-)
-
-type DfaState int
-
-const (
-	DfaState_Initial DfaState = iota
-	DfaState_Id
-	DfaState_Int1
-	DfaState_Int2
-	DfaState_Int3
-	DfaState_Assignment
-	DfaState_SemiColon
-	DfaState_Left_Paren
-	DfaState_Right_Paren
-	DfaState_GT
-	DfaState_GE
-	DfaState_LT
-	DfaState_LE
-	DfaState_Plus
-	DfaState_Minus
-	DfaState_Star
-	DfaState_Slash
-	DfaState_IntLiteral
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 type TokenType string
@@ -45,10 +26,68 @@ const (
 	TokenType_Minus       = TokenType("Minus")
 	TokenType_Star        = TokenType("Star")
 	TokenType_Slash       = TokenType("Slash")
+	TokenType_Percent     = TokenType("Percent")
 	TokenType_Left_Paren  = TokenType("(")
 	TokenType_Right_Paren = TokenType(")")
+	TokenType_Left_Brace  = TokenType("{")
+	TokenType_Right_Brace = TokenType("}")
+	TokenType_Comma       = TokenType(",")
+	TokenType_EQ          = TokenType("EQ")
+	TokenType_NE          = TokenType("NE")
+	TokenType_Not         = TokenType("Not")
+	TokenType_And         = TokenType("And")
+	TokenType_Or          = TokenType("Or")
+	TokenType_If          = TokenType("If")
+	TokenType_Else        = TokenType("Else")
+	TokenType_While       = TokenType("While")
+	TokenType_For         = TokenType("For")
+	TokenType_Break       = TokenType("Break")
+	TokenType_Continue    = TokenType("Continue")
+	TokenType_Function    = TokenType("Function")
+	TokenType_Return      = TokenType("Return")
+	TokenType_Print       = TokenType("Print")
+
+	TokenType_Float         = TokenType("Float")
+	TokenType_Bool          = TokenType("Bool")
+	TokenType_String        = TokenType("String")
+	TokenType_True          = TokenType("True")
+	TokenType_False         = TokenType("False")
+	TokenType_FloatLiteral  = TokenType("FloatLiteral")
+	TokenType_StringLiteral = TokenType("StringLiteral")
+	TokenType_CharLiteral   = TokenType("CharLiteral")
+
+	// Whitespace and comments are skipped, and the unterminated literals and
+	// comments and invalid numbers are reported, by SimpleLexer.
+	TokenType_Whitespace          = TokenType("Whitespace")
+	TokenType_Comment             = TokenType("Comment")
+	TokenType_UnterminatedString  = TokenType("UnterminatedString")
+	TokenType_UnterminatedChar    = TokenType("UnterminatedChar")
+	TokenType_UnterminatedComment = TokenType("UnterminatedComment")
+	TokenType_InvalidNumber       = TokenType("InvalidNumber")
+
+	// TokenType_EOF ends the tokens returned by Lex.
+	TokenType_EOF = TokenType("EOF")
 )
 
+// keywords are the identifiers reserved by the language.
+var keywords = map[string]TokenType{
+	"int":      TokenType_Int,
+	"float":    TokenType_Float,
+	"bool":     TokenType_Bool,
+	"string":   TokenType_String,
+	"true":     TokenType_True,
+	"false":    TokenType_False,
+	"if":       TokenType_If,
+	"else":     TokenType_Else,
+	"while":    TokenType_While,
+	"for":      TokenType_For,
+	"break":    TokenType_Break,
+	"continue": TokenType_Continue,
+	"function": TokenType_Function,
+	"return":   TokenType_Return,
+	"print":    TokenType_Print,
+}
+
 type TokenReader interface {
 	Read() *Token
 	Peek() *Token
@@ -57,192 +96,378 @@ type TokenReader interface {
 	setPosition(position int)
 }
 
-func isAlpha(ch int32) bool {
-	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
-}
-func isDigit(ch int32) bool {
-	return ch >= '0' && ch <= '9'
-}
-
 type Token struct {
 	Text string
 	Type TokenType
+	Span Span
+	// Value is the value of a literal, parsed by the lexer.
+	Value Value
+	// Trivia is the text skipped before the token: whitespace, comments and
+	// the text of lexical errors.
+	Trivia string
+}
+
+// simpleRules define the tokens of the script language. Identifiers are
+// made of letters, digits and underscores, and do not start with a digit;
+// the ones in keywords are keywords. Integers are decimal or prefixed by 0x,
+// 0o or 0b, floats decimal with a fraction, an exponent or both, and digits
+// may be separated by single underscores. Other runs of letters and digits
+// starting with a digit are invalid numbers.
+var simpleRules = []LexRule{
+	{TokenType_Id, `[\p{L}_][\p{L}\p{Nd}_]*`, 0},
+	{TokenType_IntLiteral, `[0-9](_?[0-9])*|0[xX][0-9a-fA-F](_?[0-9a-fA-F])*|0[oO][0-7](_?[0-7])*|0[bB][01](_?[01])*`, 0},
+	{TokenType_FloatLiteral, `[0-9](_?[0-9])*(\.([0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?|[eE][-+]?[0-9](_?[0-9])*)`, 0},
+	{TokenType_InvalidNumber, `[0-9][0-9A-Za-z_]*(\.[0-9A-Za-z_]*)?`, -1},
+	{TokenType_StringLiteral, `"([^"\\\n]|\\.)*"`, 0},
+	{TokenType_CharLiteral, `'([^'\\\n]|\\.)*'`, 0},
+	{TokenType_UnterminatedString, `"([^"\\\n]|\\.)*\\?`, 0},
+	{TokenType_UnterminatedChar, `'([^'\\\n]|\\.)*\\?`, 0},
+	{TokenType_Whitespace, `[ \t\r\n]+`, 0},
+	{TokenType_Comment, `//[^\n]*|/\*([^*]|\*+[^*/])*\*+/`, 0},
+	{TokenType_UnterminatedComment, `/\*([^*]|\*+[^*/])*\**`, 0},
+	{TokenType_LT, `<`, 0},
+	{TokenType_LE, `<=`, 0},
+	{TokenType_GT, `>`, 0},
+	{TokenType_GE, `>=`, 0},
+	{TokenType_Assignment, `=`, 0},
+	{TokenType_EQ, `==`, 0},
+	{TokenType_Not, `!`, 0},
+	{TokenType_NE, `!=`, 0},
+	{TokenType_And, `&&`, 0},
+	{TokenType_Or, `\|\|`, 0},
+	{TokenType_Plus, `\+`, 0},
+	{TokenType_Minus, `-`, 0},
+	{TokenType_Star, `\*`, 0},
+	{TokenType_Slash, `/`, 0},
+	{TokenType_Percent, `%`, 0},
+	{TokenType_SemiColon, `;`, 0},
+	{TokenType_Left_Paren, `\(`, 0},
+	{TokenType_Right_Paren, `\)`, 0},
+	{TokenType_Left_Brace, `\{`, 0},
+	{TokenType_Right_Brace, `\}`, 0},
+	{TokenType_Comma, `,`, 0},
 }
 
+// simpleDFA is the scanner generated from simpleRules.
+var simpleDFA = MustCompileLexer(simpleRules)
+
 type SimpleLexer struct {
-	tokens    []Token
-	token     Token
-	tokenText *bytes.Buffer
+	tokens []Token
+	errors Diagnostics
+	origin Position
+
+	// in is the input being scanned, pending the bytes read from it but
+	// not decoded yet, ahead the runes decoded but not scanned yet, raw
+	// their bytes and sizes their lengths in bytes, which differ from the
+	// encoding of the runes for invalid UTF-8. pos is the position of the
+	// first rune ahead and err the error that ended the input.
+	in      io.ByteReader
+	pending []byte
+	ahead   []rune
+	raw     []byte
+	sizes   []int
+	pos     Position
+	err     error
 }
 
 func NewSimpleLexer() SimpleLexer {
 	return SimpleLexer{}
 }
 
+// reset starts scanning in from s.origin, forgetting the tokens and errors
+// of a previous input.
+func (s *SimpleLexer) reset(in io.ByteReader) {
+	s.tokens, s.errors = nil, nil
+	s.in, s.pending, s.err = in, s.pending[:0], nil
+	s.ahead, s.raw, s.sizes = s.ahead[:0], s.raw[:0], s.sizes[:0]
+	s.pos = s.origin
+	if s.pos.Line == 0 {
+		s.pos = NewPosition()
+	}
+}
+
+// Lex splits src into tokens, the last of which is a TokenType_EOF one,
+// and returns them with the lexical errors. The trivia and text of the
+// tokens, concatenated, give src back. Lex is safe for concurrent use.
+func Lex(src string) ([]Token, Diagnostics) {
+	var lexer SimpleLexer
+	lexer.reset(strings.NewReader(src))
+	var tokens []Token
+	for {
+		token := lexer.next()
+		tokens = append(tokens, token)
+		if token.Type == TokenType_EOF {
+			return tokens, lexer.errors
+		}
+	}
+}
+
+// tokenize splits script into tokens with next and returns them all, but
+// the TokenType_EOF one.
 func (s *SimpleLexer) tokenize(script string) TokenReader {
-	s.tokenText = new(bytes.Buffer)
-	state := DfaState_Initial
-	for _, ch := range script {
-		switch state {
-		case DfaState_Initial:
-			state = s.initToken(ch)
-		case DfaState_Id:
-			if isAlpha(ch) || isDigit(ch) {
-				s.tokenText.WriteRune(ch)
-			} else {
-				state = s.initToken(ch)
+	s.reset(strings.NewReader(script))
+	for token := s.next(); token.Type != TokenType_EOF; token = s.next() {
+		s.tokens = append(s.tokens, token)
+	}
+	return NewTokenReader(s.tokens)
+}
+
+// peek returns the rune i places after the scanned ones, reading s.in up to
+// it, or false past the end of the input. An invalid UTF-8 byte is read as
+// utf8.RuneError.
+func (s *SimpleLexer) peek(i int) (rune, bool) {
+	for i >= len(s.ahead) {
+		for !utf8.FullRune(s.pending) && s.err == nil {
+			b, err := s.in.ReadByte()
+			if err != nil {
+				s.err = err
+				break
 			}
-		case DfaState_Int1:
-			if ch == 'n' {
-				state = DfaState_Int2
-				s.tokenText.WriteRune(ch)
-			} else if isAlpha(ch) || isDigit(ch) {
-				s.tokenText.WriteRune(ch)
-				state = DfaState_Id
-			} else {
-				state = s.initToken(ch)
+			s.pending = append(s.pending, b)
+		}
+		if len(s.pending) == 0 {
+			return 0, false
+		}
+		ch, size := utf8.DecodeRune(s.pending)
+		s.ahead = append(s.ahead, ch)
+		s.raw = append(s.raw, s.pending[:size]...)
+		s.sizes = append(s.sizes, size)
+		s.pending = s.pending[:copy(s.pending, s.pending[size:])]
+	}
+	return s.ahead[i], true
+}
+
+// consume scans the next n runes and returns their text, made of the bytes
+// of the input, and span.
+func (s *SimpleLexer) consume(n int) (string, Span) {
+	size := 0
+	for _, rs := range s.sizes[:n] {
+		size += rs
+	}
+	text := string(s.raw[:size])
+	s.ahead = s.ahead[:copy(s.ahead, s.ahead[n:])]
+	s.sizes = s.sizes[:copy(s.sizes, s.sizes[n:])]
+	s.raw = s.raw[:copy(s.raw, s.raw[size:])]
+	start := s.pos
+	s.pos = s.pos.endOf(text)
+	return text, Span{start, s.pos}
+}
+
+// next returns the longest token simpleDFA matches at the scanned position,
+// skipping the whitespace and comments into its trivia, with the value of a
+// literal parsed. Runes that start no token, unterminated literals and
+// comments, invalid escapes and numbers, and numbers out of range are
+// recorded in s.errors. At the end of the input, it returns a TokenType_EOF
+// token with the trailing trivia.
+func (s *SimpleLexer) next() Token {
+	var trivia strings.Builder
+	for {
+		end, rule := simpleDFA.longestMatch(s.peek)
+		if rule < 0 {
+			if _, ok := s.peek(0); !ok {
+				return Token{Type: TokenType_EOF, Span: Span{s.pos, s.pos}, Trivia: trivia.String()}
 			}
-		case DfaState_Int2:
-			if ch == 't' {
-				state = DfaState_Int3
-				s.tokenText.WriteRune(ch)
-			} else if isAlpha(ch) || isDigit(ch) {
-				s.tokenText.WriteRune(ch)
-				state = DfaState_Id
+			text, span := s.consume(1)
+			if ch, _ := utf8.DecodeRuneInString(text); ch == utf8.RuneError && len(text) == 1 {
+				s.fail(DiagnosticCode_UnknownCharacter, fmt.Sprintf("invalid UTF-8 byte %#x", text[0]), span)
 			} else {
-				state = s.initToken(ch)
+				s.fail(DiagnosticCode_UnknownCharacter, fmt.Sprintf("unknown character %q", ch), span)
 			}
-		case DfaState_Int3:
-			if ch == ' ' {
-				s.token.Type = TokenType_Int
-				state = s.initToken(ch)
-			} else if isAlpha(ch) || isDigit(ch) {
-				s.tokenText.WriteRune(ch)
-				state = DfaState_Id
-			} else {
-				state = s.initToken(ch)
+			trivia.WriteString(text)
+			continue
+		}
+		text, span := s.consume(end)
+		token := Token{Text: text, Type: simpleDFA.Rules[rule].Type, Span: span}
+		switch token.Type {
+		case TokenType_Whitespace, TokenType_Comment:
+			trivia.WriteString(text)
+			continue
+		case TokenType_UnterminatedString:
+			s.fail(DiagnosticCode_UnterminatedLiteral, "unterminated string literal", token.Span)
+			trivia.WriteString(text)
+			continue
+		case TokenType_UnterminatedChar:
+			s.fail(DiagnosticCode_UnterminatedLiteral, "unterminated character literal", token.Span)
+			trivia.WriteString(text)
+			continue
+		case TokenType_UnterminatedComment:
+			s.fail(DiagnosticCode_UnterminatedComment, "unterminated comment", token.Span)
+			trivia.WriteString(text)
+			continue
+		case TokenType_Id:
+			if keyword, ok := keywords[token.Text]; ok {
+				token.Type = keyword
 			}
+		case TokenType_IntLiteral, TokenType_FloatLiteral:
+			token.Value = s.number(token)
+		case TokenType_InvalidNumber:
+			s.fail(DiagnosticCode_InvalidNumber, "invalid number "+token.Text, token.Span)
+			token.Type, token.Value = TokenType_IntLiteral, IntValue(0)
+		case TokenType_StringLiteral, TokenType_CharLiteral:
+			token.Value = s.checkLiteral(token)
+		}
+		token.Trivia = trivia.String()
+		return token
+	}
+}
 
-		case DfaState_GT:
-			if ch == '=' {
-				s.token.Type = TokenType_GE
-				state = DfaState_GE
-				s.tokenText.WriteRune(ch)
-			} else {
-				state = s.initToken(ch)
-			}
-		case DfaState_GE:
-			state = s.initToken(ch)
-		case DfaState_LT:
-			if ch == '=' {
-				s.token.Type = TokenType_LE
-				state = DfaState_LE
-				s.tokenText.WriteRune(ch)
-			} else {
-				state = s.initToken(ch)
+func (s *SimpleLexer) fail(code DiagnosticCode, message string, span Span) {
+	s.errors = append(s.errors, NewDiagnostic(Severity_Error, code, message, span))
+}
+
+// number returns the value of an integer or float literal, and reports
+// one out of range.
+func (s *SimpleLexer) number(token Token) Value {
+	text := strings.ReplaceAll(token.Text, "_", "")
+	if token.Type == TokenType_FloatLiteral {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			s.fail(DiagnosticCode_NumberOutOfRange, "float literal "+token.Text+" overflows float", token.Span)
+		}
+		return FloatValue(f)
+	}
+	base := 10
+	if len(text) > 1 && text[0] == '0' {
+		switch text[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			text = text[2:]
+		}
+	}
+	i, err := strconv.ParseInt(text, base, 64)
+	if err != nil {
+		s.fail(DiagnosticCode_NumberOutOfRange, "integer literal "+token.Text+" overflows int", token.Span)
+	}
+	return IntValue(int(i))
+}
+
+// checkLiteral returns the value of a string or character literal, the code
+// of its character for the latter. It reports the invalid escapes, and a
+// character literal that is not one character.
+func (s *SimpleLexer) checkLiteral(token Token) Value {
+	value, err := unquote(token.Text)
+	if err, ok := err.(*escapeError); ok {
+		start := token.Span.Start.endOf(token.Text[:err.offset])
+		s.fail(DiagnosticCode_InvalidEscape, err.message, Span{start, start.endOf(token.Text[err.offset:err.end])})
+	}
+	if token.Type == TokenType_StringLiteral {
+		return StringValue(value)
+	}
+	switch utf8.RuneCountInString(value) {
+	case 0:
+		if err == nil {
+			s.fail(DiagnosticCode_InvalidCharLiteral, "empty character literal", token.Span)
+		}
+	case 1:
+	default:
+		s.fail(DiagnosticCode_InvalidCharLiteral, "character literal with more than one character", token.Span)
+	}
+	ch, _ := utf8.DecodeRuneInString(value)
+	return IntValue(int(ch))
+}
+
+// escapeError is an invalid escape sequence, from offset to end in the text
+// of a literal.
+type escapeError struct {
+	offset, end int
+	message     string
+}
+
+func (e *escapeError) Error() string {
+	return e.message
+}
+
+// unquote returns the value of a string or character literal from its text,
+// quotes included. The escapes are \n, \t, \r, \0, \\, \", \' and \u{X}
+// with X from 1 to 6 hexadecimal digits naming a Unicode code point.
+func unquote(text string) (string, error) {
+	var b strings.Builder
+	body := text[1 : len(text)-1]
+	for i := 0; i < len(body); {
+		if body[i] != '\\' {
+			ch, size := utf8.DecodeRuneInString(body[i:])
+			b.WriteRune(ch)
+			i += size
+			continue
+		}
+		start := i + 1
+		if i+1 >= len(body) {
+			return "", &escapeError{start, start + 1, "unterminated escape sequence"}
+		}
+		i += 2
+		switch body[i-1] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case '\\', '"', '\'':
+			b.WriteByte(body[i-1])
+		case 'u':
+			end := strings.IndexByte(body[i:], '}')
+			if i >= len(body) || body[i] != '{' || end < 0 {
+				return "", &escapeError{start, 1 + i, "expecting \\u{X} with hexadecimal digits X"}
 			}
-		case DfaState_LE:
-			state = s.initToken(ch)
-		case DfaState_Assignment:
-			state = s.initToken(ch)
-		case DfaState_Plus:
-			state = s.initToken(ch)
-		case DfaState_Minus:
-			state = s.initToken(ch)
-		case DfaState_Star:
-			state = s.initToken(ch)
-		case DfaState_Slash:
-			state = s.initToken(ch)
-		case DfaState_SemiColon:
-			state = s.initToken(ch)
-		case DfaState_Left_Paren:
-			state = s.initToken(ch)
-		case DfaState_Right_Paren:
-			state = s.initToken(ch)
-		case DfaState_IntLiteral:
-			if isDigit(ch) {
-				s.tokenText.WriteRune(ch)
-			} else {
-				state = s.initToken(ch)
+			end += i
+			digits := body[i+1 : end]
+			code, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+				return "", &escapeError{start, 1 + end + 1, fmt.Sprintf("invalid code point \\u{%s}", digits)}
 			}
+			b.WriteRune(rune(code))
+			i = end + 1
+		default:
+			ch, size := utf8.DecodeRuneInString(body[i-1:])
+			return "", &escapeError{start, start + 1 + size, fmt.Sprintf("unknown escape sequence \\%c", ch)}
 		}
 	}
-	return NewTokenReader(s.tokens)
+	return b.String(), nil
 }
 
-func (s *SimpleLexer) initToken(ch rune) DfaState {
-	if len(s.tokenText.Bytes()) > 0 {
-		s.token.Text = s.tokenText.String()
-		s.tokens = append(s.tokens, s.token)
-	}
-	s.tokenText = new(bytes.Buffer)
-	s.token = Token{}
-	newstate := DfaState_Initial
-	switch {
-	case isAlpha(ch):
-		if ch == 'i' {
-			newstate = DfaState_Int1
-		} else {
-			newstate = DfaState_Id
+// quote returns the text of a string literal of value.
+func quote(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, ch := range value {
+		switch ch {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(ch)
+		default:
+			if ch < ' ' || ch == 0x7f {
+				fmt.Fprintf(&b, `\u{%x}`, ch)
+			} else {
+				b.WriteRune(ch)
+			}
 		}
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Id
-	case isDigit(ch):
-		newstate = DfaState_IntLiteral
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_IntLiteral
-	case ch == '<':
-		newstate = DfaState_LT
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_LT
-	case ch == '>':
-		newstate = DfaState_GT
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_GT
-	case ch == '=':
-		newstate = DfaState_Assignment
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Assignment
-	case ch == '+':
-		newstate = DfaState_Plus
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Plus
-	case ch == '-':
-		newstate = DfaState_Minus
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Minus
-	case ch == '*':
-		newstate = DfaState_Star
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Star
-	case ch == '/':
-		newstate = DfaState_Slash
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Slash
-	case ch == ';':
-		newstate = DfaState_SemiColon
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_SemiColon
-	case ch == '(':
-		newstate = DfaState_Left_Paren
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Left_Paren
-	case ch == ')':
-		newstate = DfaState_Right_Paren
-		s.tokenText.WriteRune(ch)
-		s.token.Type = TokenType_Right_Paren
 	}
-	return newstate
+	b.WriteByte('"')
+	return b.String()
 }
 
 func (lexer *SimpleLexer) dump(reader TokenReader) {
-	fmt.Println("text\ttype")
+	fmt.Println("text\ttype\t\tspan")
 	var token *Token
 	for {
 		if token = reader.Read(); token == nil {
 			break
 		} else {
-			fmt.Printf("%s\t\t%s\n", (*token).Text, (*token).Type)
+			fmt.Printf("%s\t\t%s\t%s\n", (*token).Text, (*token).Type, (*token).Span)
 		}
 	}
 }
@@ -286,3 +511,81 @@ func (s *SimpleTokenReader) setPosition(position int) {
 func NewTokenReader(tokens []Token) TokenReader {
 	return &SimpleTokenReader{tokens: tokens}
 }
+
+// streamBacktrack is how many tokens before its position a
+// StreamTokenReader keeps, so how far back setPosition may go.
+const streamBacktrack = 1024
+
+// StreamTokenReader scans its tokens as they are read, keeping a window of
+// the last streamBacktrack ones, so that its memory does not grow with the
+// input.
+type StreamTokenReader struct {
+	lexer    *SimpleLexer
+	tokens   []Token
+	first    int
+	position int
+	done     bool
+}
+
+// NewStreamTokenReader reads the tokens of in with lexer, which records the
+// lexical errors and the error that ended in, if not io.EOF.
+func NewStreamTokenReader(lexer *SimpleLexer, in io.Reader) TokenReader {
+	bytes, ok := in.(io.ByteReader)
+	if !ok {
+		bytes = bufio.NewReader(in)
+	}
+	lexer.reset(bytes)
+	return &StreamTokenReader{lexer: lexer}
+}
+
+// token returns the token at the position, scanning up to it, or nil at the
+// end of the input.
+func (s *StreamTokenReader) token() *Token {
+	for !s.done && s.position >= s.first+len(s.tokens) {
+		token := s.lexer.next()
+		if token.Type == TokenType_EOF {
+			s.done = true
+			break
+		}
+		if len(s.tokens) >= 2*streamBacktrack && s.position-s.first > streamBacktrack {
+			// Reslicing leaves the tokens already returned in place.
+			drop := s.position - s.first - streamBacktrack
+			s.tokens = s.tokens[drop:]
+			s.first += drop
+		}
+		s.tokens = append(s.tokens, token)
+	}
+	if s.position < s.first+len(s.tokens) {
+		return &s.tokens[s.position-s.first]
+	}
+	return nil
+}
+
+func (s *StreamTokenReader) Read() *Token {
+	token := s.token()
+	if token != nil {
+		s.position++
+	}
+	return token
+}
+
+func (s *StreamTokenReader) Peek() *Token {
+	return s.token()
+}
+
+func (s *StreamTokenReader) UnRead() {
+	if s.position > 0 {
+		s.setPosition(s.position - 1)
+	}
+}
+
+func (s *StreamTokenReader) GetPosition() int {
+	return s.position
+}
+
+func (s *StreamTokenReader) setPosition(position int) {
+	if position < s.first {
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_BacktrackLimit, fmt.Sprintf("statement too long, the parser cannot go back more than %d tokens", streamBacktrack), s.tokens[0].Span))
+	}
+	s.position = position
+}
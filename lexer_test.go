@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// tokenStream renders tokens as their types and quoted texts, without the
+// final EOF one.
+func tokenStream(tokens []Token) string {
+	var parts []string
+	for _, token := range tokens {
+		if token.Type != TokenType_EOF {
+			parts = append(parts, fmt.Sprintf("%s %q", token.Type, token.Text))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		src    string
+		want   string
+		errors []DiagnosticCode
+	}{
+		{"", "", nil},
+		{"int a = 1;", `Int "int" Identifier "a" Assignment "=" IntLiteral "1" SemiColon ";"`, nil},
+		{"a<=b>=c<d>e==f!=g", `Identifier "a" LE "<=" Identifier "b" GE ">=" Identifier "c" LT "<" Identifier "d" GT ">" Identifier "e" EQ "==" Identifier "f" NE "!=" Identifier "g"`, nil},
+		{"!a&&b||c", `Not "!" Identifier "a" And "&&" Identifier "b" Or "||" Identifier "c"`, nil},
+		{"+-*/%(){},", `Plus "+" Minus "-" Star "*" Slash "/" Percent "%" ( "(" ) ")" { "{" } "}" , ","`, nil},
+		{"0x1F 0o17 0b101 1_000", `IntLiteral "0x1F" IntLiteral "0o17" IntLiteral "0b101" IntLiteral "1_000"`, nil},
+		{"1.5 2. 3e10 4.5E-3", `FloatLiteral "1.5" FloatLiteral "2." FloatLiteral "3e10" FloatLiteral "4.5E-3"`, nil},
+		{`"a\"b" 'c'`, `StringLiteral "\"a\\\"b\"" CharLiteral "'c'"`, nil},
+		{"a // comment\nb /* block\n */ c", `Identifier "a" Identifier "b" Identifier "c"`, nil},
+		{"function f() { return true; }", `Function "function" Identifier "f" ( "(" ) ")" { "{" Return "return" True "true" SemiColon ";" } "}"`, nil},
+		{"if else while for break continue print", `If "if" Else "else" While "while" For "for" Break "break" Continue "continue" Print "print"`, nil},
+		{"float bool string false", `Float "float" Bool "bool" String "string" False "false"`, nil},
+		{"12ab", `IntLiteral "12ab"`, []DiagnosticCode{DiagnosticCode_InvalidNumber}},
+		{"99999999999999999999", `IntLiteral "99999999999999999999"`, []DiagnosticCode{DiagnosticCode_NumberOutOfRange}},
+		{"a # b", `Identifier "a" Identifier "b"`, []DiagnosticCode{DiagnosticCode_UnknownCharacter}},
+		{"a \"b", `Identifier "a"`, []DiagnosticCode{DiagnosticCode_UnterminatedLiteral}},
+		{"a /* b", `Identifier "a"`, []DiagnosticCode{DiagnosticCode_UnterminatedComment}},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			tokens, errors := Lex(test.src)
+			if got := tokenStream(tokens); got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+			if last := tokens[len(tokens)-1]; last.Type != TokenType_EOF {
+				t.Errorf("last token %s, want EOF", last.Type)
+			}
+			var codes []DiagnosticCode
+			for _, d := range errors {
+				codes = append(codes, d.Code)
+			}
+			if fmt.Sprint(codes) != fmt.Sprint(test.errors) {
+				t.Errorf("errors %v, want %v", codes, test.errors)
+			}
+		})
+	}
+}
+
+func TestKeywordBoundaries(t *testing.T) {
+	tests := map[string]string{
+		"int(":     `Int "int" ( "("`,
+		"int\n":    `Int "int"`,
+		"int;":     `Int "int" SemiColon ";"`,
+		"integer":  `Identifier "integer"`,
+		"intint":   `Identifier "intint"`,
+		"_x":       `Identifier "_x"`,
+		"x_1":      `Identifier "x_1"`,
+		"_int":     `Identifier "_int"`,
+		"int_":     `Identifier "int_"`,
+		"int1":     `Identifier "int1"`,
+		"é":        `Identifier "é"`,
+		"intégral": `Identifier "intégral"`,
+		"Int":      `Identifier "Int"`,
+	}
+	// Every keyword at the end of the input.
+	for keyword, tokenType := range keywords {
+		tests[keyword] = fmt.Sprintf("%s %q", tokenType, keyword)
+	}
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			tokens, errors := Lex(src)
+			if got := tokenStream(tokens); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+			if len(errors) > 0 {
+				t.Errorf("unexpected %v", errors)
+			}
+		})
+	}
+}
+
+func TestLexRoundTrip(t *testing.T) {
+	tests := []string{
+		"",
+		"int a = 1;\n",
+		"  // comment\nprint(a); /* block */ ",
+		"string s = \"héllo wörld\"; // ünïcode\n",
+		"int a = 1;\xff int b = 2;",
+		"print(\"\xfe\xff\"); // \xc3\n",
+		"a \xe2\x82 b \xe2\x82",
+		"\xf0\x9f\x98\x80 = \xef\xbf\xbd;",
+		"x # @ $",
+		"\"unterminated \xff",
+	}
+	for _, src := range tests {
+		t.Run(fmt.Sprintf("%q", src), func(t *testing.T) {
+			tokens, _ := Lex(src)
+			var b strings.Builder
+			for _, token := range tokens {
+				b.WriteString(token.Trivia)
+				b.WriteString(token.Text)
+				start, end := token.Span.Start.Offset, token.Span.End.Offset
+				if start > end || end > len(src) || src[start:end] != token.Text {
+					t.Errorf("%s %q has offsets %d to %d", token.Type, token.Text, start, end)
+				}
+			}
+			if b.String() != src {
+				t.Errorf("tokens give %q back", b.String())
+			}
+			if eof := tokens[len(tokens)-1].Span.End.Offset; eof != len(src) {
+				t.Errorf("EOF at offset %d of %d bytes", eof, len(src))
+			}
+
+			lexer := SimpleLexer{}
+			reader := NewStreamTokenReader(&lexer, iotest.OneByteReader(strings.NewReader(src)))
+			var streamed []Token
+			for token := reader.Read(); token != nil; token = reader.Read() {
+				streamed = append(streamed, *token)
+			}
+			if len(streamed) != len(tokens)-1 {
+				t.Fatalf("streamed %d tokens, want %d", len(streamed), len(tokens)-1)
+			}
+			for i, token := range streamed {
+				if token.Text != tokens[i].Text || token.Trivia != tokens[i].Trivia || token.Span != tokens[i].Span {
+					t.Errorf("streamed %+v, want %+v", token, tokens[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLexInvalidUTF8(t *testing.T) {
+	tokens, errors := Lex("int a = 1;\xff int b = 2;")
+	if len(errors) != 1 || errors[0].Code != DiagnosticCode_UnknownCharacter || errors[0].Message != "invalid UTF-8 byte 0xff" {
+		t.Fatalf("errors %v, want one invalid byte", errors)
+	}
+	want := Span{Position{10, 1, 11}, Position{11, 1, 12}}
+	if errors[0].Span != want {
+		t.Errorf("error at %+v, want %+v", errors[0].Span, want)
+	}
+	if b := tokens[5]; b.Text != "int" || b.Span.Start.Offset != 12 || b.Span.Start.Column != 13 {
+		t.Errorf("token after the invalid byte: %+v", b)
+	}
+}
+
+// generatedScript is a script of about size bytes.
+func generatedScript(size int) []byte {
+	var b bytes.Buffer
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "int v%d = %d * (v%d + 0x1F) / 3; // comment\nif (v%d >= 2.5e3) { print(\"big\"); }\n", i, i, i, i)
+	}
+	return b.Bytes()
+}
+
+func TestStreamTokenReaderWindow(t *testing.T) {
+	src := generatedScript(1 << 20)
+	lexer := SimpleLexer{}
+	reader := NewStreamTokenReader(&lexer, bytes.NewReader(src)).(*StreamTokenReader)
+	count := 0
+	for reader.Read() != nil {
+		count++
+		if len(reader.tokens) > 2*streamBacktrack {
+			t.Fatalf("%d tokens kept after %d", len(reader.tokens), count)
+		}
+	}
+	if tokens, _ := Lex(string(src)); count != len(tokens)-1 {
+		t.Errorf("read %d tokens, Lex returns %d", count, len(tokens)-1)
+	}
+	reader.setPosition(count - streamBacktrack)
+	defer func() {
+		d, ok := recover().(*Diagnostic)
+		if !ok || d.Code != DiagnosticCode_BacktrackLimit {
+			t.Errorf("got %v, want a backtrack limit error", d)
+		}
+	}()
+	reader.setPosition(count - 3*streamBacktrack)
+}
+
+func TestParseReaderBacktrackLimit(t *testing.T) {
+	// Without its semicolon, the expression statement goes back to its
+	// first token to be parsed as another statement.
+	src := strings.Repeat("a + ", 3*streamBacktrack) + "a"
+	parser := SimpleParser{Origin: NewPosition()}
+	_, err := parser.ParseReader(strings.NewReader(src))
+	diagnostics, _ := err.(Diagnostics)
+	if len(diagnostics) != 1 || diagnostics[0].Code != DiagnosticCode_BacktrackLimit {
+		t.Errorf("got %v, want a backtrack limit error", err)
+	}
+}
+
+func BenchmarkStreamTokenReader(b *testing.B) {
+	src := generatedScript(4 << 20)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lexer := SimpleLexer{}
+		reader := NewStreamTokenReader(&lexer, bytes.NewReader(src))
+		for reader.Read() != nil {
+		}
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	src := string(generatedScript(4 << 20))
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Lex(src)
+	}
+}
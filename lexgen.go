@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+)
+
+// LexRule defines a token by a regular expression. When several rules match
+// the longest input, the one with the highest Priority wins, then the first
+// one.
+//
+// Patterns support alternation |, grouping with parentheses, the repetitions
+// *, + and ?, classes like [a-z_] and [^"], . for any rune but a newline,
+// the Unicode categories and scripts \p{Name}, and the escapes \n, \t, \r
+// and \ before any punctuation.
+type LexRule struct {
+	Type     TokenType
+	Pattern  string
+	Priority int
+}
+
+// runeRange holds the runes from lo to hi, both included.
+type runeRange struct {
+	lo, hi rune
+}
+
+// normalizeRanges sorts ranges and merges the ones that overlap or touch.
+func normalizeRanges(ranges []runeRange) []runeRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].lo < ranges[j].lo
+	})
+	var merged []runeRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.lo <= merged[n-1].hi+1 {
+			if r.hi > merged[n-1].hi {
+				merged[n-1].hi = r.hi
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// negateRanges returns the runes that are not in the normalized ranges.
+func negateRanges(ranges []runeRange) []runeRange {
+	var negated []runeRange
+	next := rune(0)
+	for _, r := range ranges {
+		if r.lo > next {
+			negated = append(negated, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		negated = append(negated, runeRange{next, unicode.MaxRune})
+	}
+	return negated
+}
+
+// nfaState has either a transition to next on the runes of ranges or
+// epsilon transitions. Rule is the index of the rule accepted in the state,
+// -1 if none.
+type nfaState struct {
+	ranges  []runeRange
+	next    int
+	epsilon []int
+	rule    int
+}
+
+// NFA is a nondeterministic automaton built from the patterns of rules with
+// the construction of Thompson: one fragment per pattern, all reached from
+// start by epsilon transitions.
+type NFA struct {
+	states []nfaState
+	start  int
+}
+
+// nfaFragment is a part of an NFA entered by start and left by end, which
+// has no transition yet.
+type nfaFragment struct {
+	start, end int
+}
+
+func (n *NFA) add() int {
+	n.states = append(n.states, nfaState{next: -1, rule: -1})
+	return len(n.states) - 1
+}
+
+func (n *NFA) link(from, to int) {
+	n.states[from].epsilon = append(n.states[from].epsilon, to)
+}
+
+// BuildNFA builds the NFA of rules. It fails on an invalid pattern.
+func BuildNFA(rules []LexRule) (*NFA, error) {
+	n := &NFA{}
+	n.start = n.add()
+	for i, rule := range rules {
+		p := &regexParser{nfa: n, pattern: []rune(rule.Pattern)}
+		fragment, err := p.parse()
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", rule.Type, err)
+		}
+		n.link(n.start, fragment.start)
+		n.states[fragment.end].rule = i
+	}
+	return n, nil
+}
+
+// regexParser builds the fragment of a pattern while parsing it:
+//
+//	alternation = sequence { "|" sequence }
+//	sequence    = { repetition }
+//	repetition  = atom { "*" | "+" | "?" }
+//	atom        = "(" alternation ")" | "[" class "]" | "." | escape | rune
+type regexParser struct {
+	nfa     *NFA
+	pattern []rune
+	pos     int
+}
+
+func (p *regexParser) parse() (nfaFragment, error) {
+	fragment, err := p.alternation()
+	if err == nil && p.pos < len(p.pattern) {
+		err = fmt.Errorf("unexpected %q at %d", p.pattern[p.pos], p.pos)
+	}
+	return fragment, err
+}
+
+func (p *regexParser) peek() (rune, bool) {
+	if p.pos < len(p.pattern) {
+		return p.pattern[p.pos], true
+	}
+	return 0, false
+}
+
+func (p *regexParser) alternation() (nfaFragment, error) {
+	left, err := p.sequence()
+	if err != nil {
+		return left, err
+	}
+	for ch, ok := p.peek(); ok && ch == '|'; ch, ok = p.peek() {
+		p.pos++
+		right, err := p.sequence()
+		if err != nil {
+			return right, err
+		}
+		start, end := p.nfa.add(), p.nfa.add()
+		p.nfa.link(start, left.start)
+		p.nfa.link(start, right.start)
+		p.nfa.link(left.end, end)
+		p.nfa.link(right.end, end)
+		left = nfaFragment{start, end}
+	}
+	return left, nil
+}
+
+func (p *regexParser) sequence() (nfaFragment, error) {
+	state := p.nfa.add()
+	fragment := nfaFragment{state, state}
+	for ch, ok := p.peek(); ok && ch != '|' && ch != ')'; ch, ok = p.peek() {
+		next, err := p.repetition()
+		if err != nil {
+			return next, err
+		}
+		p.nfa.link(fragment.end, next.start)
+		fragment.end = next.end
+	}
+	return fragment, nil
+}
+
+func (p *regexParser) repetition() (nfaFragment, error) {
+	fragment, err := p.atom()
+	if err != nil {
+		return fragment, err
+	}
+	for ch, ok := p.peek(); ok && (ch == '*' || ch == '+' || ch == '?'); ch, ok = p.peek() {
+		p.pos++
+		start, end := p.nfa.add(), p.nfa.add()
+		p.nfa.link(start, fragment.start)
+		p.nfa.link(fragment.end, end)
+		if ch != '+' {
+			p.nfa.link(start, end)
+		}
+		if ch != '?' {
+			p.nfa.link(fragment.end, fragment.start)
+		}
+		fragment = nfaFragment{start, end}
+	}
+	return fragment, nil
+}
+
+func (p *regexParser) atom() (nfaFragment, error) {
+	ch, _ := p.peek()
+	p.pos++
+	var ranges []runeRange
+	switch ch {
+	case '(':
+		fragment, err := p.alternation()
+		if err != nil {
+			return fragment, err
+		}
+		if ch, ok := p.peek(); !ok || ch != ')' {
+			return fragment, fmt.Errorf("missing ) at %d", p.pos)
+		}
+		p.pos++
+		return fragment, nil
+	case '[':
+		var err error
+		if ranges, err = p.class(); err != nil {
+			return nfaFragment{}, err
+		}
+	case '.':
+		ranges = negateRanges([]runeRange{{'\n', '\n'}})
+	case '*', '+', '?':
+		return nfaFragment{}, fmt.Errorf("nothing to repeat at %d", p.pos-1)
+	case '\\':
+		var err error
+		if ranges, err = p.escape(); err != nil {
+			return nfaFragment{}, err
+		}
+	default:
+		ranges = []runeRange{{ch, ch}}
+	}
+	start, end := p.nfa.add(), p.nfa.add()
+	p.nfa.states[start].ranges = ranges
+	p.nfa.states[start].next = end
+	return nfaFragment{start, end}, nil
+}
+
+// escape reads what follows a backslash.
+func (p *regexParser) escape() ([]runeRange, error) {
+	ch, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	p.pos++
+	switch ch {
+	case 'n':
+		ch = '\n'
+	case 't':
+		ch = '\t'
+	case 'r':
+		ch = '\r'
+	case 'p':
+		return p.property()
+	default:
+		if unicode.IsLetter(ch) || unicode.IsDigit(ch) {
+			return nil, fmt.Errorf("unknown escape \\%c at %d", ch, p.pos-2)
+		}
+	}
+	return []runeRange{{ch, ch}}, nil
+}
+
+// property reads the {Name} of a Unicode category or script after \p.
+func (p *regexParser) property() ([]runeRange, error) {
+	start := p.pos
+	if ch, ok := p.peek(); !ok || ch != '{' {
+		return nil, fmt.Errorf("missing { after \\p at %d", start)
+	}
+	end := start
+	for end < len(p.pattern) && p.pattern[end] != '}' {
+		end++
+	}
+	if end == len(p.pattern) {
+		return nil, fmt.Errorf("missing } after \\p at %d", start)
+	}
+	name := string(p.pattern[start+1 : end])
+	p.pos = end + 1
+	table := unicode.Categories[name]
+	if table == nil {
+		table = unicode.Scripts[name]
+	}
+	if table == nil {
+		return nil, fmt.Errorf("unknown Unicode class %s", name)
+	}
+	var ranges []runeRange
+	for _, r := range table.R16 {
+		ranges = appendStride(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		ranges = appendStride(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return normalizeRanges(ranges), nil
+}
+
+// appendStride appends the runes from lo to hi by steps of stride.
+func appendStride(ranges []runeRange, lo, hi, stride rune) []runeRange {
+	if stride == 1 {
+		return append(ranges, runeRange{lo, hi})
+	}
+	for ch := lo; ch <= hi; ch += stride {
+		ranges = append(ranges, runeRange{ch, ch})
+	}
+	return ranges
+}
+
+// class reads a class after its [ up to its ].
+func (p *regexParser) class() ([]runeRange, error) {
+	negated := false
+	if ch, ok := p.peek(); ok && ch == '^' {
+		negated = true
+		p.pos++
+	}
+	var ranges []runeRange
+	for {
+		ch, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("missing ]")
+		}
+		p.pos++
+		if ch == ']' && len(ranges) > 0 {
+			break
+		}
+		if ch == '\\' {
+			escaped, err := p.escape()
+			if err != nil {
+				return nil, err
+			}
+			if len(escaped) > 1 || escaped[0].lo != escaped[0].hi {
+				ranges = append(ranges, escaped...)
+				continue
+			}
+			ch = escaped[0].lo
+		}
+		r := runeRange{ch, ch}
+		if next, ok := p.peek(); ok && next == '-' && p.pos+1 < len(p.pattern) && p.pattern[p.pos+1] != ']' {
+			p.pos++
+			hi := p.pattern[p.pos]
+			p.pos++
+			if hi == '\\' {
+				escaped, err := p.escape()
+				if err != nil {
+					return nil, err
+				}
+				if len(escaped) > 1 || escaped[0].lo != escaped[0].hi {
+					return nil, fmt.Errorf("invalid range end at %d", p.pos)
+				}
+				hi = escaped[0].lo
+			}
+			if hi < ch {
+				return nil, fmt.Errorf("invalid range %c-%c", ch, hi)
+			}
+			r.hi = hi
+		}
+		ranges = append(ranges, r)
+	}
+	ranges = normalizeRanges(ranges)
+	if negated {
+		ranges = negateRanges(ranges)
+	}
+	return ranges, nil
+}
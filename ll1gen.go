@@ -0,0 +1,391 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// tokenTypeNames are the names of the token types a grammar may use, by
+// the type they name.
+var tokenTypeNames = map[TokenType]string{
+	TokenType_Id:            "Id",
+	TokenType_GT:            "GT",
+	TokenType_GE:            "GE",
+	TokenType_LT:            "LT",
+	TokenType_LE:            "LE",
+	TokenType_IntLiteral:    "IntLiteral",
+	TokenType_Int:           "Int",
+	TokenType_Assignment:    "Assignment",
+	TokenType_SemiColon:     "SemiColon",
+	TokenType_Plus:          "Plus",
+	TokenType_Minus:         "Minus",
+	TokenType_Star:          "Star",
+	TokenType_Slash:         "Slash",
+	TokenType_Percent:       "Percent",
+	TokenType_Left_Paren:    "Left_Paren",
+	TokenType_Right_Paren:   "Right_Paren",
+	TokenType_Left_Brace:    "Left_Brace",
+	TokenType_Right_Brace:   "Right_Brace",
+	TokenType_Comma:         "Comma",
+	TokenType_EQ:            "EQ",
+	TokenType_NE:            "NE",
+	TokenType_Not:           "Not",
+	TokenType_And:           "And",
+	TokenType_Or:            "Or",
+	TokenType_If:            "If",
+	TokenType_Else:          "Else",
+	TokenType_While:         "While",
+	TokenType_For:           "For",
+	TokenType_Break:         "Break",
+	TokenType_Continue:      "Continue",
+	TokenType_Function:      "Function",
+	TokenType_Return:        "Return",
+	TokenType_Print:         "Print",
+	TokenType_Float:         "Float",
+	TokenType_Bool:          "Bool",
+	TokenType_String:        "String",
+	TokenType_True:          "True",
+	TokenType_False:         "False",
+	TokenType_FloatLiteral:  "FloatLiteral",
+	TokenType_StringLiteral: "StringLiteral",
+	TokenType_CharLiteral:   "CharLiteral",
+	TokenType_EOF:           "EOF",
+}
+
+// ParserGenerator writes a recursive descent parser for a grammar. The
+// parser has a method per rule, parseStatement for statement, making a node
+// of ASTNodeType the rule name whose children are the nodes of the rules and
+// the tokens matched, in order; a token makes a node of ASTNodeType its
+// TokenType. Parts whose LL(1) predict sets are disjoint are chosen by the
+// next token; conflicting alternatives are tried in order, backtracking on
+// failure, and optional or repeated parts are taken whenever they may start.
+type ParserGenerator struct {
+	Package  string
+	TypeName string
+	// Source names the grammar in the generated code.
+	Source string
+
+	grammar   *Grammar
+	analysis  *GrammarAnalysis
+	terminals map[string]string
+	errors    Diagnostics
+	out       strings.Builder
+}
+
+// Generate returns the formatted source of the parser for g, or the
+// diagnostics that prevent it, which include those of g.Analyze.
+func (gen *ParserGenerator) Generate(g *Grammar) ([]byte, Diagnostics) {
+	analysis, diagnostics := g.Analyze()
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	gen.grammar, gen.analysis = g, analysis
+	gen.terminals = map[string]string{grammarEnd: "TokenType_EOF"}
+	gen.errors = nil
+	gen.out.Reset()
+	g.walk(func(rule *GrammarRule, e *GrammarExpr) {
+		if e.Kind == GrammarExpr_Terminal && gen.terminals[e.Name] == "" {
+			gen.terminals[e.Name] = gen.terminal(e)
+		}
+	})
+	if len(gen.errors) > 0 {
+		return nil, append(diagnostics, gen.errors...)
+	}
+
+	gen.header()
+	for _, rule := range g.Rules {
+		gen.printf("\nfunc (p *%s) %s() ASTNoder {\n", gen.TypeName, ruleMethod(rule.Name))
+		gen.printf("node := NewASTNoder(ASTNodeType(%q), \"\", Span{})\n", rule.Name)
+		gen.expr(rule.Body)
+		gen.printf("p.close(node)\nreturn node\n}\n")
+	}
+	source, err := format.Source([]byte(gen.out.String()))
+	if err != nil {
+		panic(err)
+	}
+	return source, diagnostics
+}
+
+// ruleMethod is the name of the method parsing the rule name.
+func ruleMethod(name string) string {
+	return "parse" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// terminal is the Go expression of the token type of e.
+func (gen *ParserGenerator) terminal(e *GrammarExpr) string {
+	if !strings.HasPrefix(e.Name, "'") {
+		for _, name := range tokenTypeNames {
+			if name == e.Name {
+				return "TokenType_" + name
+			}
+		}
+		gen.errors = append(gen.errors, NewDiagnostic(Severity_Error, DiagnosticCode_UnknownTerminal, "unknown token type "+e.Name, e.Span))
+		return ""
+	}
+	text := e.Name[1 : len(e.Name)-1]
+	tokens, errors := Lex(text)
+	if len(errors) > 0 || len(tokens) != 2 || tokens[0].Text != text {
+		gen.errors = append(gen.errors, NewDiagnostic(Severity_Error, DiagnosticCode_UnknownTerminal, e.Name+" is not a single token", e.Span))
+		return ""
+	}
+	if name, ok := tokenTypeNames[tokens[0].Type]; ok {
+		return "TokenType_" + name
+	}
+	return fmt.Sprintf("TokenType(%q)", tokens[0].Type)
+}
+
+func (gen *ParserGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&gen.out, format, args...)
+}
+
+// at is the call testing that the next token is in set.
+func (gen *ParserGenerator) at(set grammarSet) string {
+	types := make([]string, 0, len(set))
+	for _, t := range set.sorted() {
+		types = append(types, gen.terminals[t])
+	}
+	return "p.at(" + strings.Join(types, ", ") + ")"
+}
+
+// describe lists set for an error message.
+func describe(set grammarSet) string {
+	names := set.sorted()
+	for i, name := range names {
+		if name == grammarEnd {
+			names[i] = "end of input"
+		}
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// expr writes the statements adding to node what e matches.
+func (gen *ParserGenerator) expr(e *GrammarExpr) {
+	a := gen.analysis
+	switch e.Kind {
+	case GrammarExpr_Terminal:
+		gen.printf("node.AddChild(p.match(%s, %q))\n", gen.terminals[e.Name], e.Name)
+	case GrammarExpr_Rule:
+		gen.printf("node.AddChild(p.%s())\n", ruleMethod(e.Name))
+	case GrammarExpr_Sequence:
+		for _, item := range e.Items {
+			gen.expr(item)
+		}
+	case GrammarExpr_Choice:
+		expected := make(grammarSet)
+		for _, set := range a.Predict[e] {
+			expected.add(set)
+		}
+		if a.Conflicts[e] {
+			gen.attempts(e, expected)
+			return
+		}
+		gen.printf("switch {\n")
+		var empty *GrammarExpr
+		for i, item := range e.Items {
+			if a.nullable(item) {
+				empty = item
+				continue
+			}
+			gen.printf("case %s:\n", gen.at(a.Predict[e][i]))
+			gen.expr(item)
+		}
+		gen.printf("default:\n")
+		if empty != nil {
+			gen.expr(empty)
+		} else {
+			gen.printf("p.fail(%q)\n", describe(expected))
+		}
+		gen.printf("}\n")
+	case GrammarExpr_Optional:
+		gen.printf("if %s {\n", gen.at(a.Predict[e][0]))
+		gen.expr(e.Items[0])
+		gen.printf("}\n")
+	case GrammarExpr_Repeat:
+		gen.printf("for %s {\n", gen.at(a.Predict[e][0]))
+		gen.expr(e.Items[0])
+		gen.printf("}\n")
+	case GrammarExpr_RepeatOne:
+		gen.printf("for {\n")
+		gen.expr(e.Items[0])
+		gen.printf("if !%s {\nbreak\n}\n}\n", gen.at(a.Predict[e][0]))
+	}
+}
+
+// attempts writes a choice with conflicts as a chain of alternatives tried
+// in order. Those sharing a token with a later one are attempted, going
+// back if they fail; the others, and the last one, are parsed directly.
+func (gen *ParserGenerator) attempts(e *GrammarExpr, expected grammarSet) {
+	a := gen.analysis
+	sets := a.Predict[e]
+	for i, item := range e.Items {
+		if i > 0 {
+			gen.printf("} else ")
+		}
+		conflict := false
+		for _, later := range sets[i+1:] {
+			if len(sets[i].intersect(later)) > 0 {
+				conflict = true
+			}
+		}
+		switch {
+		case conflict:
+			gen.printf("if %s && p.attempt(node, func(node ASTNoder) {\n", gen.at(sets[i]))
+			gen.expr(item)
+			gen.printf("}) {\n")
+		case i == len(e.Items)-1 && a.nullable(item):
+			gen.printf("{\n")
+			gen.expr(item)
+			gen.printf("}\n")
+			return
+		default:
+			gen.printf("if %s {\n", gen.at(sets[i]))
+			gen.expr(item)
+		}
+	}
+	gen.printf("} else {\np.fail(%q)\n}\n", describe(expected))
+}
+
+func (gen *ParserGenerator) header() {
+	start := gen.grammar.Rules[0].Name
+	gen.printf(`// Code generated by compiler ll1 from %[3]s; DO NOT EDIT.
+
+package %[1]s
+
+import (
+	"io"
+	"strings"
+)
+
+// %[2]s parses the grammar of %[3]s.
+type %[2]s struct {
+	reader TokenReader
+	// failure is the error of a failed alternative that went the furthest.
+	failure *Diagnostic
+}
+
+// Parse builds the tree of code from the rule %[4]s. It stops at the first
+// syntax error.
+func (p *%[2]s) Parse(code string) (ASTNoder, error) {
+	return p.ParseReader(strings.NewReader(code))
+}
+
+// ParseReader is like Parse but scans the code from in.
+func (p *%[2]s) ParseReader(in io.Reader) (root ASTNoder, err error) {
+	lexer := SimpleLexer{}
+	p.reader = NewStreamTokenReader(&lexer, in)
+	p.failure = nil
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			root, err = nil, append(lexer.errors, d)
+		} else if len(lexer.errors) > 0 {
+			err = lexer.errors
+		}
+		if lexer.err != nil && lexer.err != io.EOF {
+			err = lexer.err
+		}
+	}()
+	root = p.%[5]s()
+	if !p.at(TokenType_EOF) {
+		p.fail("end of input")
+	}
+	return root, nil
+}
+
+// at reports whether the next token has one of types, TokenType_EOF
+// standing for the end of the input.
+func (p *%[2]s) at(types ...TokenType) bool {
+	next := TokenType_EOF
+	if token := p.reader.Peek(); token != nil {
+		next = token.Type
+	}
+	for _, t := range types {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *%[2]s) match(tokenType TokenType, expected string) ASTNoder {
+	if !p.at(tokenType) {
+		p.fail(expected)
+	}
+	token := p.reader.Read()
+	leaf := NewASTNoder(ASTNodeType(token.Type), token.Text, token.Span)
+	leaf.SetValue(token.Value)
+	return leaf
+}
+
+// fail aborts parsing at the next token, or at the error of an alternative
+// tried before that went further.
+func (p *%[2]s) fail(expected string) {
+	span := currentSpan(p.reader)
+	if p.failure != nil && p.failure.Span.Start.Offset > span.Start.Offset {
+		panic(p.failure)
+	}
+	found := "end of input"
+	if token := p.reader.Peek(); token != nil {
+		found = token.Text
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_UnexpectedToken, "unexpected "+found+", expecting "+expected, span))
+}
+
+// attempt parses alternative, adding what it matched to node if it
+// succeeds, or going back to where it started if it fails.
+func (p *%[2]s) attempt(node ASTNoder, alternative func(node ASTNoder)) (ok bool) {
+	position := p.reader.GetPosition()
+	try := NewASTNoder(node.GetType(), "", Span{})
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				d, isDiagnostic := r.(*Diagnostic)
+				if !isDiagnostic || d.Code == DiagnosticCode_BacktrackLimit {
+					panic(r)
+				}
+				if p.failure == nil || d.Span.Start.Offset >= p.failure.Span.Start.Offset {
+					p.failure = d
+				}
+			}
+		}()
+		alternative(try)
+		ok = true
+	}()
+	if !ok {
+		failure := p.failure
+		defer func() {
+			// Too far to go back: the error of the alternative is the best
+			// there is.
+			if r := recover(); r != nil {
+				panic(failure)
+			}
+		}()
+		p.reader.setPosition(position)
+		return false
+	}
+	for _, child := range try.GetChildren() {
+		node.AddChild(child)
+	}
+	return true
+}
+
+// close gives node the span of its children, or an empty one at the next
+// token.
+func (p *%[2]s) close(node ASTNoder) {
+	children := node.GetChildren()
+	if len(children) == 0 {
+		start := currentSpan(p.reader).Start
+		node.SetSpan(Span{Start: start, End: start})
+		return
+	}
+	node.SetSpan(joinSpan(children[0].GetSpan(), children[len(children)-1].GetSpan()))
+}
+`, gen.Package, gen.TypeName, gen.Source, start, ruleMethod(start))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSimpleGrammarUpToDate(t *testing.T) {
+	source, err := os.ReadFile("simpleparser.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grammar, diagnostics := ReadGrammar(string(source))
+	if grammar == nil {
+		t.Fatal(diagnostics)
+	}
+	generator := ParserGenerator{Package: "main", TypeName: "SimpleGrammarParser", Source: "simpleparser.go"}
+	got, diagnostics := generator.Generate(grammar)
+	if got == nil {
+		t.Fatal(diagnostics)
+	}
+	want, err := os.ReadFile("simplegrammar_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("simplegrammar_test.go is out of date; run go generate")
+	}
+}
+
+// contextErrors are the errors of SimpleParser about where a statement
+// is, which the grammar does not tell.
+var contextErrors = map[DiagnosticCode]bool{
+	DiagnosticCode_JumpOutsideLoop:   true,
+	DiagnosticCode_ReturnOutsideFunc: true,
+	DiagnosticCode_NestedFunction:    true,
+}
+
+func TestGrammarParserAccepts(t *testing.T) {
+	programs := []string{
+		"", "int a = 1;", "float f; bool b = true; string s = \"s\";", "a = b + 1;",
+		"if (a) print(1); else if (b) { print(2); } else print(3);",
+		"while (a < 10) { a = a + 1; if (a == 5) break; continue; }",
+		"for (int i = 0; i < 3; i = i + 1) print(i); for (i = 0; ; ) {} for (;;) { break; }",
+		"int f(int a, float b) { return a; } function g() { return; }",
+		"print(f(1, 2.5) + g() * int(3.5) - float(a));", "{ { } }", "a; 1; (a);",
+		"int a = ;", "int = 1;", "a + ;", "print(1)", "{ int a = 1;", "for (;;;) {}",
+		"int f( { }", "1 = 2;", "if a print(1);", "else print(1);", "f(1,);", "a = = b;",
+		"int f() { int g() { } }", "break;", "return 1;", "a b;", "int float;",
+	}
+	for _, file := range samples(t) {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		programs = append(programs, string(source))
+	}
+	for _, program := range programs {
+		parser := SimpleParser{Origin: NewPosition()}
+		_, err := parser.Parse(program)
+		accepted := true
+		if diagnostics, ok := err.(Diagnostics); ok {
+			for _, d := range diagnostics {
+				accepted = accepted && contextErrors[d.Code]
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		var grammarParser SimpleGrammarParser
+		_, grammarErr := grammarParser.Parse(program)
+		if accepted != (grammarErr == nil) {
+			name := program
+			if i := strings.IndexByte(name, '\n'); i >= 0 {
+				name = name[:i] + "..."
+			}
+			t.Errorf("%s: SimpleParser: %v, SimpleGrammarParser: %v", name, err, grammarErr)
+		}
+	}
+}
+
+// cstExpr writes the expression node of the SimpleGrammarParser tree like
+// sexpr the one of SimpleParser.
+func cstExpr(node ASTNoder) string {
+	children := node.GetChildren()
+	switch {
+	case len(children) == 0:
+		return node.GetText()
+	case len(children) == 1:
+		return cstExpr(children[0])
+	case children[0].GetText() == "(":
+		return cstExpr(children[1])
+	case len(children) == 2:
+		return "(" + children[0].GetText() + " " + cstExpr(children[1]) + ")"
+	}
+	left := cstExpr(children[0])
+	for i := 1; i+1 < len(children); i += 2 {
+		left = "(" + children[i].GetText() + " " + left + " " + cstExpr(children[i+1]) + ")"
+	}
+	return left
+}
+
+func TestGrammarParserTrees(t *testing.T) {
+	var expressions []string
+	for _, first := range simpleOperators {
+		for _, second := range simpleOperators {
+			a, b := operatorText[first.Token], operatorText[second.Token]
+			switch {
+			case first.Fixity == Fixity_Prefix && second.Fixity == Fixity_Prefix:
+				expressions = append(expressions, fmt.Sprintf("%s %s x", a, b))
+			case first.Fixity == Fixity_Prefix:
+				expressions = append(expressions, fmt.Sprintf("%s x %s y", a, b), fmt.Sprintf("%s (x %s y)", a, b))
+			case second.Fixity == Fixity_Prefix:
+				expressions = append(expressions, fmt.Sprintf("x %s %s y", a, b))
+			default:
+				expressions = append(expressions, fmt.Sprintf("x %s y %s z", a, b), fmt.Sprintf("x %s (y %s z)", a, b))
+			}
+		}
+	}
+	expressions = append(expressions, "-(a + b) * !c", "a || b && c == d < e + f * -g", "((a))")
+	for _, expression := range expressions {
+		src := expression + ";"
+		parser := SimpleParser{Origin: NewPosition()}
+		root, err := parser.Parse(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		var grammarParser SimpleGrammarParser
+		cst, err := grammarParser.Parse(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		statement := cst.GetChildren()[0].GetChildren()[0]
+		if got, want := cstExpr(statement.GetChildren()[0]), sexpr((*root).GetChildren()[0]); got != want {
+			t.Errorf("%s: SimpleGrammarParser %s, SimpleParser %s", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// llvmLoop holds the blocks that break and continue branch to.
+type llvmLoop struct {
+	breakLabel    string
+	continueLabel string
+}
+
+// LLVMGenerator translates an AST annotated by a Resolver and a TypeChecker to
+// textual LLVM IR. Every variable gets an alloca in the entry block of its
+// function, or a module global, so the IR is in SSA form without phi nodes
+// except for the short-circuit operators; mem2reg turns the allocas into
+// registers. Pointers are opaque, which LLVM 14 accepts with
+// -opaque-pointers. Print and runtime errors use the C library.
+type LLVMGenerator struct {
+	MaxCallDepth int
+	out          strings.Builder
+	globals      strings.Builder
+	allocas      strings.Builder
+	body         strings.Builder
+	temps        int
+	labels       int
+	strings      int
+	block        string
+	function     *Symbol
+	slots        map[*Symbol]string
+	loops        []llvmLoop
+}
+
+func NewLLVMGenerator() *LLVMGenerator {
+	return &LLVMGenerator{MaxCallDepth: DefaultMaxCallDepth}
+}
+
+// Generate returns the IR module of the whole program root.
+func (g *LLVMGenerator) Generate(root ASTNoder) (ir string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			ir, err = "", d
+		}
+	}()
+	g.out.Reset()
+	g.globals.Reset()
+	g.strings = 0
+	g.slots = make(map[*Symbol]string)
+	g.declareGlobals(root)
+
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			g.functionDeclare(child)
+		}
+	}
+	g.begin("i32 @main()")
+	for _, child := range root.GetChildren() {
+		g.statement(child)
+	}
+	g.emit("ret i32 0")
+	g.end()
+
+	var module strings.Builder
+	module.WriteString(llvmPrelude)
+	module.WriteString(g.globals.String())
+	module.WriteString(g.out.String())
+	return module.String(), nil
+}
+
+// declareGlobals gives every global variable of root a module global.
+func (g *LLVMGenerator) declareGlobals(node ASTNoder) {
+	if isVariableDeclaration(node.GetType()) && node.GetSymbol().IsGlobal() {
+		symbol := node.GetSymbol()
+		t := llvmType(node, symbol.Type)
+		g.slots[symbol] = fmt.Sprintf(`@"g%d.%s"`, symbol.Index, symbol.Name)
+		fmt.Fprintf(&g.globals, "%s = internal global %s %s\n", g.slots[symbol], t, llvmZero(symbol.Type))
+	}
+	for _, child := range node.GetChildren() {
+		g.declareGlobals(child)
+	}
+}
+
+// llvmType is the IR type of values of type t.
+func llvmType(node ASTNoder, t DataType) string {
+	switch t {
+	case DataType_Int:
+		return "i64"
+	case DataType_Float:
+		return "double"
+	case DataType_Bool:
+		return "i1"
+	case DataType_Void:
+		return "void"
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_Unsupported, fmt.Sprintf("the LLVM backend does not support %s values", t), node.GetSpan()))
+}
+
+func llvmZero(t DataType) string {
+	switch t {
+	case DataType_Float:
+		return "0.0"
+	case DataType_Bool:
+		return "false"
+	}
+	return "0"
+}
+
+func functionName(symbol *Symbol) string {
+	return fmt.Sprintf(`@"fn.%s"`, symbol.Name)
+}
+
+// begin starts a function with the given signature. Allocas and code are
+// collected separately, so that the allocas end up in the entry block.
+func (g *LLVMGenerator) begin(signature string) {
+	fmt.Fprintf(&g.out, "\ndefine %s {\nentry:\n", signature)
+	g.allocas.Reset()
+	g.body.Reset()
+	g.temps, g.labels = 0, 0
+	g.block = "entry"
+}
+
+func (g *LLVMGenerator) end() {
+	g.out.WriteString(g.allocas.String())
+	g.out.WriteString(g.body.String())
+	g.out.WriteString("}\n")
+}
+
+func (g *LLVMGenerator) emit(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, "  "+format+"\n", args...)
+}
+
+// temp emits an instruction producing a new value and returns its name.
+func (g *LLVMGenerator) temp(format string, args ...interface{}) string {
+	g.temps++
+	name := fmt.Sprintf("%%t%d", g.temps)
+	fmt.Fprintf(&g.body, "  %s = "+format+"\n", append([]interface{}{name}, args...)...)
+	return name
+}
+
+func (g *LLVMGenerator) label(prefix string) string {
+	g.labels++
+	return fmt.Sprintf("%s%d", prefix, g.labels)
+}
+
+// startBlock begins the basic block name.
+func (g *LLVMGenerator) startBlock(name string) {
+	fmt.Fprintf(&g.body, "%s:\n", name)
+	g.block = name
+}
+
+// terminate emits a terminator. The code following it, if any, goes to a
+// new block that nothing branches to.
+func (g *LLVMGenerator) terminate(format string, args ...interface{}) {
+	g.emit(format, args...)
+	g.startBlock(g.label("dead"))
+}
+
+// constantString adds a NUL terminated string to the module and returns its
+// name.
+func (g *LLVMGenerator) constantString(s string) string {
+	g.strings++
+	name := fmt.Sprintf("@.str.%d", g.strings)
+	var b strings.Builder
+	for _, c := range []byte(s + "\x00") {
+		if c < ' ' || c >= 0x7f || c == '"' || c == '\\' {
+			fmt.Fprintf(&b, "\\%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	fmt.Fprintf(&g.globals, "%s = private unnamed_addr constant [%d x i8] c\"%s\"\n", name, len(s)+1, b.String())
+	return name
+}
+
+// runtimeError branches to a block reporting d and exiting when failed is
+// true.
+func (g *LLVMGenerator) runtimeError(failed string, d *Diagnostic) {
+	message := d.Error() + "\n"
+	text := g.constantString(message)
+	errorLabel, ok := g.label("error"), g.label("ok")
+	g.emit("br i1 %s, label %%%s, label %%%s", failed, errorLabel, ok)
+	g.startBlock(errorLabel)
+	g.emit("call void @__runtime_error(ptr %s, i64 %d)", text, len(message))
+	g.emit("unreachable")
+	g.startBlock(ok)
+}
+
+func (g *LLVMGenerator) functionDeclare(node ASTNoder) {
+	symbol := node.GetSymbol()
+	params := make([]string, len(symbol.Params))
+	for i, param := range symbol.Params {
+		params[i] = fmt.Sprintf("%s %%%s", llvmType(param.Decl, param.Type), llvmLocal(param))
+	}
+	g.function = symbol
+	g.begin(fmt.Sprintf("internal %s %s(%s)", llvmType(node, symbol.Type), functionName(symbol), strings.Join(params, ", ")))
+	for _, param := range symbol.Params {
+		slot := g.alloca(param)
+		g.emit("store %s %%%s, ptr %s", llvmType(param.Decl, param.Type), llvmLocal(param), slot)
+	}
+	children := node.GetChildren()
+	g.statement(children[len(children)-1])
+	if symbol.Type == DataType_Void {
+		g.emit("ret void")
+	} else {
+		g.emit("ret %s %s", llvmType(node, symbol.Type), llvmZero(symbol.Type))
+	}
+	g.end()
+	g.function = nil
+}
+
+func llvmLocal(symbol *Symbol) string {
+	return fmt.Sprintf(`"%s.%d"`, symbol.Name, symbol.Index)
+}
+
+// alloca gives a local variable or parameter its stack slot.
+func (g *LLVMGenerator) alloca(symbol *Symbol) string {
+	slot := fmt.Sprintf(`%%"%s.%d.addr"`, symbol.Name, symbol.Index)
+	fmt.Fprintf(&g.allocas, "  %s = alloca %s\n", slot, llvmType(symbol.Decl, symbol.Type))
+	g.slots[symbol] = slot
+	return slot
+}
+
+func (g *LLVMGenerator) statement(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_FunctionDeclaration, ASTNodeType_Empty:
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		symbol := node.GetSymbol()
+		t := llvmType(node, symbol.Type)
+		value := llvmZero(symbol.Type)
+		if len(children) > 0 {
+			value = g.expression(children[0])
+		}
+		slot, ok := g.slots[symbol]
+		if !ok {
+			slot = g.alloca(symbol)
+		}
+		g.emit("store %s %s, ptr %s", t, value, slot)
+	case ASTNodeType_Block:
+		for _, child := range children {
+			g.statement(child)
+		}
+	case ASTNodeType_If:
+		thenLabel, elseLabel, end := g.label("then"), g.label("else"), g.label("endif")
+		g.emit("br i1 %s, label %%%s, label %%%s", g.expression(children[0]), thenLabel, elseLabel)
+		g.startBlock(thenLabel)
+		g.statement(children[1])
+		g.emit("br label %%%s", end)
+		g.startBlock(elseLabel)
+		if len(children) > 2 {
+			g.statement(children[2])
+		}
+		g.emit("br label %%%s", end)
+		g.startBlock(end)
+	case ASTNodeType_While:
+		start, bodyLabel, end := g.label("while"), g.label("body"), g.label("endwhile")
+		g.emit("br label %%%s", start)
+		g.startBlock(start)
+		g.emit("br i1 %s, label %%%s, label %%%s", g.expression(children[0]), bodyLabel, end)
+		g.startBlock(bodyLabel)
+		g.loopBody(children[1], end, start)
+		g.emit("br label %%%s", start)
+		g.startBlock(end)
+	case ASTNodeType_For:
+		start, bodyLabel, step, end := g.label("for"), g.label("body"), g.label("step"), g.label("endfor")
+		g.statement(children[0])
+		g.emit("br label %%%s", start)
+		g.startBlock(start)
+		if children[1].GetType() != ASTNodeType_Empty {
+			g.emit("br i1 %s, label %%%s, label %%%s", g.expression(children[1]), bodyLabel, end)
+		} else {
+			g.emit("br label %%%s", bodyLabel)
+		}
+		g.startBlock(bodyLabel)
+		g.loopBody(children[3], end, step)
+		g.emit("br label %%%s", step)
+		g.startBlock(step)
+		g.statement(children[2])
+		g.emit("br label %%%s", start)
+		g.startBlock(end)
+	case ASTNodeType_Break:
+		g.terminate("br label %%%s", g.loops[len(g.loops)-1].breakLabel)
+	case ASTNodeType_Continue:
+		g.terminate("br label %%%s", g.loops[len(g.loops)-1].continueLabel)
+	case ASTNodeType_Return:
+		if len(children) > 0 {
+			g.terminate("ret %s %s", llvmType(node, g.function.Type), g.expression(children[0]))
+		} else {
+			g.terminate("ret void")
+		}
+	case ASTNodeType_Print:
+		g.print(children[0])
+	case ASTNodeType_Error:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, "cannot compile a statement with syntax errors", node.GetSpan()))
+	default:
+		g.expression(node)
+	}
+}
+
+func (g *LLVMGenerator) loopBody(node ASTNoder, breakLabel, continueLabel string) {
+	g.loops = append(g.loops, llvmLoop{breakLabel, continueLabel})
+	g.statement(node)
+	g.loops = g.loops[:len(g.loops)-1]
+}
+
+// print writes the value like the interpreter does: floats with %g and
+// bools as true or false.
+func (g *LLVMGenerator) print(node ASTNoder) {
+	value := g.expression(node)
+	switch t := node.GetDataType(); t {
+	case DataType_Int:
+		g.temp("call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %s)", value)
+	case DataType_Float:
+		g.temp("call i32 (ptr, ...) @printf(ptr @.fmt.float, double %s)", value)
+	case DataType_Bool:
+		text := g.temp("select i1 %s, ptr @.str.true, ptr @.str.false", value)
+		g.temp("call i32 (ptr, ...) @printf(ptr %s)", text)
+	default:
+		llvmType(node, t)
+	}
+}
+
+var llvmConditions = map[string][2]string{
+	"<": {"slt", "olt"}, "<=": {"sle", "ole"}, ">": {"sgt", "ogt"}, ">=": {"sge", "oge"}, "==": {"eq", "oeq"}, "!=": {"ne", "une"},
+}
+
+var llvmArithmetic = map[string][2]string{
+	"+": {"add", "fadd"}, "-": {"sub", "fsub"}, "*": {"mul", "fmul"}, "/": {"sdiv", "fdiv"}, "%": {"srem", "frem"},
+}
+
+// expression returns the operand holding the value of node.
+func (g *LLVMGenerator) expression(node ASTNoder) string {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		v := node.GetValue()
+		switch v.Type {
+		case DataType_Float:
+			// A hexadecimal constant is exact for every double.
+			return fmt.Sprintf("0x%016X", math.Float64bits(v.Float))
+		case DataType_Int, DataType_Bool:
+			return v.String()
+		}
+		llvmType(node, v.Type)
+	case ASTNodeType_Identifier:
+		symbol := node.GetSymbol()
+		return g.temp("load %s, ptr %s", llvmType(node, symbol.Type), g.slots[symbol])
+	case ASTNodeType_Assignment:
+		symbol := node.GetSymbol()
+		value := g.expression(children[0])
+		g.emit("store %s %s, ptr %s", llvmType(node, symbol.Type), value, g.slots[symbol])
+		return value
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		return g.binary(node)
+	case ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		left := g.expression(children[0])
+		from := g.block
+		rightLabel, end := g.label("rhs"), g.label("endlogic")
+		short := "false"
+		if node.GetType() == ASTNodeType_LogicalAnd {
+			g.emit("br i1 %s, label %%%s, label %%%s", left, rightLabel, end)
+		} else {
+			short = "true"
+			g.emit("br i1 %s, label %%%s, label %%%s", left, end, rightLabel)
+		}
+		g.startBlock(rightLabel)
+		right := g.expression(children[1])
+		g.emit("br label %%%s", end)
+		rightBlock := g.block
+		g.startBlock(end)
+		return g.temp("phi i1 [ %s, %%%s ], [ %s, %%%s ]", short, from, right, rightBlock)
+	case ASTNodeType_Unary:
+		value := g.expression(children[0])
+		switch {
+		case node.GetText() == "!":
+			return g.temp("xor i1 %s, true", value)
+		case node.GetText() == "-" && node.GetDataType() == DataType_Float:
+			return g.temp("fneg double %s", value)
+		case node.GetText() == "-":
+			return g.temp("sub i64 0, %s", value)
+		}
+		return value
+	case ASTNodeType_Conversion:
+		return g.conversion(node)
+	case ASTNodeType_FunctionCall:
+		return g.call(node)
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, fmt.Sprintf("cannot compile %s as an expression", node.GetType()), node.GetSpan()))
+}
+
+func (g *LLVMGenerator) binary(node ASTNoder) string {
+	children := node.GetChildren()
+	operandType := children[0].GetDataType()
+	t := llvmType(node, operandType)
+	left := g.expression(children[0])
+	right := g.expression(children[1])
+	operator := node.GetText()
+	if condition, ok := llvmConditions[operator]; ok {
+		if operandType == DataType_Float {
+			return g.temp("fcmp %s double %s, %s", condition[1], left, right)
+		}
+		return g.temp("icmp %s %s %s, %s", condition[0], t, left, right)
+	}
+	if operandType == DataType_Float {
+		return g.temp("%s double %s, %s", llvmArithmetic[operator][1], left, right)
+	}
+	if operator == "/" || operator == "%" {
+		zero := g.temp("icmp eq i64 %s, 0", right)
+		g.runtimeError(zero, NewDiagnostic(Severity_Error, DiagnosticCode_DivisionByZero, errDivisionByZero.Error(), node.GetSpan()))
+		// The minimum int divided by -1 overflows, which is undefined in
+		// LLVM. Divide by 1 instead and fix the result up.
+		minusOne := g.temp("icmp eq i64 %s, -1", right)
+		divisor := g.temp("select i1 %s, i64 1, i64 %s", minusOne, right)
+		result := g.temp("%s i64 %s, %s", llvmArithmetic[operator][0], left, divisor)
+		if operator == "/" {
+			negated := g.temp("sub i64 0, %s", left)
+			return g.temp("select i1 %s, i64 %s, i64 %s", minusOne, negated, result)
+		}
+		return g.temp("select i1 %s, i64 0, i64 %s", minusOne, result)
+	}
+	return g.temp("%s i64 %s, %s", llvmArithmetic[operator][0], left, right)
+}
+
+func (g *LLVMGenerator) conversion(node ASTNoder) string {
+	child := node.GetChildren()[0]
+	value := g.expression(child)
+	from, to := child.GetDataType(), node.GetDataType()
+	llvmType(child, from)
+	llvmType(node, to)
+	switch {
+	case from == to:
+		return value
+	case from == DataType_Int && to == DataType_Float:
+		return g.temp("sitofp i64 %s to double", value)
+	case from == DataType_Float && to == DataType_Int:
+		return g.temp("fptosi double %s to i64", value)
+	case from == DataType_Int && to == DataType_Bool:
+		return g.temp("icmp ne i64 %s, 0", value)
+	case from == DataType_Float && to == DataType_Bool:
+		return g.temp("fcmp une double %s, 0.0", value)
+	case from == DataType_Bool && to == DataType_Int:
+		return g.temp("zext i1 %s to i64", value)
+	}
+	return g.temp("uitofp i1 %s to double", value)
+}
+
+// call keeps the call depth in @__depth to report a stack overflow like the
+// interpreter.
+func (g *LLVMGenerator) call(node ASTNoder) string {
+	symbol := node.GetSymbol()
+	args := make([]string, len(node.GetChildren()))
+	for i, arg := range node.GetChildren() {
+		args[i] = fmt.Sprintf("%s %s", llvmType(arg, arg.GetDataType()), g.expression(arg))
+	}
+	depth := g.temp("load i64, ptr @__depth")
+	overflow := g.temp("icmp sge i64 %s, %d", depth, g.MaxCallDepth)
+	g.runtimeError(overflow, NewDiagnostic(Severity_Error, DiagnosticCode_StackOverflow,
+		fmt.Sprintf("stack overflow: call depth exceeds %d", g.MaxCallDepth), node.GetSpan()))
+	g.emit("store i64 %s, ptr @__depth", g.temp("add i64 %s, 1", depth))
+	var result string
+	call := fmt.Sprintf("call %s %s(%s)", llvmType(node, symbol.Type), functionName(symbol), strings.Join(args, ", "))
+	if symbol.Type == DataType_Void {
+		g.emit("%s", call)
+	} else {
+		result = g.temp("%s", call)
+	}
+	g.emit("store i64 %s, ptr @__depth", depth)
+	return result
+}
+
+// llvmPrelude declares the C library functions and the runtime support.
+const llvmPrelude = `; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+`
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// build compiles file for target with the build command and returns the
+// output, or skips the test if the target does not support the program.
+func build(t *testing.T, target string, file string, args ...string) []byte {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "out")
+	var status int
+	_, stderr := capture(t, func() {
+		status = buildCommand(append([]string{"-target=" + target, file, "-o", output}, args...))
+	})
+	if status != 0 && strings.Contains(stderr, string(DiagnosticCode_Unsupported)) {
+		t.Skip("not supported by the " + target + " target")
+	}
+	if status != 0 {
+		t.Fatalf("build failed: %s", stderr)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// golden compares got to the file of testdata named after the program file
+// with extension, or rewrites it with -update.
+func golden(t *testing.T, file string, extension string, got []byte) {
+	t.Helper()
+	path := strings.TrimSuffix(file, ".ss") + extension
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run go test -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; run go test -update and review the diff\n%s", path, got)
+	}
+}
+
+func TestLLVMGolden(t *testing.T) {
+	lli, _ := exec.LookPath("lli")
+	lliArgs := []string{"-"}
+	// LLVM before 15 only reads ptr types with -opaque-pointers, which later
+	// versions no longer accept.
+	if lli != "" && exec.Command(lli, "-opaque-pointers", "-version").Run() == nil {
+		lliArgs = []string{"-opaque-pointers", "-"}
+	}
+	for _, file := range samples(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			ir := build(t, "llvm", file)
+			golden(t, file, ".ll", ir)
+			if lli == "" {
+				return
+			}
+			want := runProgram(t, file)
+			var out bytes.Buffer
+			cmd := exec.Command(lli, lliArgs...)
+			cmd.Stdin, cmd.Stdout = bytes.NewReader(ir), &out
+			cmd.Run()
+			if out.String() != want.stdout {
+				t.Errorf("lli printed\n%s\nwant\n%s", out.String(), want.stdout)
+			}
+			if got := cmd.ProcessState.ExitCode(); got != want.status {
+				t.Errorf("lli exit status %d, want %d", got, want.status)
+			}
+		})
+	}
+}
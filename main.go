@@ -4,30 +4,74 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
 var (
-	verbose bool
+	verbose           bool
+	maxCallDepth      int
+	backend           string
+	disassemble       bool
+	optimizationLevel int
+	printPasses       bool
 )
 
 func init() {
 	flag.BoolVar(&verbose, "v", false, "-v 1 to print detail")
+	flag.IntVar(&maxCallDepth, "maxdepth", DefaultMaxCallDepth, "maximum function call depth")
+	flag.StringVar(&backend, "backend", "tree", "tree to walk the AST, vm to compile to bytecode")
+	flag.BoolVar(&disassemble, "disasm", false, "print the bytecode of the vm backend")
+	optimizationFlags(flag.CommandLine, &optimizationLevel)
+	flag.BoolVar(&printPasses, "print-passes", false, "print the tree before and after each optimization pass")
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run":
+			os.Exit(runCommand(os.Args[2:]))
+		case "build":
+			os.Exit(buildCommand(os.Args[2:]))
+		case "inspect":
+			os.Exit(inspectCommand(os.Args[2:]))
+		case "dfa":
+			os.Exit(dfaCommand(os.Args[2:]))
+		case "ll1":
+			os.Exit(ll1Command(os.Args[2:]))
+		}
+	}
 	flag.Parse()
+	if backend != "tree" && backend != "vm" {
+		fmt.Fprintln(os.Stderr, "unknown backend: "+backend)
+		os.Exit(2)
+	}
 	fmt.Println("Simple script language!")
 	fmt.Println("input exit(); to quit")
 	f := bufio.NewReader(os.Stdin)
 	prompt := ">>"
 	scriptText := ""
-	calculator := SimpleParser{}
+	history := ""
+	calculator := SimpleParser{Origin: NewPosition()}
+	resolver := NewResolver()
+	checker := NewTypeChecker()
 	script := NewSimpleScript(verbose)
+	script.MaxCallDepth = maxCallDepth
+	bytecode := NewBytecodeCompiler()
+	vm := NewVM(verbose)
+	vm.MaxCallDepth = maxCallDepth
+	optimizer := NewOptimizer(optimizationLevel)
+	if printPasses {
+		optimizer.Trace = os.Stdout
+	}
 	for {
 		fmt.Print(prompt)
-		input, _ := f.ReadString('\n')
+		input, err := f.ReadString('\n')
+		if err == io.EOF && input == "" {
+			fmt.Println()
+			break
+		}
 		if len(input) == 1 {
 			continue
 		}
@@ -37,14 +81,80 @@ func main() {
 			break
 		}
 		scriptText += input + "\n"
-		if strings.HasSuffix(scriptText, ";\n") {
+		if isComplete(scriptText) {
 			fmt.Println("your input is: " + scriptText)
-			root := calculator.Parse(scriptText)
+			root, err := calculator.Parse(scriptText)
+			history += scriptText
+			calculator.Origin = calculator.Origin.endOf(scriptText)
+			scriptText = ""
 			if verbose {
 				DumpAST(*root, "")
 			}
-			script.Evaluate(*root, "")
-			scriptText = ""
+			if err != nil {
+				fmt.Fprint(os.Stderr, RenderError(err, history))
+				continue
+			}
+			diagnostics := resolver.Resolve(*root)
+			if !diagnostics.HasErrors() {
+				diagnostics = append(diagnostics, checker.Check(*root)...)
+			}
+			fmt.Fprint(os.Stderr, diagnostics.Render(history))
+			if diagnostics.HasErrors() {
+				resolver.Rollback()
+				continue
+			}
+			optimizer.OptimizeAST(*root)
+			if backend == "vm" {
+				err = runBytecode(bytecode, vm, *root)
+			} else {
+				_, err = script.Evaluate(*root, "")
+			}
+			if err != nil {
+				fmt.Fprint(os.Stderr, RenderError(err, history))
+			}
 		}
 	}
 }
+
+// runBytecode compiles root and runs it on vm.
+func runBytecode(bytecode *BytecodeCompiler, vm *VM, root ASTNoder) error {
+	functions := len(bytecode.Functions)
+	chunk, err := bytecode.Compile(root)
+	if err != nil {
+		return err
+	}
+	if disassemble {
+		for _, function := range bytecode.Functions[functions:] {
+			Disassemble(os.Stdout, function, bytecode.Functions)
+		}
+		Disassemble(os.Stdout, chunk, bytecode.Functions)
+	}
+	_, err = vm.Run(chunk, bytecode.Functions)
+	return err
+}
+
+// isComplete reports whether the input collected so far ends a statement:
+// its last token is ';' or '}', every parenthesis and brace is closed and
+// no comment is left open.
+func isComplete(scriptText string) bool {
+	tokens, errors := Lex(scriptText)
+	for _, err := range errors {
+		if err.Code == DiagnosticCode_UnterminatedComment {
+			return false
+		}
+	}
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case TokenType_Left_Paren, TokenType_Left_Brace:
+			depth++
+		case TokenType_Right_Paren, TokenType_Right_Brace:
+			depth--
+		}
+	}
+	if depth > 0 || len(tokens) < 2 {
+		return false
+	}
+	last := tokens[len(tokens)-2].Type
+	return last == TokenType_SemiColon || last == TokenType_Right_Brace
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// irPipelines are the passes run on the IR at each optimization level,
+// repeated until none of them changes anything.
+var irPipelines = [...][]irPass{
+	{},
+	{irFoldPass, irSimplifyPass, irDCEPass},
+	{irFoldPass, irSimplifyPass, irCSEPass, irDCEPass, irMergePass},
+}
+
+// maxRounds bounds the repetitions of a pipeline.
+const maxRounds = 10
+
+// Optimizer runs the passes of an optimization level. Level 0 runs none.
+// Level 1 folds constant expressions of the AST and, on the IR, propagates
+// constants and copies, applies algebraic identities and removes dead code.
+// Level 2 also eliminates common subexpressions and merges blocks.
+type Optimizer struct {
+	Level int
+	// Trace, if not nil, receives the tree or the IR before the passes and
+	// after each pass that changed it.
+	Trace io.Writer
+}
+
+func NewOptimizer(level int) *Optimizer {
+	return &Optimizer{Level: level}
+}
+
+// OptimizeAST folds the constant expressions of root in place.
+func (o *Optimizer) OptimizeAST(root ASTNoder) {
+	if o.Level < 1 {
+		return
+	}
+	if o.Trace != nil {
+		fmt.Fprintln(o.Trace, "*** tree before optimization ***")
+		FdumpAST(o.Trace, root, "")
+	}
+	changed := foldAST(root)
+	if o.Trace != nil {
+		o.traceHeader("tree", "fold", changed)
+		if changed {
+			FdumpAST(o.Trace, root, "")
+		}
+	}
+}
+
+// OptimizeIR runs the passes of the level on every function of program.
+func (o *Optimizer) OptimizeIR(program *IRProgram) {
+	level := o.Level
+	if level >= len(irPipelines) {
+		level = len(irPipelines) - 1
+	}
+	pipeline := irPipelines[level]
+	if len(pipeline) == 0 {
+		return
+	}
+	functions := append(append([]*IRFunction(nil), program.Functions...), program.Main)
+	if o.Trace != nil {
+		fmt.Fprintln(o.Trace, "*** IR before optimization ***")
+		program.Dump(o.Trace)
+	}
+	for round := 0; round < maxRounds; round++ {
+		changed := false
+		for _, pass := range pipeline {
+			passChanged := false
+			for _, f := range functions {
+				if pass.run(f) {
+					passChanged = true
+				}
+			}
+			if o.Trace != nil {
+				o.traceHeader("IR", pass.name, passChanged)
+				if passChanged {
+					program.Dump(o.Trace)
+				}
+			}
+			changed = changed || passChanged
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+func (o *Optimizer) traceHeader(what string, pass string, changed bool) {
+	if changed {
+		fmt.Fprintf(o.Trace, "*** %s after %s ***\n", what, pass)
+	} else {
+		fmt.Fprintf(o.Trace, "*** %s after %s: no change ***\n", what, pass)
+	}
+}
+
+// optimizationFlag is one of the boolean flags -O0, -O1 and -O2 setting the
+// optimization level.
+type optimizationFlag struct {
+	level *int
+	value int
+}
+
+func (f optimizationFlag) String() string {
+	if f.level != nil && *f.level == f.value {
+		return "true"
+	}
+	return "false"
+}
+
+func (f optimizationFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err == nil && on {
+		*f.level = f.value
+	}
+	return err
+}
+
+func (f optimizationFlag) IsBoolFlag() bool {
+	return true
+}
+
+// optimizationFlags defines -O0, -O1 and -O2 setting level.
+func optimizationFlags(flags *flag.FlagSet, level *int) {
+	usages := []string{
+		"do not optimize",
+		"fold constants, propagate constants and copies, simplify and remove dead code",
+		"also eliminate common subexpressions and merge blocks",
+	}
+	for i, usage := range usages {
+		flags.Var(optimizationFlag{level, i}, "O"+strconv.Itoa(i), usage)
+	}
+}
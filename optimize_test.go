@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptimizerLevels(t *testing.T) {
+	script := "int f(int x, int a) { int b = x + a; int c = (x + a) * 1; return b * c; }"
+	tests := []struct {
+		level int
+		want  string
+	}{
+		{0, `func f(%x int, %a int) int {
+b0:
+	%0 int = add %x, %a
+	%b int = copy %0
+	%1 int = add %x, %a
+	%2 int = mul %1, 1
+	%c int = copy %2
+	%3 int = mul %b, %c
+	return %3
+}
+`},
+		{1, `func f(%x int, %a int) int {
+b0:
+	%0 int = add %x, %a
+	%1 int = add %x, %a
+	%3 int = mul %0, %1
+	return %3
+}
+`},
+		{2, `func f(%x int, %a int) int {
+b0:
+	%0 int = add %x, %a
+	%3 int = mul %0, %0
+	return %3
+}
+`},
+	}
+	for _, test := range tests {
+		program := lowerScript(t, script)
+		NewOptimizer(test.level).OptimizeIR(program)
+		if got := dumpFunction(program, "f"); got != test.want {
+			t.Errorf("-O%d: got\n%s\nwant\n%s", test.level, got, test.want)
+		}
+	}
+}
+
+func TestOptimizerKeepsRuntimeErrors(t *testing.T) {
+	for _, script := range []string{
+		"print(5 / 0);",
+		"int z() { return 0; } print(0 * (1 / z()));",
+		"int z() { return 0; } int d = 1 % z(); print(1);",
+	} {
+		file := writeScript(t, script)
+		for _, backend := range []string{"tree", "vm", "ir"} {
+			got := runProgram(t, "-backend="+backend, "-O2", file)
+			if got.status != 1 || got.stdout != "" || !strings.Contains(got.stderr, string(DiagnosticCode_DivisionByZero)) {
+				t.Errorf("%s -backend=%s: %+v, want a division by zero", script, backend, got)
+			}
+		}
+	}
+}
+
+func TestPrintPasses(t *testing.T) {
+	file := writeScript(t, "int a = 2 * 3; print(a + 0);")
+	got := runProgram(t, "-backend=ir", "-O2", "-print-passes", file)
+	if got.status != 0 || got.stdout != "6\n" {
+		t.Fatalf("%+v", got)
+	}
+	var headers []string
+	for _, line := range strings.Split(got.stderr, "\n") {
+		if strings.HasPrefix(line, "***") {
+			headers = append(headers, line)
+		}
+	}
+	want := []string{
+		"*** tree before optimization ***",
+		"*** tree after fold ***",
+		"*** IR before optimization ***",
+		"*** IR after fold: no change ***",
+		"*** IR after simplify ***",
+		"*** IR after cse: no change ***",
+		"*** IR after dce: no change ***",
+		"*** IR after merge: no change ***",
+		"*** IR after fold: no change ***",
+		"*** IR after simplify: no change ***",
+		"*** IR after cse: no change ***",
+		"*** IR after dce: no change ***",
+		"*** IR after merge: no change ***",
+	}
+	if strings.Join(headers, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(headers, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(got.stderr, "IntLiteral 6") {
+		t.Errorf("the folded tree is not printed:\n%s", got.stderr)
+	}
+}
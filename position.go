@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Position is a location in the script. Offset is a 0-based byte offset,
+// Line and Column are 1-based, Column counts runes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func NewPosition() Position {
+	return Position{Line: 1, Column: 1}
+}
+
+// advance moves p past ch, encoded in size bytes of the script.
+func (p Position) advance(ch rune, size int) Position {
+	p.Offset += size
+	if ch == '\n' {
+		p.Line++
+		p.Column = 1
+	} else {
+		p.Column++
+	}
+	return p
+}
+
+// endOf is the position just after text when text starts at p. An invalid
+// UTF-8 byte counts as a column.
+func (p Position) endOf(text string) Position {
+	for len(text) > 0 {
+		ch, size := utf8.DecodeRuneInString(text)
+		p = p.advance(ch, size)
+		text = text[size:]
+	}
+	return p
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span covers the source from Start up to, but not including, End.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) String() string {
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
+
+func joinSpan(from, to Span) Span {
+	return Span{Start: from.Start, End: to.End}
+}
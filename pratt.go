@@ -0,0 +1,140 @@
+package main
+
+import "fmt"
+
+// Fixity is where an operator stands relative to its operands.
+type Fixity int
+
+const (
+	Fixity_Prefix Fixity = iota
+	Fixity_Infix
+	Fixity_Postfix
+)
+
+type Associativity int
+
+const (
+	Associativity_Left Associativity = iota
+	Associativity_Right
+)
+
+// Operator is an entry of an OperatorTable. Operators of higher Precedence
+// bind tighter. An infix operator with a Middle token, like ? with : in
+// a ? b : c, takes a full expression between the two and a third operand;
+// the node it makes has all operands as children and the operator token as
+// text. Name describes the expressions of the operator in syntax errors,
+// which quote the token when it is empty.
+type Operator struct {
+	Token         TokenType
+	Fixity        Fixity
+	Precedence    int
+	Associativity Associativity
+	NodeType      ASTNodeType
+	Middle        TokenType
+	Name          string
+}
+
+// OperatorTable drives the parsing of expressions. A token may be both a
+// prefix operator and an infix or postfix one, but not the last two.
+type OperatorTable []Operator
+
+// simpleOperators are the operators of the script language.
+var simpleOperators = OperatorTable{
+	{TokenType_Or, Fixity_Infix, 1, Associativity_Left, ASTNodeType_LogicalOr, "", "logical or"},
+	{TokenType_And, Fixity_Infix, 2, Associativity_Left, ASTNodeType_LogicalAnd, "", "logical and"},
+	{TokenType_EQ, Fixity_Infix, 3, Associativity_Left, ASTNodeType_Equality, "", "equality"},
+	{TokenType_NE, Fixity_Infix, 3, Associativity_Left, ASTNodeType_Equality, "", "equality"},
+	{TokenType_LT, Fixity_Infix, 4, Associativity_Left, ASTNodeType_Relational, "", "relational"},
+	{TokenType_LE, Fixity_Infix, 4, Associativity_Left, ASTNodeType_Relational, "", "relational"},
+	{TokenType_GT, Fixity_Infix, 4, Associativity_Left, ASTNodeType_Relational, "", "relational"},
+	{TokenType_GE, Fixity_Infix, 4, Associativity_Left, ASTNodeType_Relational, "", "relational"},
+	{TokenType_Plus, Fixity_Infix, 5, Associativity_Left, ASTNodeType_AddtiveExp, "", "additive"},
+	{TokenType_Minus, Fixity_Infix, 5, Associativity_Left, ASTNodeType_AddtiveExp, "", "additive"},
+	{TokenType_Star, Fixity_Infix, 6, Associativity_Left, ASTNodeType_Multiplicative, "", "multiplicative"},
+	{TokenType_Slash, Fixity_Infix, 6, Associativity_Left, ASTNodeType_Multiplicative, "", "multiplicative"},
+	{TokenType_Percent, Fixity_Infix, 6, Associativity_Left, ASTNodeType_Multiplicative, "", "multiplicative"},
+	{TokenType_Not, Fixity_Prefix, 7, Associativity_Right, ASTNodeType_Unary, "", "unary"},
+	{TokenType_Minus, Fixity_Prefix, 7, Associativity_Right, ASTNodeType_Unary, "", "unary"},
+	{TokenType_Plus, Fixity_Prefix, 7, Associativity_Right, ASTNodeType_Unary, "", "unary"},
+}
+
+// describe returns the description of the expressions of o in syntax
+// errors, token being the operator.
+func (o *Operator) describe(token *Token) string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return fmt.Sprintf("%q", token.Text)
+}
+
+// find returns the prefix operator of token if prefix is true, else its
+// infix or postfix one, or nil.
+func (t OperatorTable) find(token *Token, prefix bool) *Operator {
+	if token == nil {
+		return nil
+	}
+	for i := range t {
+		if t[i].Token == token.Type && (t[i].Fixity == Fixity_Prefix) == prefix {
+			return &t[i]
+		}
+	}
+	return nil
+}
+
+// operation parses by precedence climbing an expression whose operators,
+// outside of parentheses and of the middle of ternary ones, have at least
+// the given precedence.
+func (s *SimpleParser) operation(reader TokenReader, precedence int) *ASTNoder {
+	operators := s.Operators
+	if operators == nil {
+		operators = simpleOperators
+	}
+	var left ASTNoder
+	if operator := operators.find(reader.Peek(), true); operator != nil {
+		token := reader.Read()
+		child := s.operation(reader, operator.Precedence)
+		if child == nil {
+			s.fail(reader, DiagnosticCode_ExpectedExpression, "invalid "+operator.describe(token)+" expression, expecting an operand.")
+		}
+		left = NewASTNoder(operator.NodeType, token.Text, joinSpan(token.Span, (*child).GetSpan()))
+		left.AddChild(*child)
+	} else if child := s.primary(reader); child != nil {
+		left = *child
+	} else {
+		return nil
+	}
+	for {
+		operator := operators.find(reader.Peek(), false)
+		if operator == nil || operator.Precedence < precedence {
+			return &left
+		}
+		token := reader.Read()
+		if operator.Fixity == Fixity_Postfix {
+			node := NewASTNoder(operator.NodeType, token.Text, joinSpan(left.GetSpan(), token.Span))
+			node.AddChild(left)
+			left = node
+			continue
+		}
+		node := NewASTNoder(operator.NodeType, token.Text, Span{})
+		node.AddChild(left)
+		if operator.Middle != "" {
+			middle := s.expression(reader)
+			if middle == nil {
+				s.fail(reader, DiagnosticCode_ExpectedExpression, "invalid "+operator.describe(token)+" expression, expecting the middle part.")
+			}
+			node.AddChild(*middle)
+			s.expect(reader, operator.Middle, DiagnosticCode_ExpectedExpression, "invalid "+operator.describe(token)+" expression, expecting "+string(operator.Middle))
+		}
+		next := operator.Precedence + 1
+		if operator.Associativity == Associativity_Right {
+			next = operator.Precedence
+		}
+		right := s.operation(reader, next)
+		if right == nil {
+			s.fail(reader, DiagnosticCode_ExpectedExpression, "invalid "+operator.describe(token)+" expression, expecting the right part.")
+		}
+		node.AddChild(*right)
+		node.SetSpan(joinSpan(left.GetSpan(), (*right).GetSpan()))
+		left = node
+	}
+}
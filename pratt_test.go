@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// operatorText is the text of the tokens of the operators.
+var operatorText = map[TokenType]string{
+	TokenType_Or: "||", TokenType_And: "&&", TokenType_EQ: "==", TokenType_NE: "!=",
+	TokenType_LT: "<", TokenType_LE: "<=", TokenType_GT: ">", TokenType_GE: ">=",
+	TokenType_Plus: "+", TokenType_Minus: "-", TokenType_Star: "*", TokenType_Slash: "/",
+	TokenType_Percent: "%", TokenType_Not: "!",
+}
+
+// parseExpression parses the expression statement src with operators and
+// returns its tree, or the syntax errors.
+func parseExpression(operators OperatorTable, src string) (string, error) {
+	parser := SimpleParser{Origin: NewPosition(), Operators: operators}
+	root, err := parser.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return sexpr((*root).GetChildren()[0]), nil
+}
+
+func TestOperatorPairs(t *testing.T) {
+	for _, first := range simpleOperators {
+		for _, second := range simpleOperators {
+			a, b := operatorText[first.Token], operatorText[second.Token]
+			var src, want string
+			switch {
+			case first.Fixity == Fixity_Prefix && second.Fixity == Fixity_Prefix:
+				src, want = fmt.Sprintf("%s %s x;", a, b), fmt.Sprintf("(%s (%s x))", a, b)
+			case first.Fixity == Fixity_Prefix:
+				src, want = fmt.Sprintf("%s x %s y;", a, b), fmt.Sprintf("(%s (%s x) y)", b, a)
+			case second.Fixity == Fixity_Prefix:
+				src, want = fmt.Sprintf("x %s %s y;", a, b), fmt.Sprintf("(%s x (%s y))", a, b)
+			case first.Precedence >= second.Precedence:
+				src, want = fmt.Sprintf("x %s y %s z;", a, b), fmt.Sprintf("(%s (%s x y) z)", b, a)
+			default:
+				src, want = fmt.Sprintf("x %s y %s z;", a, b), fmt.Sprintf("(%s x (%s y z))", a, b)
+			}
+			got, err := parseExpression(nil, src)
+			if err != nil || got != want {
+				t.Errorf("%s: got %s %v, want %s", src, got, err, want)
+			}
+		}
+	}
+}
+
+// testOperators have a ternary operator == , with the middle part after
+// ==, a right associative *, a postfix ! and a prefix - binding less than
+// it.
+var testOperators = OperatorTable{
+	{TokenType_EQ, Fixity_Infix, 1, Associativity_Right, ASTNodeType("Conditional"), TokenType_Comma, "conditional"},
+	{TokenType_Plus, Fixity_Infix, 2, Associativity_Left, ASTNodeType_AddtiveExp, "", ""},
+	{TokenType_Star, Fixity_Infix, 3, Associativity_Right, ASTNodeType("Power"), "", "power"},
+	{TokenType_Minus, Fixity_Prefix, 4, Associativity_Right, ASTNodeType_Unary, "", "unary"},
+	{TokenType_Not, Fixity_Postfix, 5, Associativity_Left, ASTNodeType("Factorial"), "", "factorial"},
+}
+
+func TestOperatorTable(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"a == b , c;", "(== a b c)"},
+		{"a == b , c == d , e;", "(== a b (== c d e))"},
+		{"a == b == c , d , e;", "(== a (== b c d) e)"},
+		{"a + b == c + d , e + f;", "(== (+ a b) (+ c d) (+ e f))"},
+		{"a * b * c;", "(* a (* b c))"},
+		{"a + b * c + d;", "(+ (+ a (* b c)) d)"},
+		{"a!;", "(! a)"},
+		{"a!!;", "(! (! a))"},
+		{"-a!;", "(- (! a))"},
+		{"a! * b!;", "(* (! a) (! b))"},
+		{"- a * b;", "(* (- a) b)"},
+		{"(a == b , c)!;", "(! (== a b c))"},
+	}
+	for _, test := range tests {
+		got, err := parseExpression(testOperators, test.src)
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %s %v, want %s", test.src, got, err, test.want)
+		}
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+	tests := []struct {
+		operators OperatorTable
+		src       string
+		want      string
+	}{
+		{nil, "a + ;", "invalid additive expression, expecting the right part."},
+		{nil, "a * ;", "invalid multiplicative expression, expecting the right part."},
+		{nil, "a || ;", "invalid logical or expression, expecting the right part."},
+		{nil, "- ;", "invalid unary expression, expecting an operand."},
+		{testOperators, "a + ;", `invalid "+" expression, expecting the right part.`},
+		{testOperators, "a == ;", "invalid conditional expression, expecting the middle part."},
+		{testOperators, "a == b ;", "invalid conditional expression, expecting ,"},
+		{testOperators, "a == b , ;", "invalid conditional expression, expecting the right part."},
+	}
+	for _, test := range tests {
+		_, err := parseExpression(test.operators, test.src)
+		diagnostics, _ := err.(Diagnostics)
+		if len(diagnostics) == 0 || diagnostics[0].Message != test.want {
+			t.Errorf("%s: got %v, want %s", test.src, err, test.want)
+		}
+	}
+}
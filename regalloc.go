@@ -0,0 +1,212 @@
+package main
+
+import "sort"
+
+// liveInterval is the range of positions from the definition of a register
+// to its last use. Instructions are numbered in the order of the blocks,
+// two apart; the phis of a block share its first position and the
+// parameters are defined at 0. The interval covers the holes where the
+// register is not live, which makes it conservative but a single range.
+type liveInterval struct {
+	reg         *IRReg
+	start, end  int
+	crossesCall bool
+}
+
+// regAllocation maps the registers of a function to machine registers, or
+// to stack slots for the ones that were spilled.
+type regAllocation struct {
+	registers map[*IRReg]string
+	slots     map[*IRReg]int
+	slotCount int
+	used      map[string]bool
+}
+
+// splitCriticalEdges gives every edge from a branch to a block with phis a
+// block of its own, where the copies of the phis can be placed. The blocks
+// are then put in reverse postorder.
+func splitCriticalEdges(f *IRFunction) {
+	for _, b := range append([]*IRBlock(nil), f.Blocks...) {
+		t := b.Terminator()
+		if t == nil || t.Op != IROp_Branch {
+			continue
+		}
+		for i, s := range t.Blocks {
+			if !hasPhis(s) {
+				continue
+			}
+			edge := f.newBlock()
+			edge.Instrs = []*IRInstr{{Op: IROp_Jump, Blocks: []*IRBlock{s}, Span: t.Span}}
+			t.Blocks[i] = edge
+			for _, phi := range s.Instrs {
+				if phi.Op != IROp_Phi {
+					break
+				}
+				for j, pred := range phi.Blocks {
+					if pred == b {
+						phi.Blocks[j] = edge
+					}
+				}
+			}
+		}
+	}
+	removeUnreachable(f)
+}
+
+// isCall tells whether instr calls a function or the runtime, which may
+// change any register that is not callee-saved.
+func isCall(instr *IRInstr) bool {
+	return instr.Op == IROp_Call || instr.Op == IROp_Print
+}
+
+// buildIntervals returns the live intervals of the registers of f sorted by
+// start. A register live on entry to a block is live from its first
+// position and one live on exit until its terminator; the arguments of a
+// phi are live on exit from the predecessors.
+func buildIntervals(f *IRFunction) []*liveInterval {
+	live := computeLiveness(f)
+	intervals := make(map[*IRReg]*liveInterval)
+	var order []*liveInterval
+	extend := func(r *IRReg, position int) {
+		i := intervals[r]
+		if i == nil {
+			i = &liveInterval{reg: r, start: position, end: position}
+			intervals[r] = i
+			order = append(order, i)
+		}
+		if position < i.start {
+			i.start = position
+		}
+		if position > i.end {
+			i.end = position
+		}
+	}
+	for _, param := range f.Params {
+		extend(param, 0)
+	}
+	var calls []int
+	position := 2
+	for _, b := range f.Blocks {
+		from := position
+		if hasPhis(b) {
+			position += 2
+		}
+		for _, instr := range b.Instrs {
+			at := position
+			if instr.Op == IROp_Phi {
+				at = from
+			} else {
+				position += 2
+				for _, arg := range instr.Args {
+					if r, ok := arg.(*IRReg); ok {
+						extend(r, at)
+					}
+				}
+			}
+			if instr.Dest != nil {
+				extend(instr.Dest, at)
+			}
+			if isCall(instr) {
+				calls = append(calls, at)
+			}
+		}
+		to := position - 2
+		for r := range live.in[b] {
+			extend(r, from)
+		}
+		for r := range live.out[b] {
+			extend(r, to)
+		}
+	}
+	for _, i := range order {
+		for _, call := range calls {
+			if i.start < call && call < i.end {
+				i.crossesCall = true
+				break
+			}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return order[a].start < order[b].start
+	})
+	return order
+}
+
+// allocateRegisters assigns machine registers to the registers of f with
+// the linear scan of Poletto and Sarkar. Intervals are visited by start and
+// take the first free register in the order of registers; one that lives
+// across a call only takes a calleeSaved one. When none is left, the
+// interval that ends last among the current one and the ones holding a
+// suitable register is spilled to a stack slot for its whole life. Slots
+// are reused once their interval ended.
+func allocateRegisters(f *IRFunction, registers []string, calleeSaved map[string]bool) *regAllocation {
+	a := &regAllocation{
+		registers: make(map[*IRReg]string),
+		slots:     make(map[*IRReg]int),
+		used:      make(map[string]bool),
+	}
+	var slotEnds []int
+	spill := func(i *liveInterval) {
+		slot := len(slotEnds)
+		for k, end := range slotEnds {
+			if end < i.start {
+				slot = k
+				break
+			}
+		}
+		if slot == len(slotEnds) {
+			slotEnds = append(slotEnds, 0)
+		}
+		slotEnds[slot] = i.end
+		a.slots[i.reg] = slot
+	}
+	free := make(map[string]bool, len(registers))
+	for _, r := range registers {
+		free[r] = true
+	}
+	var active []*liveInterval
+	for _, current := range buildIntervals(f) {
+		kept := active[:0]
+		for _, i := range active {
+			if i.end < current.start {
+				free[a.registers[i.reg]] = true
+			} else {
+				kept = append(kept, i)
+			}
+		}
+		active = kept
+		suitable := func(r string) bool {
+			return !current.crossesCall || calleeSaved[r]
+		}
+		register := ""
+		for _, r := range registers {
+			if free[r] && suitable(r) {
+				register = r
+				break
+			}
+		}
+		if register == "" {
+			victim := -1
+			for k, i := range active {
+				if suitable(a.registers[i.reg]) && (victim < 0 || i.end > active[victim].end) {
+					victim = k
+				}
+			}
+			if victim < 0 || active[victim].end <= current.end {
+				spill(current)
+				continue
+			}
+			spilled := active[victim]
+			register = a.registers[spilled.reg]
+			delete(a.registers, spilled.reg)
+			spill(spilled)
+			active = append(active[:victim], active[victim+1:]...)
+		}
+		a.registers[current.reg] = register
+		a.used[register] = true
+		free[register] = false
+		active = append(active, current)
+	}
+	a.slotCount = len(slotEnds)
+	return a
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestRegisterPressure checks the allocation of testdata/registers.ss, whose
+// function pressure has 40 live variables and 8-argument calls: more than
+// there are registers. TestNativeMatchesInterpreter runs the program.
+func TestRegisterPressure(t *testing.T) {
+	for _, level := range []int{1, 2} {
+		var root ASTNoder
+		capture(t, func() {
+			root, _ = loadProgram(filepath.Join("testdata", "registers.ss"))
+		})
+		if root == nil {
+			t.Fatal("registers.ss does not compile")
+		}
+		optimizer := NewOptimizer(level)
+		optimizer.OptimizeAST(root)
+		program, err := buildIR(root, optimizer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range append(program.Functions, program.Main) {
+			splitCriticalEdges(f)
+			a := allocateRegisters(f, x86Registers, x86CalleeSaved)
+			intervals := buildIntervals(f)
+			if f.Name == "pressure" && a.slotCount == 0 {
+				t.Errorf("-O%d: nothing spilled in pressure", level)
+			}
+			for k, i := range intervals {
+				register, inRegister := a.registers[i.reg]
+				slot, inSlot := a.slots[i.reg]
+				if inRegister == inSlot {
+					t.Errorf("-O%d: %s: %s is in a register: %v, in a slot: %v", level, f.Name, i.reg, inRegister, inSlot)
+				}
+				if inRegister && i.crossesCall && !x86CalleeSaved[register] {
+					t.Errorf("-O%d: %s: %s lives across a call in %s", level, f.Name, i.reg, register)
+				}
+				for _, j := range intervals[:k] {
+					overlap := i.start <= j.end && j.start <= i.end
+					if overlap && inRegister && a.registers[j.reg] == register {
+						t.Errorf("-O%d: %s: %s and %s share %s", level, f.Name, i.reg, j.reg, register)
+					}
+					if other, ok := a.slots[j.reg]; overlap && inSlot && ok && other == slot {
+						t.Errorf("-O%d: %s: %s and %s share slot %d", level, f.Name, i.reg, j.reg, slot)
+					}
+				}
+			}
+		}
+	}
+}
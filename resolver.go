@@ -0,0 +1,215 @@
+package main
+
+import "fmt"
+
+type SymbolKind string
+
+const (
+	SymbolKind_Variable  = SymbolKind("variable")
+	SymbolKind_Parameter = SymbolKind("parameter")
+	SymbolKind_Function  = SymbolKind("function")
+)
+
+// Symbol is one declaration. Type is the type of a variable or parameter and
+// the return type of a function. Variables and parameters get a storage
+// slot: Index is a global slot for declarations outside any function and a
+// slot in the frame of Function otherwise. For functions Locals is the
+// number of frame slots, parameters first.
+type Symbol struct {
+	Name     string
+	Kind     SymbolKind
+	Type     DataType
+	Decl     ASTNoder
+	Function *Symbol
+	Index    int
+	Params   []*Symbol
+	Locals   int
+}
+
+func (s *Symbol) IsGlobal() bool {
+	return s.Function == nil
+}
+
+// Scope maps names to the symbols declared directly in one lexical scope.
+type Scope struct {
+	parent   *Scope
+	symbols  map[string]*Symbol
+	function *Symbol
+}
+
+func NewScope(parent *Scope, function *Symbol) *Scope {
+	return &Scope{parent: parent, symbols: make(map[string]*Symbol), function: function}
+}
+
+// Lookup finds name in this scope or the nearest enclosing one.
+func (sc *Scope) Lookup(name string) *Symbol {
+	for scope := sc; scope != nil; scope = scope.parent {
+		if symbol, ok := scope.symbols[name]; ok {
+			return symbol
+		}
+	}
+	return nil
+}
+
+// Resolver binds every name in an AST to its declaration before the program
+// runs. The global scope is kept between calls to Resolve so that a script
+// can be resolved piece by piece, as the REPL does.
+type Resolver struct {
+	globals     *Scope
+	scope       *Scope
+	function    *Symbol
+	globalCount int
+	defined     []string
+	diagnostics Diagnostics
+}
+
+func NewResolver() *Resolver {
+	globals := NewScope(nil, nil)
+	return &Resolver{globals: globals, scope: globals}
+}
+
+// Resolve annotates root with symbols through SetSymbol and returns the
+// errors and warnings found.
+func (r *Resolver) Resolve(root ASTNoder) Diagnostics {
+	r.scope, r.function = r.globals, nil
+	r.defined, r.diagnostics = nil, nil
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			r.declareFunction(child)
+		}
+	}
+	r.resolveChildren(root)
+	return r.diagnostics
+}
+
+// Rollback drops the global declarations made by the last call to Resolve,
+// for when the program is rejected and will not run.
+func (r *Resolver) Rollback() {
+	for _, name := range r.defined {
+		delete(r.globals.symbols, name)
+	}
+	r.defined = nil
+}
+
+func (r *Resolver) report(severity Severity, code DiagnosticCode, message string, span Span) *Diagnostic {
+	d := NewDiagnostic(severity, code, message, span)
+	r.diagnostics = append(r.diagnostics, d)
+	return d
+}
+
+func (r *Resolver) pushScope() {
+	r.scope = NewScope(r.scope, r.function)
+}
+
+func (r *Resolver) popScope() {
+	r.scope = r.scope.parent
+}
+
+// define declares a new symbol for node in the current scope.
+func (r *Resolver) define(node ASTNoder, kind SymbolKind) *Symbol {
+	name := node.GetText()
+	previous, redeclared := r.scope.symbols[name]
+	if redeclared {
+		r.report(Severity_Error, DiagnosticCode_Redeclaration, "redeclaration of "+name, node.GetSpan()).
+			AddNote("previously declared here", previous.Decl.GetSpan())
+	} else if outer := r.scope.Lookup(name); outer != nil {
+		r.report(Severity_Warning, DiagnosticCode_Shadowing, fmt.Sprintf("%s %s shadows an outer %s", kind, name, outer.Kind), node.GetSpan()).
+			AddNote("shadowed declaration is here", outer.Decl.GetSpan())
+	}
+	symbol := &Symbol{Name: name, Kind: kind, Type: declaredType(node), Decl: node, Function: r.function}
+	if kind != SymbolKind_Function {
+		if r.function != nil {
+			symbol.Index = r.function.Locals
+			r.function.Locals++
+		} else {
+			symbol.Index = r.globalCount
+			r.globalCount++
+		}
+	}
+	if !redeclared {
+		r.scope.symbols[name] = symbol
+		if r.scope == r.globals {
+			r.defined = append(r.defined, name)
+		}
+	}
+	node.SetSymbol(symbol)
+	return symbol
+}
+
+func (r *Resolver) declareFunction(node ASTNoder) {
+	symbol := r.define(node, SymbolKind_Function)
+	children := node.GetChildren()
+	symbol.Params = make([]*Symbol, 0, len(children)-1)
+	for _, param := range children[:len(children)-1] {
+		symbol.Params = append(symbol.Params, &Symbol{Name: param.GetText(), Kind: SymbolKind_Parameter, Type: param.GetDataType(), Decl: param})
+	}
+}
+
+func (r *Resolver) resolveChildren(node ASTNoder) {
+	for _, child := range node.GetChildren() {
+		r.resolve(child)
+	}
+}
+
+func (r *Resolver) resolve(node ASTNoder) {
+	switch node.GetType() {
+	case ASTNodeType_FunctionDeclaration:
+		r.resolveFunction(node)
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		r.resolveChildren(node)
+		r.define(node, SymbolKind_Variable)
+	case ASTNodeType_Identifier:
+		symbol := r.lookup(node)
+		if symbol != nil && symbol.Kind == SymbolKind_Function {
+			r.report(Severity_Error, DiagnosticCode_NotAVariable, node.GetText()+" is a function, not a variable", node.GetSpan())
+		}
+	case ASTNodeType_Assignment:
+		symbol := r.lookup(node)
+		if symbol != nil && symbol.Kind == SymbolKind_Function {
+			r.report(Severity_Error, DiagnosticCode_NotAVariable, "cannot assign to function "+node.GetText(), node.GetSpan())
+		}
+		r.resolveChildren(node)
+	case ASTNodeType_FunctionCall:
+		symbol := r.lookup(node)
+		if symbol != nil && symbol.Kind != SymbolKind_Function {
+			r.report(Severity_Error, DiagnosticCode_NotAFunction, node.GetText()+" is not a function", node.GetSpan()).
+				AddNote(node.GetText()+" is declared here", symbol.Decl.GetSpan())
+		} else if symbol != nil && len(symbol.Params) != len(node.GetChildren()) {
+			r.report(Severity_Error, DiagnosticCode_ArgumentCount,
+				fmt.Sprintf("function %s expects %d arguments but got %d", node.GetText(), len(symbol.Params), len(node.GetChildren())),
+				node.GetSpan()).AddNote("function declared here", symbol.Decl.GetSpan())
+		}
+		r.resolveChildren(node)
+	case ASTNodeType_Block, ASTNodeType_For:
+		r.pushScope()
+		r.resolveChildren(node)
+		r.popScope()
+	default:
+		r.resolveChildren(node)
+	}
+}
+
+// resolveFunction puts the parameters and the top level of the body into
+// one scope, so a body declaration cannot silently hide a parameter.
+func (r *Resolver) resolveFunction(node ASTNoder) {
+	symbol := node.GetSymbol()
+	r.function = symbol
+	r.pushScope()
+	children := node.GetChildren()
+	for i, param := range children[:len(children)-1] {
+		symbol.Params[i] = r.define(param, SymbolKind_Parameter)
+	}
+	r.resolveChildren(children[len(children)-1])
+	r.popScope()
+	r.function = nil
+}
+
+func (r *Resolver) lookup(node ASTNoder) *Symbol {
+	symbol := r.scope.Lookup(node.GetText())
+	if symbol == nil {
+		r.report(Severity_Error, DiagnosticCode_Undeclared, "undeclared identifier "+node.GetText(), node.GetSpan())
+		return nil
+	}
+	node.SetSymbol(symbol)
+	return symbol
+}
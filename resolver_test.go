@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// resolve parses script and returns the codes of the diagnostics of r.
+func resolve(t *testing.T, r *Resolver, script string) []DiagnosticCode {
+	t.Helper()
+	parser := SimpleParser{Origin: NewPosition()}
+	root, err := parser.Parse(script)
+	if err != nil {
+		t.Fatalf("%s: %v", script, err)
+	}
+	var codes []DiagnosticCode
+	for _, d := range r.Resolve(*root) {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		script string
+		codes  []DiagnosticCode
+	}{
+		{"int a = 1; print(a);", nil},
+		{"print(a);", []DiagnosticCode{DiagnosticCode_Undeclared}},
+		{"a = 1;", []DiagnosticCode{DiagnosticCode_Undeclared}},
+		{"{ int a = 1; } print(a);", []DiagnosticCode{DiagnosticCode_Undeclared}},
+		{"int f() { return g(); } int g() { return 1; }", nil},
+		{"int a; int a;", []DiagnosticCode{DiagnosticCode_Redeclaration}},
+		{"int f(int a) { int a = 1; return a; }", []DiagnosticCode{DiagnosticCode_Redeclaration}},
+		{"int f(int a, int a) { return a; }", []DiagnosticCode{DiagnosticCode_Redeclaration}},
+		{"int a; { int a; }", []DiagnosticCode{DiagnosticCode_Shadowing}},
+		{"int a; int f(int a) { return a; }", []DiagnosticCode{DiagnosticCode_Shadowing}},
+		{"int a; a();", []DiagnosticCode{DiagnosticCode_NotAFunction}},
+		{"int f() { return 1; } f = 2;", []DiagnosticCode{DiagnosticCode_NotAVariable}},
+		{"int f() { return 1; } print(f);", []DiagnosticCode{DiagnosticCode_NotAVariable}},
+		{"int f(int a) { return a; } f();", []DiagnosticCode{DiagnosticCode_ArgumentCount}},
+		{"int f(int a) { return a; } f(1, 2);", []DiagnosticCode{DiagnosticCode_ArgumentCount}},
+		{"g();", []DiagnosticCode{DiagnosticCode_Undeclared}},
+	}
+	for _, test := range tests {
+		t.Run(test.script, func(t *testing.T) {
+			if got := resolve(t, NewResolver(), test.script); fmt.Sprint(got) != fmt.Sprint(test.codes) {
+				t.Errorf("got %v, want %v", got, test.codes)
+			}
+		})
+	}
+}
+
+func TestRedeclarationNote(t *testing.T) {
+	parser := SimpleParser{Origin: NewPosition()}
+	root, err := parser.Parse("int a;\nint a;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := NewResolver().Resolve(*root)
+	if len(diagnostics) != 1 || len(diagnostics[0].Notes) != 1 {
+		t.Fatalf("got %v, want a redeclaration with a note", diagnostics)
+	}
+	if d, note := diagnostics[0], diagnostics[0].Notes[0]; d.Span.Start.Line != 2 || note.Span.Start.Line != 1 {
+		t.Errorf("redeclaration at %s with a note at %s, want lines 2 and 1", d.Span.Start, note.Span.Start)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	// Each REPL line is resolved with the same Resolver.
+	r := NewResolver()
+	if codes := resolve(t, r, "int a = 1;"); codes != nil {
+		t.Fatal(codes)
+	}
+	if codes := resolve(t, r, "int b = 2; print(c);"); len(codes) != 1 {
+		t.Fatalf("got %v, want the undeclared c", codes)
+	}
+	r.Rollback()
+	if codes := resolve(t, r, "print(a); int b = 3;"); codes != nil {
+		t.Errorf("got %v, want a kept and b dropped", codes)
+	}
+	if codes := resolve(t, r, "int a = 4;"); fmt.Sprint(codes) != fmt.Sprint([]DiagnosticCode{DiagnosticCode_Redeclaration}) {
+		t.Errorf("got %v, want a redeclaration of a", codes)
+	}
+}
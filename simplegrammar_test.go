@@ -0,0 +1,566 @@
+// Code generated by compiler ll1 from simpleparser.go; DO NOT EDIT.
+
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// SimpleGrammarParser parses the grammar of simpleparser.go.
+type SimpleGrammarParser struct {
+	reader TokenReader
+	// failure is the error of a failed alternative that went the furthest.
+	failure *Diagnostic
+}
+
+// Parse builds the tree of code from the rule programm. It stops at the first
+// syntax error.
+func (p *SimpleGrammarParser) Parse(code string) (ASTNoder, error) {
+	return p.ParseReader(strings.NewReader(code))
+}
+
+// ParseReader is like Parse but scans the code from in.
+func (p *SimpleGrammarParser) ParseReader(in io.Reader) (root ASTNoder, err error) {
+	lexer := SimpleLexer{}
+	p.reader = NewStreamTokenReader(&lexer, in)
+	p.failure = nil
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			root, err = nil, append(lexer.errors, d)
+		} else if len(lexer.errors) > 0 {
+			err = lexer.errors
+		}
+		if lexer.err != nil && lexer.err != io.EOF {
+			err = lexer.err
+		}
+	}()
+	root = p.parseProgramm()
+	if !p.at(TokenType_EOF) {
+		p.fail("end of input")
+	}
+	return root, nil
+}
+
+// at reports whether the next token has one of types, TokenType_EOF
+// standing for the end of the input.
+func (p *SimpleGrammarParser) at(types ...TokenType) bool {
+	next := TokenType_EOF
+	if token := p.reader.Peek(); token != nil {
+		next = token.Type
+	}
+	for _, t := range types {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SimpleGrammarParser) match(tokenType TokenType, expected string) ASTNoder {
+	if !p.at(tokenType) {
+		p.fail(expected)
+	}
+	token := p.reader.Read()
+	leaf := NewASTNoder(ASTNodeType(token.Type), token.Text, token.Span)
+	leaf.SetValue(token.Value)
+	return leaf
+}
+
+// fail aborts parsing at the next token, or at the error of an alternative
+// tried before that went further.
+func (p *SimpleGrammarParser) fail(expected string) {
+	span := currentSpan(p.reader)
+	if p.failure != nil && p.failure.Span.Start.Offset > span.Start.Offset {
+		panic(p.failure)
+	}
+	found := "end of input"
+	if token := p.reader.Peek(); token != nil {
+		found = token.Text
+	}
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_UnexpectedToken, "unexpected "+found+", expecting "+expected, span))
+}
+
+// attempt parses alternative, adding what it matched to node if it
+// succeeds, or going back to where it started if it fails.
+func (p *SimpleGrammarParser) attempt(node ASTNoder, alternative func(node ASTNoder)) (ok bool) {
+	position := p.reader.GetPosition()
+	try := NewASTNoder(node.GetType(), "", Span{})
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				d, isDiagnostic := r.(*Diagnostic)
+				if !isDiagnostic || d.Code == DiagnosticCode_BacktrackLimit {
+					panic(r)
+				}
+				if p.failure == nil || d.Span.Start.Offset >= p.failure.Span.Start.Offset {
+					p.failure = d
+				}
+			}
+		}()
+		alternative(try)
+		ok = true
+	}()
+	if !ok {
+		failure := p.failure
+		defer func() {
+			// Too far to go back: the error of the alternative is the best
+			// there is.
+			if r := recover(); r != nil {
+				panic(failure)
+			}
+		}()
+		p.reader.setPosition(position)
+		return false
+	}
+	for _, child := range try.GetChildren() {
+		node.AddChild(child)
+	}
+	return true
+}
+
+// close gives node the span of its children, or an empty one at the next
+// token.
+func (p *SimpleGrammarParser) close(node ASTNoder) {
+	children := node.GetChildren()
+	if len(children) == 0 {
+		start := currentSpan(p.reader).Start
+		node.SetSpan(Span{Start: start, End: start})
+		return
+	}
+	node.SetSpan(joinSpan(children[0].GetSpan(), children[len(children)-1].GetSpan()))
+}
+
+func (p *SimpleGrammarParser) parseProgramm() ASTNoder {
+	node := NewASTNoder(ASTNodeType("programm"), "", Span{})
+	for p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_Break, TokenType_Continue, TokenType_False, TokenType_Float, TokenType_For, TokenType_Function, TokenType_If, TokenType_Int, TokenType_Print, TokenType_Return, TokenType_String, TokenType_True, TokenType_While, TokenType_Left_Brace, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+		if p.at(TokenType_Bool, TokenType_Float, TokenType_Function, TokenType_Int, TokenType_String) && p.attempt(node, func(node ASTNoder) {
+			node.AddChild(p.parseFunctionDeclare())
+		}) {
+		} else if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_Break, TokenType_Continue, TokenType_False, TokenType_Float, TokenType_For, TokenType_If, TokenType_Int, TokenType_Print, TokenType_Return, TokenType_String, TokenType_True, TokenType_While, TokenType_Left_Brace, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+			node.AddChild(p.parseStatement())
+		} else {
+			p.fail("'!', '(', '+', '-', 'bool', 'break', 'continue', 'false', 'float', 'for', 'function', 'if', 'int', 'print', 'return', 'string', 'true', 'while', '{', CharLiteral, FloatLiteral, Id, IntLiteral or StringLiteral")
+		}
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseFunctionDeclare() ASTNoder {
+	node := NewASTNoder(ASTNodeType("functionDeclare"), "", Span{})
+	switch {
+	case p.at(TokenType_Bool, TokenType_Float, TokenType_Int, TokenType_String):
+		node.AddChild(p.parseType())
+	case p.at(TokenType_Function):
+		node.AddChild(p.match(TokenType_Function, "'function'"))
+	default:
+		p.fail("'bool', 'float', 'function', 'int' or 'string'")
+	}
+	node.AddChild(p.match(TokenType_Id, "Id"))
+	node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+	if p.at(TokenType_Bool, TokenType_Float, TokenType_Int, TokenType_String) {
+		node.AddChild(p.parseParameters())
+	}
+	node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	node.AddChild(p.parseBlock())
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseParameters() ASTNoder {
+	node := NewASTNoder(ASTNodeType("parameters"), "", Span{})
+	node.AddChild(p.parseType())
+	node.AddChild(p.match(TokenType_Id, "Id"))
+	for p.at(TokenType_Comma) {
+		node.AddChild(p.match(TokenType_Comma, "','"))
+		node.AddChild(p.parseType())
+		node.AddChild(p.match(TokenType_Id, "Id"))
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseType() ASTNoder {
+	node := NewASTNoder(ASTNodeType("type"), "", Span{})
+	switch {
+	case p.at(TokenType_Int):
+		node.AddChild(p.match(TokenType_Int, "'int'"))
+	case p.at(TokenType_Float):
+		node.AddChild(p.match(TokenType_Float, "'float'"))
+	case p.at(TokenType_Bool):
+		node.AddChild(p.match(TokenType_Bool, "'bool'"))
+	case p.at(TokenType_String):
+		node.AddChild(p.match(TokenType_String, "'string'"))
+	default:
+		p.fail("'bool', 'float', 'int' or 'string'")
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("statement"), "", Span{})
+	if p.at(TokenType_Left_Brace) {
+		node.AddChild(p.parseBlock())
+	} else if p.at(TokenType_If) {
+		node.AddChild(p.parseIfStatement())
+	} else if p.at(TokenType_While) {
+		node.AddChild(p.parseWhileStatement())
+	} else if p.at(TokenType_For) {
+		node.AddChild(p.parseForStatement())
+	} else if p.at(TokenType_Break) {
+		node.AddChild(p.parseBreakStatement())
+	} else if p.at(TokenType_Continue) {
+		node.AddChild(p.parseContinueStatement())
+	} else if p.at(TokenType_Return) {
+		node.AddChild(p.parseReturnStatement())
+	} else if p.at(TokenType_Print) {
+		node.AddChild(p.parsePrintStatement())
+	} else if p.at(TokenType_Bool, TokenType_Float, TokenType_Int, TokenType_String) && p.attempt(node, func(node ASTNoder) {
+		node.AddChild(p.parseDeclaration())
+	}) {
+	} else if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) && p.attempt(node, func(node ASTNoder) {
+		node.AddChild(p.parseExpressionStatement())
+	}) {
+	} else if p.at(TokenType_Id) {
+		node.AddChild(p.parseAssignmentStatement())
+	} else {
+		p.fail("'!', '(', '+', '-', 'bool', 'break', 'continue', 'false', 'float', 'for', 'if', 'int', 'print', 'return', 'string', 'true', 'while', '{', CharLiteral, FloatLiteral, Id, IntLiteral or StringLiteral")
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseBlock() ASTNoder {
+	node := NewASTNoder(ASTNodeType("block"), "", Span{})
+	node.AddChild(p.match(TokenType_Left_Brace, "'{'"))
+	for p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_Break, TokenType_Continue, TokenType_False, TokenType_Float, TokenType_For, TokenType_If, TokenType_Int, TokenType_Print, TokenType_Return, TokenType_String, TokenType_True, TokenType_While, TokenType_Left_Brace, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+		node.AddChild(p.parseStatement())
+	}
+	node.AddChild(p.match(TokenType_Right_Brace, "'}'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseIfStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("ifStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_If, "'if'"))
+	node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+	node.AddChild(p.parseExpression())
+	node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	node.AddChild(p.parseStatement())
+	if p.at(TokenType_Else) {
+		node.AddChild(p.match(TokenType_Else, "'else'"))
+		node.AddChild(p.parseStatement())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseWhileStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("whileStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_While, "'while'"))
+	node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+	node.AddChild(p.parseExpression())
+	node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	node.AddChild(p.parseStatement())
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseForStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("forStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_For, "'for'"))
+	node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+	switch {
+	case p.at(TokenType_Bool, TokenType_Float, TokenType_Int, TokenType_String):
+		node.AddChild(p.parseDeclaration())
+	case p.at(TokenType_Id):
+		node.AddChild(p.parseAssignmentStatement())
+	case p.at(TokenType_SemiColon):
+		node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	default:
+		p.fail("';', 'bool', 'float', 'int', 'string' or Id")
+	}
+	if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+		node.AddChild(p.parseExpression())
+	}
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+		if p.at(TokenType_Id) && p.attempt(node, func(node ASTNoder) {
+			node.AddChild(p.parseAssignment())
+		}) {
+		} else if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+			node.AddChild(p.parseExpression())
+		} else {
+			p.fail("'!', '(', '+', '-', 'bool', 'false', 'float', 'int', 'string', 'true', CharLiteral, FloatLiteral, Id, IntLiteral or StringLiteral")
+		}
+	}
+	node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	node.AddChild(p.parseStatement())
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseBreakStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("breakStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_Break, "'break'"))
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseContinueStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("continueStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_Continue, "'continue'"))
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseReturnStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("returnStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_Return, "'return'"))
+	if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+		node.AddChild(p.parseExpression())
+	}
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parsePrintStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("printStatement"), "", Span{})
+	node.AddChild(p.match(TokenType_Print, "'print'"))
+	node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+	node.AddChild(p.parseExpression())
+	node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseDeclaration() ASTNoder {
+	node := NewASTNoder(ASTNodeType("declaration"), "", Span{})
+	node.AddChild(p.parseType())
+	node.AddChild(p.match(TokenType_Id, "Id"))
+	if p.at(TokenType_Assignment) {
+		node.AddChild(p.match(TokenType_Assignment, "'='"))
+		node.AddChild(p.parseExpression())
+	}
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseExpressionStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("expressionStatement"), "", Span{})
+	node.AddChild(p.parseExpression())
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseAssignmentStatement() ASTNoder {
+	node := NewASTNoder(ASTNodeType("assignmentStatement"), "", Span{})
+	node.AddChild(p.parseAssignment())
+	node.AddChild(p.match(TokenType_SemiColon, "';'"))
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseAssignment() ASTNoder {
+	node := NewASTNoder(ASTNodeType("assignment"), "", Span{})
+	node.AddChild(p.match(TokenType_Id, "Id"))
+	node.AddChild(p.match(TokenType_Assignment, "'='"))
+	node.AddChild(p.parseExpression())
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseExpression() ASTNoder {
+	node := NewASTNoder(ASTNodeType("expression"), "", Span{})
+	node.AddChild(p.parseLogicalOr())
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseLogicalOr() ASTNoder {
+	node := NewASTNoder(ASTNodeType("logicalOr"), "", Span{})
+	node.AddChild(p.parseLogicalAnd())
+	for p.at(TokenType_Or) {
+		node.AddChild(p.match(TokenType_Or, "'||'"))
+		node.AddChild(p.parseLogicalAnd())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseLogicalAnd() ASTNoder {
+	node := NewASTNoder(ASTNodeType("logicalAnd"), "", Span{})
+	node.AddChild(p.parseEquality())
+	for p.at(TokenType_And) {
+		node.AddChild(p.match(TokenType_And, "'&&'"))
+		node.AddChild(p.parseEquality())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseEquality() ASTNoder {
+	node := NewASTNoder(ASTNodeType("equality"), "", Span{})
+	node.AddChild(p.parseRelational())
+	for p.at(TokenType_NE, TokenType_EQ) {
+		switch {
+		case p.at(TokenType_EQ):
+			node.AddChild(p.match(TokenType_EQ, "'=='"))
+		case p.at(TokenType_NE):
+			node.AddChild(p.match(TokenType_NE, "'!='"))
+		default:
+			p.fail("'!=' or '=='")
+		}
+		node.AddChild(p.parseRelational())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseRelational() ASTNoder {
+	node := NewASTNoder(ASTNodeType("relational"), "", Span{})
+	node.AddChild(p.parseAdditive())
+	for p.at(TokenType_LT, TokenType_LE, TokenType_GT, TokenType_GE) {
+		switch {
+		case p.at(TokenType_LT):
+			node.AddChild(p.match(TokenType_LT, "'<'"))
+		case p.at(TokenType_LE):
+			node.AddChild(p.match(TokenType_LE, "'<='"))
+		case p.at(TokenType_GT):
+			node.AddChild(p.match(TokenType_GT, "'>'"))
+		case p.at(TokenType_GE):
+			node.AddChild(p.match(TokenType_GE, "'>='"))
+		default:
+			p.fail("'<', '<=', '>' or '>='")
+		}
+		node.AddChild(p.parseAdditive())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseAdditive() ASTNoder {
+	node := NewASTNoder(ASTNodeType("additive"), "", Span{})
+	node.AddChild(p.parseMultiplicative())
+	for p.at(TokenType_Plus, TokenType_Minus) {
+		switch {
+		case p.at(TokenType_Plus):
+			node.AddChild(p.match(TokenType_Plus, "'+'"))
+		case p.at(TokenType_Minus):
+			node.AddChild(p.match(TokenType_Minus, "'-'"))
+		default:
+			p.fail("'+' or '-'")
+		}
+		node.AddChild(p.parseMultiplicative())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseMultiplicative() ASTNoder {
+	node := NewASTNoder(ASTNodeType("multiplicative"), "", Span{})
+	node.AddChild(p.parseUnary())
+	for p.at(TokenType_Percent, TokenType_Star, TokenType_Slash) {
+		switch {
+		case p.at(TokenType_Star):
+			node.AddChild(p.match(TokenType_Star, "'*'"))
+		case p.at(TokenType_Slash):
+			node.AddChild(p.match(TokenType_Slash, "'/'"))
+		case p.at(TokenType_Percent):
+			node.AddChild(p.match(TokenType_Percent, "'%'"))
+		default:
+			p.fail("'%', '*' or '/'")
+		}
+		node.AddChild(p.parseUnary())
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseUnary() ASTNoder {
+	node := NewASTNoder(ASTNodeType("unary"), "", Span{})
+	switch {
+	case p.at(TokenType_Not, TokenType_Plus, TokenType_Minus):
+		switch {
+		case p.at(TokenType_Not):
+			node.AddChild(p.match(TokenType_Not, "'!'"))
+		case p.at(TokenType_Minus):
+			node.AddChild(p.match(TokenType_Minus, "'-'"))
+		case p.at(TokenType_Plus):
+			node.AddChild(p.match(TokenType_Plus, "'+'"))
+		default:
+			p.fail("'!', '+' or '-'")
+		}
+		node.AddChild(p.parseUnary())
+	case p.at(TokenType_Left_Paren, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral):
+		node.AddChild(p.parsePrimary())
+	default:
+		p.fail("'!', '(', '+', '-', 'bool', 'false', 'float', 'int', 'string', 'true', CharLiteral, FloatLiteral, Id, IntLiteral or StringLiteral")
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parsePrimary() ASTNoder {
+	node := NewASTNoder(ASTNodeType("primary"), "", Span{})
+	switch {
+	case p.at(TokenType_IntLiteral):
+		node.AddChild(p.match(TokenType_IntLiteral, "IntLiteral"))
+	case p.at(TokenType_CharLiteral):
+		node.AddChild(p.match(TokenType_CharLiteral, "CharLiteral"))
+	case p.at(TokenType_FloatLiteral):
+		node.AddChild(p.match(TokenType_FloatLiteral, "FloatLiteral"))
+	case p.at(TokenType_StringLiteral):
+		node.AddChild(p.match(TokenType_StringLiteral, "StringLiteral"))
+	case p.at(TokenType_True):
+		node.AddChild(p.match(TokenType_True, "'true'"))
+	case p.at(TokenType_False):
+		node.AddChild(p.match(TokenType_False, "'false'"))
+	case p.at(TokenType_Id):
+		node.AddChild(p.match(TokenType_Id, "Id"))
+		if p.at(TokenType_Left_Paren) {
+			node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+			if p.at(TokenType_Not, TokenType_Left_Paren, TokenType_Plus, TokenType_Minus, TokenType_Bool, TokenType_False, TokenType_Float, TokenType_Int, TokenType_String, TokenType_True, TokenType_CharLiteral, TokenType_FloatLiteral, TokenType_Id, TokenType_IntLiteral, TokenType_StringLiteral) {
+				node.AddChild(p.parseArguments())
+			}
+			node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+		}
+	case p.at(TokenType_Bool, TokenType_Float, TokenType_Int, TokenType_String):
+		node.AddChild(p.parseType())
+		node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+		node.AddChild(p.parseExpression())
+		node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	case p.at(TokenType_Left_Paren):
+		node.AddChild(p.match(TokenType_Left_Paren, "'('"))
+		node.AddChild(p.parseExpression())
+		node.AddChild(p.match(TokenType_Right_Paren, "')'"))
+	default:
+		p.fail("'(', 'bool', 'false', 'float', 'int', 'string', 'true', CharLiteral, FloatLiteral, Id, IntLiteral or StringLiteral")
+	}
+	p.close(node)
+	return node
+}
+
+func (p *SimpleGrammarParser) parseArguments() ASTNoder {
+	node := NewASTNoder(ASTNodeType("arguments"), "", Span{})
+	node.AddChild(p.parseExpression())
+	for p.at(TokenType_Comma) {
+		node.AddChild(p.match(TokenType_Comma, "','"))
+		node.AddChild(p.parseExpression())
+	}
+	p.close(node)
+	return node
+}
@@ -2,20 +2,54 @@ package main
 
 import (
 	"fmt"
-	"strconv"
+	"io"
+	"os"
+	"strings"
 )
 
+// The generated parser is only used by the tests, which check it accepts
+// the same programs as SimpleParser.
+//go:generate go run . ll1 -type SimpleGrammarParser -o simplegrammar_test.go simpleparser.go
+
 /**
  * 一个简单的语法解析器。
- * 能够解析简单的表达式、变量声明和初始化语句、赋值语句。
- * 它支持的语法规则为：
+ * 能够解析简单的表达式（包括比较和逻辑表达式）、变量声明和初始化语句、赋值语句，
+ * 块、if/else、while、for、break 和 continue 语句，以及函数声明、调用和 return 语句。
+ * 支持 int、float、bool 和 string 四种类型，以及显式类型转换。
+ * 它支持的语法规则如下，可以用 compiler ll1 读出并生成解析器。
+ * 其中表达式按 simpleOperators 用优先级爬升解析，expression 到 unary 各层只是它的等价写法。
  *
- * programm -> intDeclare | expressionStatement | assignmentStatement
- * intDeclare -> 'int' Id ( = additive) ';'
- * expressionStatement -> addtive ';'
- * addtive -> multiplicative ( (+ | -) multiplicative)*
- * multiplicative -> primary ( (* | /) primary)*
- * primary -> IntLiteral | Id | (additive)
+ * programm -> (functionDeclare | statement)*
+ * functionDeclare -> (type | 'function') Id '(' parameters? ')' block
+ * parameters -> type Id (',' type Id)*
+ * type -> 'int' | 'float' | 'bool' | 'string'
+ * statement -> block | ifStatement | whileStatement | forStatement
+ *            | breakStatement | continueStatement | returnStatement
+ *            | printStatement | declaration | expressionStatement | assignmentStatement
+ * block -> '{' statement* '}'
+ * ifStatement -> 'if' '(' expression ')' statement ('else' statement)?
+ * whileStatement -> 'while' '(' expression ')' statement
+ * forStatement -> 'for' '(' (declaration | assignmentStatement | ';')
+ *                 expression? ';' (assignment | expression)? ')' statement
+ * breakStatement -> 'break' ';'
+ * continueStatement -> 'continue' ';'
+ * returnStatement -> 'return' expression? ';'
+ * printStatement -> 'print' '(' expression ')' ';'
+ * declaration -> type Id ('=' expression)? ';'
+ * expressionStatement -> expression ';'
+ * assignmentStatement -> assignment ';'
+ * assignment -> Id '=' expression
+ * expression -> logicalOr
+ * logicalOr -> logicalAnd ('||' logicalAnd)*
+ * logicalAnd -> equality ('&&' equality)*
+ * equality -> relational (('==' | '!=') relational)*
+ * relational -> additive (('<' | '<=' | '>' | '>=') additive)*
+ * additive -> multiplicative (('+' | '-') multiplicative)*
+ * multiplicative -> unary (('*' | '/' | '%') unary)*
+ * unary -> ('!' | '-' | '+') unary | primary
+ * primary -> IntLiteral | CharLiteral | FloatLiteral | StringLiteral | 'true' | 'false'
+ *          | Id ('(' arguments? ')')? | type '(' expression ')' | '(' expression ')'
+ * arguments -> expression (',' expression)*
  */
 
 type ASTNodeType string
@@ -28,31 +62,115 @@ const (
 	ASTNodeType_Multiplicative = ASTNodeType("Multiplicative")
 	ASTNodeType_Assignment     = ASTNodeType("Assignment")
 	ASTNodeType_Identifier     = ASTNodeType("Identifier")
+	ASTNodeType_Relational     = ASTNodeType("Relational")
+	ASTNodeType_Equality       = ASTNodeType("Equality")
+	ASTNodeType_LogicalAnd     = ASTNodeType("LogicalAnd")
+	ASTNodeType_LogicalOr      = ASTNodeType("LogicalOr")
+	ASTNodeType_Unary          = ASTNodeType("Unary")
+	ASTNodeType_Error          = ASTNodeType("Error")
+	ASTNodeType_Block          = ASTNodeType("Block")
+	ASTNodeType_If             = ASTNodeType("If")
+	ASTNodeType_While          = ASTNodeType("While")
+	ASTNodeType_For            = ASTNodeType("For")
+	ASTNodeType_Break          = ASTNodeType("Break")
+	ASTNodeType_Continue       = ASTNodeType("Continue")
+	ASTNodeType_Empty          = ASTNodeType("Empty")
+
+	ASTNodeType_FunctionDeclaration = ASTNodeType("FunctionDeclaration")
+	ASTNodeType_Parameter           = ASTNodeType("Parameter")
+	ASTNodeType_FunctionCall        = ASTNodeType("FunctionCall")
+	ASTNodeType_Return              = ASTNodeType("Return")
+
+	ASTNodeType_FloatDeclaration  = ASTNodeType("FloatDeclaration")
+	ASTNodeType_BoolDeclaration   = ASTNodeType("BoolDeclaration")
+	ASTNodeType_StringDeclaration = ASTNodeType("StringDeclaration")
+	ASTNodeType_FloatLiteral      = ASTNodeType("FloatLiteral")
+	ASTNodeType_BoolLiteral       = ASTNodeType("BoolLiteral")
+	ASTNodeType_StringLiteral     = ASTNodeType("StringLiteral")
+	ASTNodeType_Conversion        = ASTNodeType("Conversion")
+	ASTNodeType_Print             = ASTNodeType("Print")
 )
 
+// typeKeywords maps the type keywords to the types they name.
+var typeKeywords = map[TokenType]DataType{
+	TokenType_Int:    DataType_Int,
+	TokenType_Float:  DataType_Float,
+	TokenType_Bool:   DataType_Bool,
+	TokenType_String: DataType_String,
+}
+
+var declarationTypes = map[TokenType]ASTNodeType{
+	TokenType_Int:    ASTNodeType_IntDeclaration,
+	TokenType_Float:  ASTNodeType_FloatDeclaration,
+	TokenType_Bool:   ASTNodeType_BoolDeclaration,
+	TokenType_String: ASTNodeType_StringDeclaration,
+}
+
+// isVariableDeclaration reports whether nodeType declares a variable.
+func isVariableDeclaration(nodeType ASTNodeType) bool {
+	switch nodeType {
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		return true
+	}
+	return false
+}
+
+// declaredType is the type of the variable, parameter or function return
+// value declared by node.
+func declaredType(node ASTNoder) DataType {
+	switch node.GetType() {
+	case ASTNodeType_IntDeclaration:
+		return DataType_Int
+	case ASTNodeType_FloatDeclaration:
+		return DataType_Float
+	case ASTNodeType_BoolDeclaration:
+		return DataType_Bool
+	case ASTNodeType_StringDeclaration:
+		return DataType_String
+	}
+	return node.GetDataType()
+}
+
 type ASTNoder interface {
 	AddChild(child ASTNoder)
 	GetText() string
 	GetType() ASTNodeType
 	GetChildren() []ASTNoder
 	GetParent() ASTNoder
+	GetSpan() Span
+	SetSpan(span Span)
+	GetSymbol() *Symbol
+	SetSymbol(symbol *Symbol)
+	GetDataType() DataType
+	SetDataType(dataType DataType)
+	GetValue() Value
+	SetValue(value Value)
+	SetChild(index int, child ASTNoder)
 }
 
 type SimpleASTNode struct {
 	nodeType ASTNodeType
 	text     string
+	span     Span
+	symbol   *Symbol
+	dataType DataType
+	value    Value
 	parent   ASTNoder
 	children []ASTNoder
 }
 
-func NewASTNoder(nodeType ASTNodeType, text string) ASTNoder {
-	return &SimpleASTNode{nodeType: nodeType, text: text}
+func NewASTNoder(nodeType ASTNodeType, text string, span Span) ASTNoder {
+	return &SimpleASTNode{nodeType: nodeType, text: text, span: span}
 }
 
 func (s *SimpleASTNode) AddChild(child ASTNoder) {
 	s.children = append(s.children, child)
 }
 
+func (s *SimpleASTNode) SetChild(index int, child ASTNoder) {
+	s.children[index] = child
+}
+
 func (s *SimpleASTNode) GetText() string {
 	return s.text
 }
@@ -69,26 +187,124 @@ func (s *SimpleASTNode) GetParent() ASTNoder {
 	return s.parent
 }
 
+func (s *SimpleASTNode) GetSpan() Span {
+	return s.span
+}
+
+func (s *SimpleASTNode) SetSpan(span Span) {
+	s.span = span
+}
+
+// GetSymbol is the declaration a name refers to, or the symbol a declaration
+// introduces, as bound by the Resolver.
+func (s *SimpleASTNode) GetSymbol() *Symbol {
+	return s.symbol
+}
+
+func (s *SimpleASTNode) SetSymbol(symbol *Symbol) {
+	s.symbol = symbol
+}
+
+// GetDataType is the type of an expression as computed by the TypeChecker,
+// or the declared type of a parameter or function.
+func (s *SimpleASTNode) GetDataType() DataType {
+	return s.dataType
+}
+
+func (s *SimpleASTNode) SetDataType(dataType DataType) {
+	s.dataType = dataType
+}
+
+// GetValue is the value of a literal, as parsed by the lexer or computed by
+// constant folding.
+func (s *SimpleASTNode) GetValue() Value {
+	return s.value
+}
+
+func (s *SimpleASTNode) SetValue(value Value) {
+	s.value = value
+}
+
 type SimpleParser struct {
+	// Origin is the position of the first character of the parsed code, so
+	// that spans stay meaningful when a script is fed in piece by piece.
+	Origin Position
+	// Operators are the operators of expressions, simpleOperators if nil.
+	Operators     OperatorTable
+	errors        Diagnostics
+	blockDepth    int
+	loopDepth     int
+	functionDepth int
 }
 
 func DumpAST(node ASTNoder, indent string) {
-	fmt.Printf("%s%s %s\n", indent, node.GetType(), node.GetText())
+	FdumpAST(os.Stdout, node, indent)
+}
+
+// FdumpAST writes the tree of node to w, one node per line.
+func FdumpAST(w io.Writer, node ASTNoder, indent string) {
+	if node.GetDataType() != "" {
+		fmt.Fprintf(w, "%s%s %s @%s : %s\n", indent, node.GetType(), node.GetText(), node.GetSpan(), node.GetDataType())
+	} else {
+		fmt.Fprintf(w, "%s%s %s @%s\n", indent, node.GetType(), node.GetText(), node.GetSpan())
+	}
 	for _, _node := range node.GetChildren() {
-		DumpAST(_node, "\t"+indent)
+		FdumpAST(w, _node, "\t"+indent)
 	}
 }
 
-func (s *SimpleParser) Evaluate(script string) int {
-	node := s.Parse(script)
+func (s *SimpleParser) Evaluate(script string) (int, error) {
+	node, err := s.Parse(script)
+	if err != nil {
+		return 0, err
+	}
 	DumpAST(*node, "	")
-	return s.evaluate(*node, "\t")
+	return s.evaluate(*node, "\t"), nil
+}
+
+// Parse builds the AST of code. A statement with a syntax error becomes an
+// ASTNodeType_Error node and parsing resumes after the next semicolon; all
+// errors found are returned together as Diagnostics alongside the partial AST.
+func (s *SimpleParser) Parse(code string) (*ASTNoder, error) {
+	return s.ParseReader(strings.NewReader(code))
+}
+
+// ParseReader is like Parse but scans the code from in as the parser asks
+// for tokens. Lexical errors are returned before the syntax errors, and an
+// error reading in other than io.EOF instead of them.
+func (s *SimpleParser) ParseReader(in io.Reader) (*ASTNoder, error) {
+	s.errors = nil
+	s.blockDepth, s.loopDepth, s.functionDepth = 0, 0, 0
+	lexer := SimpleLexer{origin: s.Origin}
+	root := s.prog(NewStreamTokenReader(&lexer, in))
+	if lexer.err != nil && lexer.err != io.EOF {
+		return root, lexer.err
+	}
+	s.errors = append(lexer.errors, s.errors...)
+	if len(s.errors) > 0 {
+		return root, s.errors
+	}
+	return root, nil
 }
 
-func (s *SimpleParser) Parse(code string) *ASTNoder {
-	lexer := SimpleLexer{}
-	tokens := lexer.tokenize(code)
-	return s.prog(tokens)
+// fail aborts parsing with a syntax error at the current token.
+func (s *SimpleParser) fail(reader TokenReader, code DiagnosticCode, message string) {
+	panic(NewDiagnostic(Severity_Error, code, message, currentSpan(reader)))
+}
+
+// currentSpan is the span of the next token, or an empty span just past the
+// last token when the input is exhausted.
+func currentSpan(reader TokenReader) Span {
+	if token := reader.Peek(); token != nil {
+		return token.Span
+	}
+	pos := reader.GetPosition()
+	if pos == 0 {
+		return Span{}
+	}
+	reader.setPosition(pos - 1)
+	end := reader.Read().Span.End
+	return Span{Start: end, End: end}
 }
 
 func (s *SimpleParser) evaluate(node ASTNoder, indent string) int {
@@ -110,7 +326,7 @@ func (s *SimpleParser) evaluate(node ASTNoder, indent string) int {
 			result = value1 - value2
 		}
 	case ASTNodeType_IntLiteral:
-		result, _ = strconv.Atoi(node.GetText())
+		result = node.GetValue().Int
 	case ASTNodeType_Multiplicative:
 		child1 := node.GetChildren()[0]
 		value1 := s.evaluate(child1, indent+"\t")
@@ -126,116 +342,404 @@ func (s *SimpleParser) evaluate(node ASTNoder, indent string) int {
 }
 
 func (s *SimpleParser) prog(tokens TokenReader) *ASTNoder {
-	noder := NewASTNoder(ASTNodeType_Program, "pwc")
+	noder := NewASTNoder(ASTNodeType_Program, "pwc", Span{})
 	for {
 		token := tokens.Peek()
 		if token == nil {
 			break
 		}
-		child := s.intDeclare(tokens)
-		if child == nil {
-			child = s.expressionStatement(tokens)
+		noder.AddChild(s.statementOrError(tokens))
+	}
+	if children := noder.GetChildren(); len(children) > 0 {
+		noder.SetSpan(joinSpan(children[0].GetSpan(), children[len(children)-1].GetSpan()))
+	}
+	return &noder
+}
+
+// statementOrError parses one statement. A syntax error is recorded and the
+// tokens up to the next semicolon, or up to the closing brace of the
+// enclosing block, are replaced by an error node. A semicolon missing at the
+// end of a line is reported there and nothing is skipped, so that the
+// statement of the next line, and its errors, are not lost.
+func (s *SimpleParser) statementOrError(reader TokenReader) (node ASTNoder) {
+	start := currentSpan(reader)
+	position := reader.GetPosition()
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			s.errors = append(s.errors, d)
+			if d.Code == DiagnosticCode_ExpectedSemiColon && reader.GetPosition() > position {
+				end := previousEnd(reader)
+				if next := reader.Peek(); next != nil && next.Span.Start.Line > end.Line {
+					d.Span = Span{end, end}
+					node = NewASTNoder(ASTNodeType_Error, d.Message, Span{start.Start, end})
+					return
+				}
+			}
+			node = NewASTNoder(ASTNodeType_Error, d.Message, joinSpan(start, s.synchronize(reader, start)))
 		}
-		if child == nil {
-			child = s.assignmentStatement(tokens)
+	}()
+	child := s.statement(reader)
+	if child == nil {
+		s.fail(reader, DiagnosticCode_UnknownStatement, "unknown statement")
+	}
+	return *child
+}
+
+// synchronize skips tokens up to and including the next semicolon and
+// returns the span of the last token skipped. Inside a block it stops in
+// front of a right brace so that the block can still be closed.
+func (s *SimpleParser) synchronize(reader TokenReader, last Span) Span {
+	for {
+		token := reader.Peek()
+		if token == nil || (token.Type == TokenType_Right_Brace && s.blockDepth > 0) {
+			return last
 		}
-		if child != nil {
-			noder.AddChild(*child)
-		} else {
-			panic("unknown statement")
+		reader.Read()
+		last = token.Span
+		if token.Type == TokenType_SemiColon {
+			return last
 		}
 	}
+}
 
-	return &noder
+// previousEnd is the end of the last token read.
+func previousEnd(reader TokenReader) Position {
+	position := reader.GetPosition()
+	reader.setPosition(position - 1)
+	return reader.Read().Span.End
 }
 
-func (s *SimpleParser) intDeclare(reader TokenReader) *ASTNoder {
-	var node ASTNoder
+// expect reads the next token, which must be of tokenType.
+func (s *SimpleParser) expect(reader TokenReader, tokenType TokenType, code DiagnosticCode, message string) *Token {
 	token := reader.Peek()
-	if token != nil && token.Type == TokenType_Int {
-		reader.Read()
-		if reader.Peek().Type == TokenType_Id {
-			token = reader.Read()
-			node = NewASTNoder(ASTNodeType_IntDeclaration, token.Text)
-			token = reader.Peek()
-			if token != nil && token.Type == TokenType_Assignment {
-				reader.Read()
-				child := s.additive(reader)
-				if child == nil {
-					panic("invalide variable initialization, expecting an expression")
-				} else {
-					node.AddChild(*child)
-				}
-			}
-		} else {
-			panic("variable name expected")
+	if token == nil || token.Type != tokenType {
+		s.fail(reader, code, message)
+	}
+	return reader.Read()
+}
+
+func (s *SimpleParser) statement(reader TokenReader) *ASTNoder {
+	token := reader.Peek()
+	if token == nil {
+		return nil
+	}
+	switch token.Type {
+	case TokenType_Left_Brace:
+		return s.block(reader)
+	case TokenType_If:
+		return s.ifStatement(reader)
+	case TokenType_While:
+		return s.whileStatement(reader)
+	case TokenType_For:
+		return s.forStatement(reader)
+	case TokenType_Break, TokenType_Continue:
+		return s.jumpStatement(reader)
+	case TokenType_Return:
+		return s.returnStatement(reader)
+	case TokenType_Print:
+		return s.printStatement(reader)
+	case TokenType_Function:
+		return s.functionDeclare(reader)
+	case TokenType_Int, TokenType_Float, TokenType_Bool, TokenType_String:
+		if s.isFunctionDeclare(reader) {
+			return s.functionDeclare(reader)
 		}
-		if node != nil {
+	}
+	child := s.declaration(reader)
+	if child == nil {
+		child = s.expressionStatement(reader)
+	}
+	if child == nil {
+		child = s.assignmentStatement(reader)
+	}
+	return child
+}
+
+// isFunctionDeclare looks ahead for type Id '(' without consuming tokens.
+func (s *SimpleParser) isFunctionDeclare(reader TokenReader) bool {
+	pos := reader.GetPosition()
+	defer reader.setPosition(pos)
+	reader.Read()
+	token := reader.Read()
+	if token == nil || token.Type != TokenType_Id {
+		return false
+	}
+	token = reader.Read()
+	return token != nil && token.Type == TokenType_Left_Paren
+}
+
+// functionDeclare gives the FunctionDeclaration node one Parameter child per
+// parameter followed by the body block.
+func (s *SimpleParser) functionDeclare(reader TokenReader) *ASTNoder {
+	token := reader.Read()
+	start := token.Span
+	if s.blockDepth > 0 || s.functionDepth > 0 {
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_NestedFunction, "functions can only be declared at the top level", start))
+	}
+	returnType, ok := typeKeywords[token.Type]
+	if !ok {
+		returnType = DataType_Void
+	}
+	token = s.expect(reader, TokenType_Id, DiagnosticCode_ExpectedName, "function name expected")
+	node := NewASTNoder(ASTNodeType_FunctionDeclaration, token.Text, joinSpan(start, token.Span))
+	node.SetDataType(returnType)
+	s.expect(reader, TokenType_Left_Paren, DiagnosticCode_ExpectedLeftParen, "expecting left parenthesis after function name")
+	token = reader.Peek()
+	if token != nil && token.Type != TokenType_Right_Paren {
+		for {
+			typeToken := reader.Peek()
+			paramType, ok := DataType(""), false
+			if typeToken != nil {
+				paramType, ok = typeKeywords[typeToken.Type]
+			}
+			if !ok {
+				s.fail(reader, DiagnosticCode_ExpectedType, "parameter type expected")
+			}
+			reader.Read()
+			token = s.expect(reader, TokenType_Id, DiagnosticCode_ExpectedName, "parameter name expected")
+			param := NewASTNoder(ASTNodeType_Parameter, token.Text, joinSpan(typeToken.Span, token.Span))
+			param.SetDataType(paramType)
+			node.AddChild(param)
 			token = reader.Peek()
-			if token != nil && token.Type == TokenType_SemiColon {
-				reader.Read()
-			} else {
-				panic("variable name expected")
+			if token == nil || token.Type != TokenType_Comma {
+				break
 			}
+			reader.Read()
 		}
 	}
-	if node != nil {
-		return &node
+	s.expect(reader, TokenType_Right_Paren, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
+	token = reader.Peek()
+	if token == nil || token.Type != TokenType_Left_Brace {
+		s.fail(reader, DiagnosticCode_ExpectedStatement, "expecting function body")
 	}
-	return nil
+	s.functionDepth++
+	defer func() { s.functionDepth-- }()
+	body := s.block(reader)
+	node.AddChild(*body)
+	node.SetSpan(joinSpan(start, (*body).GetSpan()))
+	return &node
 }
 
-func (s *SimpleParser) additive1(reader TokenReader) *ASTNoder {
-	child1 := s.multiplicative(reader)
-	var node ASTNoder
+func (s *SimpleParser) returnStatement(reader TokenReader) *ASTNoder {
 	token := reader.Peek()
-	if child1 != nil && token != nil {
-		if token.Type == TokenType_Plus {
-			token := reader.Read()
-			child2 := s.additive1(reader)
-			if child2 != nil {
-				node = NewASTNoder(ASTNodeType_AddtiveExp, token.Text)
-				node.AddChild(*child1)
-				node.AddChild(*child2)
-			} else {
-				panic("invalid additive expression, expecting the right part.")
-			}
+	if s.functionDepth == 0 {
+		s.fail(reader, DiagnosticCode_ReturnOutsideFunc, "return outside of a function")
+	}
+	reader.Read()
+	node := NewASTNoder(ASTNodeType_Return, token.Text, token.Span)
+	if child := s.expression(reader); child != nil {
+		node.AddChild(*child)
+	}
+	end := s.expect(reader, TokenType_SemiColon, DiagnosticCode_ExpectedSemiColon, "invalid statement, expecting semicolon")
+	node.SetSpan(joinSpan(token.Span, end.Span))
+	return &node
+}
+
+// printStatement parses the print builtin, which writes a value and a
+// newline to the standard output.
+func (s *SimpleParser) printStatement(reader TokenReader) *ASTNoder {
+	token := reader.Read()
+	node := NewASTNoder(ASTNodeType_Print, token.Text, token.Span)
+	s.expect(reader, TokenType_Left_Paren, DiagnosticCode_ExpectedLeftParen, "expecting left parenthesis after print")
+	child := s.expression(reader)
+	if child == nil {
+		s.fail(reader, DiagnosticCode_ExpectedExpression, "expecting an expression to print")
+	}
+	node.AddChild(*child)
+	s.expect(reader, TokenType_Right_Paren, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
+	end := s.expect(reader, TokenType_SemiColon, DiagnosticCode_ExpectedSemiColon, "invalid statement, expecting semicolon")
+	node.SetSpan(joinSpan(token.Span, end.Span))
+	return &node
+}
+
+func (s *SimpleParser) block(reader TokenReader) *ASTNoder {
+	start := reader.Read().Span
+	node := NewASTNoder(ASTNodeType_Block, "", start)
+	s.blockDepth++
+	defer func() { s.blockDepth-- }()
+	for {
+		token := reader.Peek()
+		if token == nil {
+			s.fail(reader, DiagnosticCode_ExpectedRightBrace, "expecting right brace")
 		}
+		if token.Type == TokenType_Right_Brace {
+			reader.Read()
+			node.SetSpan(joinSpan(start, token.Span))
+			return &node
+		}
+		node.AddChild(s.statementOrError(reader))
 	}
-	if node != nil {
-		return &node
+}
+
+// condition parses the parenthesized condition of if and while.
+func (s *SimpleParser) condition(reader TokenReader, keyword string) ASTNoder {
+	s.expect(reader, TokenType_Left_Paren, DiagnosticCode_ExpectedLeftParen, "expecting left parenthesis after "+keyword)
+	child := s.expression(reader)
+	if child == nil {
+		s.fail(reader, DiagnosticCode_ExpectedExpression, "expecting a condition after "+keyword)
 	}
-	return child1
+	s.expect(reader, TokenType_Right_Paren, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
+	return *child
+}
+
+// body parses the statement controlled by if, else, while or for.
+func (s *SimpleParser) body(reader TokenReader, keyword string) ASTNoder {
+	child := s.statement(reader)
+	if child == nil {
+		s.fail(reader, DiagnosticCode_ExpectedStatement, "expecting a statement after "+keyword)
+	}
+	return *child
+}
+
+func (s *SimpleParser) loopBody(reader TokenReader, keyword string) ASTNoder {
+	s.loopDepth++
+	defer func() { s.loopDepth-- }()
+	return s.body(reader, keyword)
+}
+
+func (s *SimpleParser) ifStatement(reader TokenReader) *ASTNoder {
+	token := reader.Read()
+	node := NewASTNoder(ASTNodeType_If, token.Text, token.Span)
+	node.AddChild(s.condition(reader, token.Text))
+	child := s.body(reader, token.Text)
+	node.AddChild(child)
+	token = reader.Peek()
+	if token != nil && token.Type == TokenType_Else {
+		reader.Read()
+		child = s.body(reader, token.Text)
+		node.AddChild(child)
+	}
+	node.SetSpan(joinSpan(node.GetSpan(), child.GetSpan()))
+	return &node
 }
 
-func (s *SimpleParser) additive(reader TokenReader) *ASTNoder {
-	child1 := s.multiplicative(reader)
+func (s *SimpleParser) whileStatement(reader TokenReader) *ASTNoder {
+	token := reader.Read()
+	node := NewASTNoder(ASTNodeType_While, token.Text, token.Span)
+	node.AddChild(s.condition(reader, token.Text))
+	child := s.loopBody(reader, token.Text)
+	node.AddChild(child)
+	node.SetSpan(joinSpan(node.GetSpan(), child.GetSpan()))
+	return &node
+}
+
+// forStatement always gives the For node four children: init, condition,
+// step and body. Missing parts are Empty nodes.
+func (s *SimpleParser) forStatement(reader TokenReader) *ASTNoder {
+	token := reader.Read()
+	node := NewASTNoder(ASTNodeType_For, token.Text, token.Span)
+	s.expect(reader, TokenType_Left_Paren, DiagnosticCode_ExpectedLeftParen, "expecting left parenthesis after for")
+
+	init := s.declaration(reader)
+	if init == nil {
+		init = s.assignmentStatement(reader)
+	}
+	if init == nil {
+		init = s.empty(reader)
+		s.expect(reader, TokenType_SemiColon, DiagnosticCode_ExpectedSemiColon, "expecting declaration, assignment or semicolon in for")
+	}
+	node.AddChild(*init)
+
+	condition := s.expression(reader)
+	if condition == nil {
+		condition = s.empty(reader)
+	}
+	node.AddChild(*condition)
+	s.expect(reader, TokenType_SemiColon, DiagnosticCode_ExpectedSemiColon, "invalid for condition, expecting semicolon")
+
+	step := s.assignment(reader)
+	if step == nil {
+		step = s.expression(reader)
+	}
+	if step == nil {
+		step = s.empty(reader)
+	}
+	node.AddChild(*step)
+	s.expect(reader, TokenType_Right_Paren, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
+
+	child := s.loopBody(reader, token.Text)
+	node.AddChild(child)
+	node.SetSpan(joinSpan(node.GetSpan(), child.GetSpan()))
+	return &node
+}
+
+func (s *SimpleParser) empty(reader TokenReader) *ASTNoder {
+	span := currentSpan(reader)
+	node := NewASTNoder(ASTNodeType_Empty, "", Span{Start: span.Start, End: span.Start})
+	return &node
+}
+
+func (s *SimpleParser) jumpStatement(reader TokenReader) *ASTNoder {
+	token := reader.Peek()
+	if s.loopDepth == 0 {
+		s.fail(reader, DiagnosticCode_JumpOutsideLoop, token.Text+" outside of a loop")
+	}
+	reader.Read()
+	nodeType := ASTNodeType_Break
+	if token.Type == TokenType_Continue {
+		nodeType = ASTNodeType_Continue
+	}
+	end := s.expect(reader, TokenType_SemiColon, DiagnosticCode_ExpectedSemiColon, "invalid statement, expecting semicolon")
+	node := NewASTNoder(nodeType, token.Text, joinSpan(token.Span, end.Span))
+	return &node
+}
+
+func (s *SimpleParser) declaration(reader TokenReader) *ASTNoder {
 	var node ASTNoder
-	if child1 != nil {
-		for {
-			token := reader.Peek()
-			if token != nil && token.Type == TokenType_Plus {
-				token = reader.Read()
-				child2 := s.multiplicative(reader)
-				if child2 != nil {
-					node = NewASTNoder(ASTNodeType_AddtiveExp, token.Text)
-					node.AddChild(*child1)
-					node.AddChild(*child2)
-					*child1 = node
-				} else {
-					panic("invalid additive expression, expecting the right part.")
-				}
+	token := reader.Peek()
+	if token == nil {
+		return nil
+	}
+	nodeType, ok := declarationTypes[token.Type]
+	if !ok {
+		return nil
+	}
+	pos := reader.GetPosition()
+	start := reader.Read().Span
+	token = reader.Peek()
+	if token != nil && token.Type == TokenType_Id {
+		token = reader.Read()
+		node = NewASTNoder(nodeType, token.Text, joinSpan(start, token.Span))
+		token = reader.Peek()
+		if token != nil && token.Type == TokenType_Assignment {
+			reader.Read()
+			child := s.expression(reader)
+			if child == nil {
+				s.fail(reader, DiagnosticCode_ExpectedExpression, "invalide variable initialization, expecting an expression")
 			} else {
-				break
+				node.AddChild(*child)
 			}
 		}
+	} else if token != nil && token.Type == TokenType_Left_Paren {
+		// a conversion such as int(x) starting an expression statement
+		reader.setPosition(pos)
+		return nil
+	} else {
+		s.fail(reader, DiagnosticCode_ExpectedName, "variable name expected")
 	}
-	return child1
+	token = reader.Peek()
+	if token != nil && token.Type == TokenType_SemiColon {
+		reader.Read()
+		node.SetSpan(joinSpan(start, token.Span))
+	} else {
+		s.fail(reader, DiagnosticCode_ExpectedSemiColon, "invalid statement, expecting semicolon")
+	}
+	return &node
+}
+
+// expression parses an expression with the operators of s.Operators, or
+// simpleOperators if it is nil.
+func (s *SimpleParser) expression(reader TokenReader) *ASTNoder {
+	return s.operation(reader, 0)
 }
 
 func (s *SimpleParser) expressionStatement(reader TokenReader) *ASTNoder {
 	pos := reader.GetPosition()
-	node := s.additive(reader)
+	node := s.expression(reader)
 	if node != nil {
 		token := reader.Peek()
 		if token != nil && token.Type == TokenType_SemiColon {
@@ -249,33 +753,40 @@ func (s *SimpleParser) expressionStatement(reader TokenReader) *ASTNoder {
 }
 
 func (s *SimpleParser) assignmentStatement(reader TokenReader) *ASTNoder {
+	node := s.assignment(reader)
+	if node != nil {
+		token := s.expect(reader, TokenType_SemiColon, DiagnosticCode_ExpectedSemiColon, "invalid statement, expecting semicolon")
+		(*node).SetSpan(joinSpan((*node).GetSpan(), token.Span))
+	}
+	return node
+}
+
+func (s *SimpleParser) assignment(reader TokenReader) *ASTNoder {
 	pos := reader.GetPosition()
 	var node ASTNoder
 	token := reader.Peek()
 	if token != nil && token.Type == TokenType_Id {
 		token = reader.Read()
-		node = NewASTNoder(ASTNodeType_Assignment, token.Text)
+		node = NewASTNoder(ASTNodeType_Assignment, token.Text, token.Span)
 		token = reader.Peek()
 		if token != nil && token.Type == TokenType_Assignment {
 			reader.Read()
-			child := s.additive(reader)
+			child := s.expression(reader)
 			if child != nil {
 				node.AddChild(*child)
-				token = reader.Peek()
-				if token != nil && token.Type == TokenType_SemiColon {
-					reader.Read()
-				} else {
-					panic("invalid statement, expecting semicolon")
-				}
+				node.SetSpan(joinSpan(node.GetSpan(), (*child).GetSpan()))
 			} else {
-				panic("invalide assignment statement, expecting an expression")
+				s.fail(reader, DiagnosticCode_ExpectedExpression, "invalide assignment statement, expecting an expression")
 			}
 		} else {
 			reader.setPosition(pos)
 			return nil
 		}
 	}
-	return &node
+	if node != nil {
+		return &node
+	}
+	return nil
 }
 
 func (s *SimpleParser) primary(reader TokenReader) *ASTNoder {
@@ -283,47 +794,90 @@ func (s *SimpleParser) primary(reader TokenReader) *ASTNoder {
 	token := reader.Peek()
 	if token != nil {
 		switch token.Type {
-		case TokenType_IntLiteral:
+		case TokenType_IntLiteral, TokenType_CharLiteral:
+			reader.Read()
+			node = NewASTNoder(ASTNodeType_IntLiteral, token.Text, token.Span)
+			node.SetValue(token.Value)
+		case TokenType_FloatLiteral:
+			reader.Read()
+			node = NewASTNoder(ASTNodeType_FloatLiteral, token.Text, token.Span)
+			node.SetValue(token.Value)
+		case TokenType_StringLiteral:
 			reader.Read()
-			node = NewASTNoder(ASTNodeType_IntLiteral, token.Text)
+			node = NewASTNoder(ASTNodeType_StringLiteral, token.Text, token.Span)
+			node.SetValue(token.Value)
+		case TokenType_True, TokenType_False:
+			reader.Read()
+			node = NewASTNoder(ASTNodeType_BoolLiteral, token.Text, token.Span)
+			node.SetValue(BoolValue(token.Type == TokenType_True))
+		case TokenType_Int, TokenType_Float, TokenType_Bool, TokenType_String:
+			return s.conversion(reader)
 		case TokenType_Id:
 			reader.Read()
-			node = NewASTNoder(ASTNodeType_Identifier, token.Text)
+			next := reader.Peek()
+			if next != nil && next.Type == TokenType_Left_Paren {
+				return s.functionCall(reader, token)
+			}
+			node = NewASTNoder(ASTNodeType_Identifier, token.Text, token.Span)
 		case TokenType_Left_Paren:
 			reader.Read()
-			node := s.additive(reader)
-			if node != nil {
+			child := s.expression(reader)
+			if child != nil {
 				token := reader.Peek()
 				if token != nil && token.Type == TokenType_Right_Paren {
 					reader.Read()
+					node = *child
 				} else {
-					panic("expecting right parenthesis")
+					s.fail(reader, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
 				}
 			} else {
-				panic("expecting an additive expression inside parenthesis")
+				s.fail(reader, DiagnosticCode_ExpectedExpression, "expecting an expression inside parenthesis")
 			}
 		}
 	}
+	if node != nil {
+		return &node
+	}
+	return nil
+}
+
+// conversion parses an explicit conversion such as float(x).
+func (s *SimpleParser) conversion(reader TokenReader) *ASTNoder {
+	token := reader.Read()
+	node := NewASTNoder(ASTNodeType_Conversion, token.Text, token.Span)
+	node.SetDataType(typeKeywords[token.Type])
+	s.expect(reader, TokenType_Left_Paren, DiagnosticCode_ExpectedLeftParen, "expecting left parenthesis after "+token.Text)
+	child := s.expression(reader)
+	if child == nil {
+		s.fail(reader, DiagnosticCode_ExpectedExpression, "expecting an expression to convert")
+	}
+	node.AddChild(*child)
+	end := s.expect(reader, TokenType_Right_Paren, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
+	node.SetSpan(joinSpan(token.Span, end.Span))
 	return &node
 }
 
-func (s *SimpleParser) multiplicative(reader TokenReader) *ASTNoder {
-	child1 := s.primary(reader)
-	var node ASTNoder
-	token := reader.Peek()
-	if child1 != nil && token != nil {
-		if token.Type == TokenType_Star {
-			token = reader.Read()
-			child2 := s.primary(reader)
-			if child2 != nil {
-				node = NewASTNoder(ASTNodeType_Multiplicative, token.Text)
-				node.AddChild(*child1)
-				node.AddChild(*child2)
-				child1 = &node
-			} else {
-				panic("invalid multiplicative expression, expecting the right part.")
+// functionCall parses the argument list of a call to the function named by
+// token, which has already been read.
+func (s *SimpleParser) functionCall(reader TokenReader, token *Token) *ASTNoder {
+	node := NewASTNoder(ASTNodeType_FunctionCall, token.Text, token.Span)
+	reader.Read()
+	next := reader.Peek()
+	if next != nil && next.Type != TokenType_Right_Paren {
+		for {
+			child := s.expression(reader)
+			if child == nil {
+				s.fail(reader, DiagnosticCode_ExpectedExpression, "expecting an argument expression")
+			}
+			node.AddChild(*child)
+			next = reader.Peek()
+			if next == nil || next.Type != TokenType_Comma {
+				break
 			}
+			reader.Read()
 		}
 	}
-	return child1
+	end := s.expect(reader, TokenType_Right_Paren, DiagnosticCode_ExpectedRightParen, "expecting right parenthesis")
+	node.SetSpan(joinSpan(token.Span, end.Span))
+	return &node
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sexpr writes node as a parenthesized expression: an operator node is its
+// text followed by its operands, a leaf is its text.
+func sexpr(node ASTNoder) string {
+	children := node.GetChildren()
+	if len(children) == 0 {
+		return node.GetText()
+	}
+	parts := []string{node.GetText()}
+	for _, child := range children {
+		parts = append(parts, sexpr(child))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// checkScript parses, resolves and type checks script and returns its tree.
+func checkScript(t *testing.T, script string) ASTNoder {
+	t.Helper()
+	parser := SimpleParser{Origin: NewPosition()}
+	root, err := parser.Parse(script)
+	if err != nil {
+		t.Fatalf("%s: %v", script, err)
+	}
+	diagnostics := NewResolver().Resolve(*root)
+	diagnostics = append(diagnostics, NewTypeChecker().Check(*root)...)
+	if diagnostics.HasErrors() {
+		t.Fatalf("%s: %v", script, diagnostics)
+	}
+	return *root
+}
+
+// runScript parses, resolves, type checks and runs script, and returns its
+// tree and the value of its last statement.
+func runScript(t *testing.T, script string) (ASTNoder, Value) {
+	t.Helper()
+	root := checkScript(t, script)
+	interpreter := NewSimpleScript(false)
+	interpreter.Echo = false
+	result, err := interpreter.Evaluate(root, "")
+	if err != nil {
+		t.Fatalf("%s: %v", script, err)
+	}
+	return root, result
+}
+
+func TestArithmeticPrecedenceAndAssociativity(t *testing.T) {
+	tests := []struct {
+		expression string
+		tree       string
+		value      int
+	}{
+		{"a - b - c", "(- (- a b) c)", 10},
+		{"a / b / c", "(/ (/ a b) c)", 0},
+		{"a * b * c", "(* (* a b) c)", 480},
+		{"a % b % c", "(% (% a b) c)", 2},
+		{"a + b - c", "(- (+ a b) c)", 22},
+		{"a - b + c", "(+ (- a b) c)", 18},
+		{"a / b * c", "(* (/ a b) c)", 12},
+		{"a * b % c", "(% (* a b) c)", 0},
+		{"a + b * c", "(+ a (* b c))", 44},
+		{"a * b + c", "(+ (* a b) c)", 124},
+		{"a - b % c", "(- a (% b c))", 18},
+		{"(a + b) * c", "(* (+ a b) c)", 104},
+		{"a - (b - c)", "(- a (- b c))", 18},
+		{"-a", "(- a)", -20},
+		{"+a", "(+ a)", 20},
+		{"- -a", "(- (- a))", 20},
+		{"-a * b", "(* (- a) b)", -120},
+		{"-a - -b", "(- (- a) (- b))", -14},
+		{"+a - b", "(- (+ a) b)", 14},
+		{"a * -b + c", "(+ (* a (- b)) c)", -116},
+		{"a + b * c - a / c % b", "(- (+ a (* b c)) (% (/ a c) b))", 39},
+		{"a < b + c == b * c > a", "(== (< a (+ b c)) (> (* b c) a))", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			root, result := runScript(t, "int a = 20; int b = 6; int c = 4; "+test.expression+";")
+			children := root.GetChildren()
+			if tree := sexpr(children[len(children)-1]); tree != test.tree {
+				t.Errorf("tree %s, want %s", tree, test.tree)
+			}
+			if result.Type == DataType_Bool {
+				if result.Bool != (test.value != 0) {
+					t.Errorf("value %v, want %v", result.Bool, test.value != 0)
+				}
+			} else if result.Type != DataType_Int || result.Int != test.value {
+				t.Errorf("value %v, want %d", result, test.value)
+			}
+		})
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	script := "int a = ;\nint b = 1 +;\n{ int x = 1 + }\nint c = 2\nprint(1;\nfloat = 3;\nprint(c);\n"
+	parser := SimpleParser{Origin: NewPosition()}
+	root, err := parser.Parse(script)
+	diagnostics, _ := err.(Diagnostics)
+	var codes []DiagnosticCode
+	for _, d := range diagnostics {
+		codes = append(codes, d.Code)
+	}
+	want := []DiagnosticCode{DiagnosticCode_ExpectedExpression, DiagnosticCode_ExpectedExpression, DiagnosticCode_ExpectedExpression,
+		DiagnosticCode_ExpectedSemiColon, DiagnosticCode_ExpectedRightParen, DiagnosticCode_ExpectedName}
+	if fmt.Sprint(codes) != fmt.Sprint(want) {
+		t.Fatalf("errors %v, want %v", codes, want)
+	}
+	// The missing semicolon is reported at the end of its line.
+	if got := diagnostics[3].Span.Start; got.Line != 4 || got.Column != 10 {
+		t.Errorf("missing semicolon reported at %s, want 4:10", got)
+	}
+	var types []ASTNodeType
+	for _, child := range (*root).GetChildren() {
+		types = append(types, child.GetType())
+	}
+	wantTypes := []ASTNodeType{ASTNodeType_Error, ASTNodeType_Error, ASTNodeType_Block, ASTNodeType_Error,
+		ASTNodeType_Error, ASTNodeType_Error, ASTNodeType_Print}
+	if fmt.Sprint(types) != fmt.Sprint(wantTypes) {
+		t.Errorf("statements %v, want %v", types, wantTypes)
+	}
+	// Recovery in the block stops at its closing brace.
+	block := (*root).GetChildren()[2].GetChildren()
+	if len(block) != 1 || block[0].GetType() != ASTNodeType_Error {
+		t.Errorf("block holds %v, want one error", block)
+	}
+}
+
+func TestMissingSemicolonOnTheSameLine(t *testing.T) {
+	// Without a line break, the next statement is skipped with the rest of
+	// the line, up to its semicolon, and its errors are not reported.
+	parser := SimpleParser{Origin: NewPosition()}
+	_, err := parser.Parse("int c = 2 print(1;\nprint(c);")
+	diagnostics, _ := err.(Diagnostics)
+	if len(diagnostics) != 1 || diagnostics[0].Code != DiagnosticCode_ExpectedSemiColon {
+		t.Errorf("got %v, want only the missing semicolon", err)
+	}
+}
@@ -1,93 +1,248 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
+import "fmt"
+
+type ControlFlow int
+
+const (
+	ControlFlow_Normal ControlFlow = iota
+	ControlFlow_Break
+	ControlFlow_Continue
+	ControlFlow_Return
 )
 
+const DefaultMaxCallDepth = 1000
+
+// Frame is the activation record of one function call, with one slot per
+// parameter and local variable of the function as numbered by the Resolver.
+type Frame struct {
+	function    *Symbol
+	locals      []Value
+	returnValue Value
+}
+
+// SimpleScript runs an AST that has been annotated by a Resolver and a
+// TypeChecker. Globals are kept between calls to Evaluate.
 type SimpleScript struct {
-	variables map[string]int
-	verbose   bool
+	globals      []Value
+	frames       []*Frame
+	flow         ControlFlow
+	MaxCallDepth int
+	// Echo prints declarations and the result of top-level statements, as
+	// the REPL does.
+	Echo    bool
+	verbose bool
 }
 
 func NewSimpleScript(verbose bool) *SimpleScript {
 	return &SimpleScript{
-		variables: make(map[string]int),
-		verbose:   verbose,
+		MaxCallDepth: DefaultMaxCallDepth,
+		Echo:         true,
+		verbose:      verbose,
+	}
+}
+
+// slot returns the storage of the variable or parameter symbol.
+func (s *SimpleScript) slot(symbol *Symbol) *Value {
+	if symbol.IsGlobal() {
+		for len(s.globals) <= symbol.Index {
+			s.globals = append(s.globals, Value{})
+		}
+		return &s.globals[symbol.Index]
+	}
+	return &s.frames[len(s.frames)-1].locals[symbol.Index]
+}
+
+// symbolOf returns the symbol bound to node, failing if the tree has not
+// been resolved.
+func (s *SimpleScript) symbolOf(node ASTNoder) *Symbol {
+	symbol := node.GetSymbol()
+	if symbol == nil {
+		s.fail(node, DiagnosticCode_UnknownVariable, "unresolved name: "+node.GetText())
 	}
+	return symbol
+}
+
+// Evaluate runs node. Runtime errors are reported as a *Diagnostic.
+func (s *SimpleScript) Evaluate(node ASTNoder, indent string) (result Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			result, err = Value{}, d
+			s.frames, s.flow = nil, ControlFlow_Normal
+		}
+	}()
+	return s.evaluate(node, indent), nil
 }
 
-func (s *SimpleScript) Evaluate(node ASTNoder, indent string) int {
-	result := 0
+func (s *SimpleScript) fail(node ASTNoder, code DiagnosticCode, message string) {
+	panic(NewDiagnostic(Severity_Error, code, message, node.GetSpan()))
+}
+
+func (s *SimpleScript) evaluate(node ASTNoder, indent string) Value {
+	var result Value
 	if s.verbose {
 		fmt.Printf("%sCalculating:%s\n", indent, node.GetType())
 	}
 	switch node.GetType() {
 	case ASTNodeType_Program:
 		for _, n := range node.GetChildren() {
-			result = s.Evaluate(n, indent)
+			result = s.evaluate(n, indent)
 		}
-	case ASTNodeType_AddtiveExp:
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
 		child1 := node.GetChildren()[0]
-		value1 := s.Evaluate(child1, indent+"\t")
+		value1 := s.evaluate(child1, indent+"\t")
 		child2 := node.GetChildren()[1]
-		value2 := s.Evaluate(child2, indent+"\t")
-		if node.GetText() == "+" {
-			result = value1 + value2
-		} else {
-			result = value1 - value2
+		value2 := s.evaluate(child2, indent+"\t")
+		var err error
+		if result, err = binaryOp(node.GetText(), value1, value2); err == errDivisionByZero {
+			s.fail(node, DiagnosticCode_DivisionByZero, err.Error())
+		} else if err != nil {
+			s.fail(node, DiagnosticCode_InvalidNode, err.Error())
 		}
-	case ASTNodeType_IntLiteral:
-		result, _ = strconv.Atoi(node.GetText())
-	case ASTNodeType_Multiplicative:
-		child1 := node.GetChildren()[0]
-		value1 := s.Evaluate(child1, indent+"\t")
-		child2 := node.GetChildren()[1]
-		value2 := s.Evaluate(child2, indent+"\t")
-		if node.GetText() == "*" {
-			result = value1 * value2
-		} else {
-			result = value1 / value2
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		result = node.GetValue()
+	case ASTNodeType_LogicalAnd:
+		result = s.evaluate(node.GetChildren()[0], indent+"\t")
+		if result.Bool {
+			result = s.evaluate(node.GetChildren()[1], indent+"\t")
 		}
-	case ASTNodeType_Identifier:
-		varName := node.GetText()
-		if v, ok := s.variables[varName]; !ok {
-			panic("unknown variable: " + varName)
-		} else {
-			result = v
+	case ASTNodeType_LogicalOr:
+		result = s.evaluate(node.GetChildren()[0], indent+"\t")
+		if !result.Bool {
+			result = s.evaluate(node.GetChildren()[1], indent+"\t")
+		}
+	case ASTNodeType_Unary:
+		result = unaryOp(node.GetText(), s.evaluate(node.GetChildren()[0], indent+"\t"))
+	case ASTNodeType_Conversion:
+		var err error
+		result, err = convertValue(s.evaluate(node.GetChildren()[0], indent+"\t"), node.GetDataType())
+		if err != nil {
+			s.fail(node, DiagnosticCode_ConversionError, err.Error())
 		}
+	case ASTNodeType_Identifier:
+		result = *s.slot(s.symbolOf(node))
 	case ASTNodeType_Assignment:
-		varName := node.GetText()
-		if _, ok := s.variables[varName]; !ok {
-			panic("unknown variable: " + varName)
-		} else {
-			s.variables[varName] = s.Evaluate(node.GetChildren()[0], indent+"\t")
+		symbol := s.symbolOf(node)
+		result = s.evaluate(node.GetChildren()[0], indent+"\t")
+		*s.slot(symbol) = result
+	case ASTNodeType_Block:
+		for _, n := range node.GetChildren() {
+			s.evaluate(n, indent+"\t")
+			if s.flow != ControlFlow_Normal {
+				break
+			}
+		}
+	case ASTNodeType_If:
+		children := node.GetChildren()
+		if s.evaluate(children[0], indent+"\t").Bool {
+			s.evaluate(children[1], indent+"\t")
+		} else if len(children) > 2 {
+			s.evaluate(children[2], indent+"\t")
+		}
+	case ASTNodeType_While:
+		children := node.GetChildren()
+		for s.evaluate(children[0], indent+"\t").Bool {
+			s.evaluate(children[1], indent+"\t")
+			if s.endOfIteration() {
+				break
+			}
+		}
+	case ASTNodeType_For:
+		children := node.GetChildren()
+		s.evaluate(children[0], indent+"\t")
+		for children[1].GetType() == ASTNodeType_Empty || s.evaluate(children[1], indent+"\t").Bool {
+			s.evaluate(children[3], indent+"\t")
+			if s.endOfIteration() {
+				break
+			}
+			s.evaluate(children[2], indent+"\t")
+		}
+	case ASTNodeType_Break:
+		s.flow = ControlFlow_Break
+	case ASTNodeType_Continue:
+		s.flow = ControlFlow_Continue
+	case ASTNodeType_FunctionCall:
+		result = s.call(node, indent)
+	case ASTNodeType_Return:
+		if len(node.GetChildren()) > 0 {
+			s.frames[len(s.frames)-1].returnValue = s.evaluate(node.GetChildren()[0], indent+"\t")
 		}
-	case ASTNodeType_IntDeclaration:
+		s.flow = ControlFlow_Return
+	case ASTNodeType_Print:
+		fmt.Println(s.evaluate(node.GetChildren()[0], indent+"\t"))
+	case ASTNodeType_Error:
+		s.fail(node, DiagnosticCode_InvalidNode, "cannot evaluate a statement with syntax errors")
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
 		varName := node.GetText()
-		varValue := 0
+		varValue := zeroValue(declaredType(node))
 		if len(node.GetChildren()) > 0 {
 			child := node.GetChildren()[0]
-			result := s.Evaluate(child, indent+"\t")
+			result := s.evaluate(child, indent+"\t")
 			varValue = result
 		}
-		s.variables[varName] = varValue
-		fmt.Println("varName: ", varName, "  varValue: ", s.variables[varName])
+		*s.slot(s.symbolOf(node)) = varValue
+		if s.Echo {
+			fmt.Println("varName: ", varName, "  varValue: ", varValue)
+		}
 		result = varValue
 	}
 
 	if s.verbose {
 		fmt.Println(indent, "result:", result)
-	} else if indent == "" {
+	} else if indent == "" && s.Echo {
 		switch node.GetType() {
-		case ASTNodeType_IntDeclaration:
+		case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
 			fallthrough
 		case ASTNodeType_Assignment:
 			fmt.Println(node.GetText(), "result:", result)
-		case ASTNodeType_Program:
+		case ASTNodeType_Program, ASTNodeType_Block, ASTNodeType_If, ASTNodeType_While, ASTNodeType_For, ASTNodeType_FunctionDeclaration, ASTNodeType_Print:
 		default:
-			fmt.Println("result:", result)
+			if result.Type != "" && result.Type != DataType_Void {
+				fmt.Println("result:", result)
+			}
 		}
 	}
 	return result
 }
+
+// call evaluates the arguments in the caller's frame, then runs the function
+// body in a new frame.
+func (s *SimpleScript) call(node ASTNoder, indent string) Value {
+	function := s.symbolOf(node)
+	args := node.GetChildren()
+	if function.Kind != SymbolKind_Function || len(args) != len(function.Params) {
+		s.fail(node, DiagnosticCode_UnknownFunction, "invalid call of "+node.GetText())
+	}
+	if len(s.frames) >= s.MaxCallDepth {
+		s.fail(node, DiagnosticCode_StackOverflow, fmt.Sprintf("stack overflow: call depth exceeds %d", s.MaxCallDepth))
+	}
+	frame := &Frame{function: function, locals: make([]Value, function.Locals), returnValue: zeroValue(function.Type)}
+	for i, arg := range args {
+		frame.locals[function.Params[i].Index] = s.evaluate(arg, indent+"\t")
+	}
+	children := function.Decl.GetChildren()
+	s.frames = append(s.frames, frame)
+	s.evaluate(children[len(children)-1], indent+"\t")
+	s.frames = s.frames[:len(s.frames)-1]
+	s.flow = ControlFlow_Normal
+	return frame.returnValue
+}
+
+// endOfIteration consumes a pending break or continue at the end of a loop
+// body and reports whether the loop has to stop. A pending return is left
+// for the enclosing call.
+func (s *SimpleScript) endOfIteration() bool {
+	switch s.flow {
+	case ControlFlow_Return:
+		return true
+	case ControlFlow_Break:
+		s.flow = ControlFlow_Normal
+		return true
+	}
+	s.flow = ControlFlow_Normal
+	return false
+}
@@ -0,0 +1,342 @@
+package main
+
+import "fmt"
+
+type irRegSet map[*IRReg]bool
+
+// computeEdges sets the Preds and Succs of the blocks of f from their
+// terminators.
+func computeEdges(f *IRFunction) {
+	for _, b := range f.Blocks {
+		b.Preds, b.Succs = nil, nil
+	}
+	for _, b := range f.Blocks {
+		if t := b.Terminator(); t != nil {
+			for _, s := range t.Blocks {
+				b.Succs = append(b.Succs, s)
+				s.Preds = append(s.Preds, b)
+			}
+		}
+	}
+}
+
+// removeUnreachable drops the blocks that cannot be reached from the entry,
+// puts the others in reverse postorder, numbers them and recomputes the
+// edges. Phis lose their incoming values from the dropped blocks.
+func removeUnreachable(f *IRFunction) {
+	f.Blocks = reversePostorder(f)
+	reachable := make(map[*IRBlock]bool)
+	for i, b := range f.Blocks {
+		b.ID = i
+		reachable[b] = true
+	}
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Op != IROp_Phi {
+				continue
+			}
+			args, preds := instr.Args[:0], instr.Blocks[:0]
+			for i, pred := range instr.Blocks {
+				if reachable[pred] {
+					args, preds = append(args, instr.Args[i]), append(preds, pred)
+				}
+			}
+			instr.Args, instr.Blocks = args, preds
+		}
+	}
+	computeEdges(f)
+}
+
+// reversePostorder returns the blocks reachable from the entry, each one
+// before its successors except along back edges.
+func reversePostorder(f *IRFunction) []*IRBlock {
+	visited := make(map[*IRBlock]bool)
+	var order []*IRBlock
+	var visit func(b *IRBlock)
+	visit = func(b *IRBlock) {
+		visited[b] = true
+		if t := b.Terminator(); t != nil {
+			for _, s := range t.Blocks {
+				if !visited[s] {
+					visit(s)
+				}
+			}
+		}
+		order = append(order, b)
+	}
+	visit(f.Blocks[0])
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// computeDominators sets the Idom of every block with the iterative
+// algorithm of Cooper, Harvey and Kennedy. The edges must be up to date.
+func computeDominators(f *IRFunction) {
+	order := reversePostorder(f)
+	index := make(map[*IRBlock]int, len(order))
+	for i, b := range order {
+		index[b] = i
+		b.Idom = nil
+	}
+	entry := order[0]
+	entry.Idom = entry
+	intersect := func(a, b *IRBlock) *IRBlock {
+		for a != b {
+			for index[a] > index[b] {
+				a = a.Idom
+			}
+			for index[b] > index[a] {
+				b = b.Idom
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range order[1:] {
+			var idom *IRBlock
+			for _, pred := range b.Preds {
+				if pred.Idom == nil {
+					continue
+				}
+				if idom == nil {
+					idom = pred
+				} else {
+					idom = intersect(pred, idom)
+				}
+			}
+			if b.Idom != idom {
+				b.Idom = idom
+				changed = true
+			}
+		}
+	}
+	entry.Idom = nil
+}
+
+// dominates tells whether every path from the entry to b goes through a.
+func dominates(a, b *IRBlock) bool {
+	for ; b != nil; b = b.Idom {
+		if b == a {
+			return true
+		}
+	}
+	return false
+}
+
+// dominatorTree returns the blocks immediately dominated by each block.
+func dominatorTree(f *IRFunction) map[*IRBlock][]*IRBlock {
+	children := make(map[*IRBlock][]*IRBlock)
+	for _, b := range f.Blocks {
+		if b.Idom != nil {
+			children[b.Idom] = append(children[b.Idom], b)
+		}
+	}
+	return children
+}
+
+// dominanceFrontiers returns for each block the blocks where its dominance
+// ends: those with a predecessor it dominates but which it does not
+// strictly dominate.
+func dominanceFrontiers(f *IRFunction) map[*IRBlock][]*IRBlock {
+	frontiers := make(map[*IRBlock][]*IRBlock)
+	for _, b := range f.Blocks {
+		if len(b.Preds) < 2 {
+			continue
+		}
+		for _, pred := range b.Preds {
+			for runner := pred; runner != nil && runner != b.Idom; runner = runner.Idom {
+				if n := len(frontiers[runner]); n == 0 || frontiers[runner][n-1] != b {
+					frontiers[runner] = append(frontiers[runner], b)
+				}
+			}
+		}
+	}
+	return frontiers
+}
+
+// irLiveness holds the registers live on entry to and on exit from each
+// block. The argument of a phi is live on exit from its predecessor, not on
+// entry to the block of the phi.
+type irLiveness struct {
+	in  map[*IRBlock]irRegSet
+	out map[*IRBlock]irRegSet
+}
+
+func computeLiveness(f *IRFunction) *irLiveness {
+	uses := make(map[*IRBlock]irRegSet)
+	defs := make(map[*IRBlock]irRegSet)
+	phiUses := make(map[*IRBlock]irRegSet)
+	for _, b := range f.Blocks {
+		uses[b], defs[b] = make(irRegSet), make(irRegSet)
+		for _, instr := range b.Instrs {
+			for i, arg := range instr.Args {
+				r, ok := arg.(*IRReg)
+				if !ok {
+					continue
+				}
+				if instr.Op == IROp_Phi {
+					pred := instr.Blocks[i]
+					if phiUses[pred] == nil {
+						phiUses[pred] = make(irRegSet)
+					}
+					phiUses[pred][r] = true
+				} else if !defs[b][r] {
+					uses[b][r] = true
+				}
+			}
+			if instr.Dest != nil {
+				defs[b][instr.Dest] = true
+			}
+		}
+	}
+	live := &irLiveness{in: make(map[*IRBlock]irRegSet), out: make(map[*IRBlock]irRegSet)}
+	for _, b := range f.Blocks {
+		live.in[b], live.out[b] = make(irRegSet), make(irRegSet)
+	}
+	order := reversePostorder(f)
+	for changed := true; changed; {
+		changed = false
+		for i := len(order) - 1; i >= 0; i-- {
+			b := order[i]
+			out := live.out[b]
+			for _, s := range b.Succs {
+				for r := range live.in[s] {
+					out[r] = true
+				}
+			}
+			for r := range phiUses[b] {
+				out[r] = true
+			}
+			in := live.in[b]
+			for r := range uses[b] {
+				if !in[r] {
+					in[r], changed = true, true
+				}
+			}
+			for r := range out {
+				if !defs[b][r] && !in[r] {
+					in[r], changed = true, true
+				}
+			}
+		}
+	}
+	return live
+}
+
+// constructSSA puts f in SSA form. Phis are placed on the iterated
+// dominance frontiers of the definitions of a register where it is live,
+// then the registers defined more than once are renamed to one version per
+// definition while walking the dominator tree.
+func constructSSA(f *IRFunction) {
+	computeDominators(f)
+	frontiers := dominanceFrontiers(f)
+	live := computeLiveness(f)
+
+	var regs []*IRReg
+	sites := make(map[*IRReg][]*IRBlock)
+	defCount := make(map[*IRReg]int)
+	define := func(r *IRReg, b *IRBlock) {
+		if _, ok := sites[r]; !ok {
+			regs = append(regs, r)
+		}
+		if n := len(sites[r]); n == 0 || sites[r][n-1] != b {
+			sites[r] = append(sites[r], b)
+		}
+		defCount[r]++
+	}
+	for _, param := range f.Params {
+		define(param, f.Blocks[0])
+	}
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Dest != nil {
+				define(instr.Dest, b)
+			}
+		}
+	}
+
+	phiReg := make(map[*IRInstr]*IRReg)
+	for _, r := range regs {
+		hasPhi := make(map[*IRBlock]bool)
+		queued := make(map[*IRBlock]bool)
+		work := append([]*IRBlock(nil), sites[r]...)
+		for _, b := range work {
+			queued[b] = true
+		}
+		for len(work) > 0 {
+			b := work[len(work)-1]
+			work = work[:len(work)-1]
+			for _, y := range frontiers[b] {
+				if hasPhi[y] || !live.in[y][r] {
+					continue
+				}
+				hasPhi[y] = true
+				phi := &IRInstr{Op: IROp_Phi, Dest: r, Args: make([]IRValue, len(y.Preds)), Blocks: append([]*IRBlock(nil), y.Preds...)}
+				y.Instrs = append([]*IRInstr{phi}, y.Instrs...)
+				phiReg[phi] = r
+				defCount[r]++
+				if !queued[y] {
+					queued[y] = true
+					work = append(work, y)
+				}
+			}
+		}
+	}
+
+	versions := make(map[*IRReg]int)
+	stacks := make(map[*IRReg][]*IRReg)
+	for _, param := range f.Params {
+		stacks[param] = []*IRReg{param}
+	}
+	current := func(r *IRReg) IRValue {
+		if defCount[r] < 2 {
+			return r
+		}
+		if stack := stacks[r]; len(stack) > 0 {
+			return stack[len(stack)-1]
+		}
+		// Not assigned on every path: the variable is never read there.
+		return IRConst{zeroValue(r.Type)}
+	}
+	children := dominatorTree(f)
+	var rename func(b *IRBlock)
+	rename = func(b *IRBlock) {
+		var pushed []*IRReg
+		for _, instr := range b.Instrs {
+			if instr.Op != IROp_Phi {
+				for i, arg := range instr.Args {
+					if r, ok := arg.(*IRReg); ok {
+						instr.Args[i] = current(r)
+					}
+				}
+			}
+			if r := instr.Dest; r != nil && defCount[r] > 1 {
+				versions[r]++
+				instr.Dest = &IRReg{Name: fmt.Sprintf("%s.%d", r.Name, versions[r]), Type: r.Type}
+				stacks[r] = append(stacks[r], instr.Dest)
+				pushed = append(pushed, r)
+			}
+		}
+		for _, s := range b.Succs {
+			for _, instr := range s.Instrs {
+				if r, ok := phiReg[instr]; ok {
+					for i, pred := range instr.Blocks {
+						if pred == b {
+							instr.Args[i] = current(r)
+						}
+					}
+				}
+			}
+		}
+		for _, child := range children[b] {
+			rename(child)
+		}
+		for _, r := range pushed {
+			stacks[r] = stacks[r][:len(stacks[r])-1]
+		}
+	}
+	rename(f.Blocks[0])
+}
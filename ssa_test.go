@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// lowerScript returns the IR of script, unoptimized.
+func lowerScript(t *testing.T, script string) *IRProgram {
+	t.Helper()
+	program, err := NewIRBuilder().Build(checkScript(t, script))
+	if err != nil {
+		t.Fatalf("%s: %v", script, err)
+	}
+	return program
+}
+
+// dumpFunction returns the text of the function name of p.
+func dumpFunction(p *IRProgram, name string) string {
+	var b strings.Builder
+	for _, f := range append(p.Functions, p.Main) {
+		if f.Name == name {
+			p.dumpFunction(&b, f)
+		}
+	}
+	return b.String()
+}
+
+// sumScript sums the multiples of 3 below n in a loop with a conditional
+// assignment: s and i need phis at the head of the loop and s one more
+// after the if.
+const sumScript = `int sum(int n) {
+	int s = 0;
+	int i = 0;
+	while (i < n) {
+		if (i % 3 == 0) s = s + i;
+		i = i + 1;
+	}
+	return s;
+}
+print(sum(10));
+`
+
+func TestConstructSSA(t *testing.T) {
+	want := `func sum(%n int) int {
+b0:
+	%s.1 int = copy 0
+	%i.1 int = copy 0
+	jump b1
+b1:	; preds b0, b5
+	%i.2 int = phi [b0, %i.1], [b5, %i.3]
+	%s.2 int = phi [b0, %s.1], [b5, %s.4]
+	%0 bool = lt %i.2, %n
+	branch %0, b3, b2
+b2:	; preds b1
+	return %s.2
+b3:	; preds b1
+	%1 int = mod %i.2, 3
+	%2 bool = eq %1, 0
+	branch %2, b4, b5
+b4:	; preds b3
+	%3 int = add %s.2, %i.2
+	%s.3 int = copy %3
+	jump b5
+b5:	; preds b3, b4
+	%s.4 int = phi [b3, %s.2], [b4, %s.3]
+	%4 int = add %i.2, 1
+	%i.3 int = copy %4
+	jump b1
+}
+`
+	if got := dumpFunction(lowerScript(t, sumScript), "sum"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// blockMap writes m as the blocks each block of f maps to.
+func blockMap(f *IRFunction, m map[*IRBlock][]*IRBlock) string {
+	var parts []string
+	for _, b := range f.Blocks {
+		if len(m[b]) == 0 {
+			continue
+		}
+		names := make([]string, len(m[b]))
+		for i, other := range m[b] {
+			names[i] = other.String()
+		}
+		parts = append(parts, b.String()+": "+strings.Join(names, " "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestDominance(t *testing.T) {
+	f := lowerScript(t, sumScript).Functions[0]
+	computeDominators(f)
+	if got, want := blockMap(f, dominatorTree(f)), "b0: b1, b1: b2 b3, b3: b4 b5"; got != want {
+		t.Errorf("dominator tree %s, want %s", got, want)
+	}
+	// The loop head is on the frontier of the blocks of the loop, itself
+	// included, and the join after the if on the one of its then block.
+	if got, want := blockMap(f, dominanceFrontiers(f)), "b1: b1, b3: b1, b4: b5, b5: b1"; got != want {
+		t.Errorf("dominance frontiers %s, want %s", got, want)
+	}
+}
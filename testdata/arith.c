@@ -0,0 +1,106 @@
+/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= 1000)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+
+static long long a;
+static long long b;
+static long long c;
+static long long m;
+
+int main(void) {
+	a = 20LL;
+	b = 6LL;
+	c = 4LL;
+	printf("%lld\n", ss_sub(ss_sub(a, b), c));
+	printf("%lld\n", ss_div(ss_div(a, b, "2:25: error[E0202]: division by zero\n"), c, "2:25: error[E0202]: division by zero\n"));
+	printf("%lld\n", ss_mul(ss_mul(a, b), c));
+	printf("%lld\n", ss_mod(ss_mod(a, b, "2:61: error[E0202]: division by zero\n"), c, "2:61: error[E0202]: division by zero\n"));
+	printf("%lld\n", ss_add(a, ss_mul(b, c)));
+	printf("%lld\n", ss_mul(ss_add(a, b), c));
+	printf("%lld\n", ss_sub(a, ss_sub(b, c)));
+	printf("%lld\n", ss_mul(ss_neg(a), b));
+	printf("%lld\n", ss_neg(ss_neg(a)));
+	printf("%lld\n", ss_sub(+a, b));
+	printf("%lld\n", ss_div(ss_neg(7LL), 2LL, "4:7: error[E0202]: division by zero\n"));
+	printf("%lld\n", ss_mod(ss_neg(7LL), 3LL, "4:22: error[E0202]: division by zero\n"));
+	printf("%lld\n", ss_div(7LL, ss_neg(2LL), "4:37: error[E0202]: division by zero\n"));
+	printf("%lld\n", ss_mod(7LL, ss_neg(3LL), "4:52: error[E0202]: division by zero\n"));
+	printf("%lld\n", ss_add(ss_add(ss_add(31LL, 15LL), 5LL), 1000LL));
+	printf("%lld\n", ss_add(9223372036854775807LL, 1LL));
+	m = ss_sub(ss_neg(9223372036854775807LL), 1LL);
+	printf("%lld\n", m);
+	printf("%lld\n", ss_sub(m, 1LL));
+	printf("%lld\n", ss_mul(m, ss_neg(1LL)));
+	puts(a > b && b > c ? "true" : "false");
+	puts(a < b || b == 6LL ? "true" : "false");
+	puts(!(a == b) ? "true" : "false");
+	puts((a != b) == true ? "true" : "false");
+	printf("%lld\n", ss_add(97LL, 1LL));
+	return 0;
+}
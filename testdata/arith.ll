@@ -0,0 +1,237 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@"g0.a" = internal global i64 0
+@"g1.b" = internal global i64 0
+@"g2.c" = internal global i64 0
+@"g3.m" = internal global i64 0
+@.str.1 = private unnamed_addr constant [38 x i8] c"2:25: error[E0202]: division by zero\0A\00"
+@.str.2 = private unnamed_addr constant [38 x i8] c"2:25: error[E0202]: division by zero\0A\00"
+@.str.3 = private unnamed_addr constant [38 x i8] c"2:61: error[E0202]: division by zero\0A\00"
+@.str.4 = private unnamed_addr constant [38 x i8] c"2:61: error[E0202]: division by zero\0A\00"
+@.str.5 = private unnamed_addr constant [37 x i8] c"4:7: error[E0202]: division by zero\0A\00"
+@.str.6 = private unnamed_addr constant [38 x i8] c"4:22: error[E0202]: division by zero\0A\00"
+@.str.7 = private unnamed_addr constant [38 x i8] c"4:37: error[E0202]: division by zero\0A\00"
+@.str.8 = private unnamed_addr constant [38 x i8] c"4:52: error[E0202]: division by zero\0A\00"
+
+define i32 @main() {
+entry:
+  store i64 20, ptr @"g0.a"
+  store i64 6, ptr @"g1.b"
+  store i64 4, ptr @"g2.c"
+  %t1 = load i64, ptr @"g0.a"
+  %t2 = load i64, ptr @"g1.b"
+  %t3 = sub i64 %t1, %t2
+  %t4 = load i64, ptr @"g2.c"
+  %t5 = sub i64 %t3, %t4
+  %t6 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t5)
+  %t7 = load i64, ptr @"g0.a"
+  %t8 = load i64, ptr @"g1.b"
+  %t9 = icmp eq i64 %t8, 0
+  br i1 %t9, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.1, i64 37)
+  unreachable
+ok2:
+  %t10 = icmp eq i64 %t8, -1
+  %t11 = select i1 %t10, i64 1, i64 %t8
+  %t12 = sdiv i64 %t7, %t11
+  %t13 = sub i64 0, %t7
+  %t14 = select i1 %t10, i64 %t13, i64 %t12
+  %t15 = load i64, ptr @"g2.c"
+  %t16 = icmp eq i64 %t15, 0
+  br i1 %t16, label %error3, label %ok4
+error3:
+  call void @__runtime_error(ptr @.str.2, i64 37)
+  unreachable
+ok4:
+  %t17 = icmp eq i64 %t15, -1
+  %t18 = select i1 %t17, i64 1, i64 %t15
+  %t19 = sdiv i64 %t14, %t18
+  %t20 = sub i64 0, %t14
+  %t21 = select i1 %t17, i64 %t20, i64 %t19
+  %t22 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t21)
+  %t23 = load i64, ptr @"g0.a"
+  %t24 = load i64, ptr @"g1.b"
+  %t25 = mul i64 %t23, %t24
+  %t26 = load i64, ptr @"g2.c"
+  %t27 = mul i64 %t25, %t26
+  %t28 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t27)
+  %t29 = load i64, ptr @"g0.a"
+  %t30 = load i64, ptr @"g1.b"
+  %t31 = icmp eq i64 %t30, 0
+  br i1 %t31, label %error5, label %ok6
+error5:
+  call void @__runtime_error(ptr @.str.3, i64 37)
+  unreachable
+ok6:
+  %t32 = icmp eq i64 %t30, -1
+  %t33 = select i1 %t32, i64 1, i64 %t30
+  %t34 = srem i64 %t29, %t33
+  %t35 = select i1 %t32, i64 0, i64 %t34
+  %t36 = load i64, ptr @"g2.c"
+  %t37 = icmp eq i64 %t36, 0
+  br i1 %t37, label %error7, label %ok8
+error7:
+  call void @__runtime_error(ptr @.str.4, i64 37)
+  unreachable
+ok8:
+  %t38 = icmp eq i64 %t36, -1
+  %t39 = select i1 %t38, i64 1, i64 %t36
+  %t40 = srem i64 %t35, %t39
+  %t41 = select i1 %t38, i64 0, i64 %t40
+  %t42 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t41)
+  %t43 = load i64, ptr @"g0.a"
+  %t44 = load i64, ptr @"g1.b"
+  %t45 = load i64, ptr @"g2.c"
+  %t46 = mul i64 %t44, %t45
+  %t47 = add i64 %t43, %t46
+  %t48 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t47)
+  %t49 = load i64, ptr @"g0.a"
+  %t50 = load i64, ptr @"g1.b"
+  %t51 = add i64 %t49, %t50
+  %t52 = load i64, ptr @"g2.c"
+  %t53 = mul i64 %t51, %t52
+  %t54 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t53)
+  %t55 = load i64, ptr @"g0.a"
+  %t56 = load i64, ptr @"g1.b"
+  %t57 = load i64, ptr @"g2.c"
+  %t58 = sub i64 %t56, %t57
+  %t59 = sub i64 %t55, %t58
+  %t60 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t59)
+  %t61 = load i64, ptr @"g0.a"
+  %t62 = sub i64 0, %t61
+  %t63 = load i64, ptr @"g1.b"
+  %t64 = mul i64 %t62, %t63
+  %t65 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t64)
+  %t66 = load i64, ptr @"g0.a"
+  %t67 = sub i64 0, %t66
+  %t68 = sub i64 0, %t67
+  %t69 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t68)
+  %t70 = load i64, ptr @"g0.a"
+  %t71 = load i64, ptr @"g1.b"
+  %t72 = sub i64 %t70, %t71
+  %t73 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t72)
+  %t74 = sub i64 0, 7
+  %t75 = icmp eq i64 2, 0
+  br i1 %t75, label %error9, label %ok10
+error9:
+  call void @__runtime_error(ptr @.str.5, i64 36)
+  unreachable
+ok10:
+  %t76 = icmp eq i64 2, -1
+  %t77 = select i1 %t76, i64 1, i64 2
+  %t78 = sdiv i64 %t74, %t77
+  %t79 = sub i64 0, %t74
+  %t80 = select i1 %t76, i64 %t79, i64 %t78
+  %t81 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t80)
+  %t82 = sub i64 0, 7
+  %t83 = icmp eq i64 3, 0
+  br i1 %t83, label %error11, label %ok12
+error11:
+  call void @__runtime_error(ptr @.str.6, i64 37)
+  unreachable
+ok12:
+  %t84 = icmp eq i64 3, -1
+  %t85 = select i1 %t84, i64 1, i64 3
+  %t86 = srem i64 %t82, %t85
+  %t87 = select i1 %t84, i64 0, i64 %t86
+  %t88 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t87)
+  %t89 = sub i64 0, 2
+  %t90 = icmp eq i64 %t89, 0
+  br i1 %t90, label %error13, label %ok14
+error13:
+  call void @__runtime_error(ptr @.str.7, i64 37)
+  unreachable
+ok14:
+  %t91 = icmp eq i64 %t89, -1
+  %t92 = select i1 %t91, i64 1, i64 %t89
+  %t93 = sdiv i64 7, %t92
+  %t94 = sub i64 0, 7
+  %t95 = select i1 %t91, i64 %t94, i64 %t93
+  %t96 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t95)
+  %t97 = sub i64 0, 3
+  %t98 = icmp eq i64 %t97, 0
+  br i1 %t98, label %error15, label %ok16
+error15:
+  call void @__runtime_error(ptr @.str.8, i64 37)
+  unreachable
+ok16:
+  %t99 = icmp eq i64 %t97, -1
+  %t100 = select i1 %t99, i64 1, i64 %t97
+  %t101 = srem i64 7, %t100
+  %t102 = select i1 %t99, i64 0, i64 %t101
+  %t103 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t102)
+  %t104 = add i64 31, 15
+  %t105 = add i64 %t104, 5
+  %t106 = add i64 %t105, 1000
+  %t107 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t106)
+  %t108 = add i64 9223372036854775807, 1
+  %t109 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t108)
+  %t110 = sub i64 0, 9223372036854775807
+  %t111 = sub i64 %t110, 1
+  store i64 %t111, ptr @"g3.m"
+  %t112 = load i64, ptr @"g3.m"
+  %t113 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t112)
+  %t114 = load i64, ptr @"g3.m"
+  %t115 = sub i64 %t114, 1
+  %t116 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t115)
+  %t117 = load i64, ptr @"g3.m"
+  %t118 = sub i64 0, 1
+  %t119 = mul i64 %t117, %t118
+  %t120 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t119)
+  %t121 = load i64, ptr @"g0.a"
+  %t122 = load i64, ptr @"g1.b"
+  %t123 = icmp sgt i64 %t121, %t122
+  br i1 %t123, label %rhs17, label %endlogic18
+rhs17:
+  %t124 = load i64, ptr @"g1.b"
+  %t125 = load i64, ptr @"g2.c"
+  %t126 = icmp sgt i64 %t124, %t125
+  br label %endlogic18
+endlogic18:
+  %t127 = phi i1 [ false, %ok16 ], [ %t126, %rhs17 ]
+  %t128 = select i1 %t127, ptr @.str.true, ptr @.str.false
+  %t129 = call i32 (ptr, ...) @printf(ptr %t128)
+  %t130 = load i64, ptr @"g0.a"
+  %t131 = load i64, ptr @"g1.b"
+  %t132 = icmp slt i64 %t130, %t131
+  br i1 %t132, label %endlogic20, label %rhs19
+rhs19:
+  %t133 = load i64, ptr @"g1.b"
+  %t134 = icmp eq i64 %t133, 6
+  br label %endlogic20
+endlogic20:
+  %t135 = phi i1 [ true, %endlogic18 ], [ %t134, %rhs19 ]
+  %t136 = select i1 %t135, ptr @.str.true, ptr @.str.false
+  %t137 = call i32 (ptr, ...) @printf(ptr %t136)
+  %t138 = load i64, ptr @"g0.a"
+  %t139 = load i64, ptr @"g1.b"
+  %t140 = icmp eq i64 %t138, %t139
+  %t141 = xor i1 %t140, true
+  %t142 = select i1 %t141, ptr @.str.true, ptr @.str.false
+  %t143 = call i32 (ptr, ...) @printf(ptr %t142)
+  %t144 = load i64, ptr @"g0.a"
+  %t145 = load i64, ptr @"g1.b"
+  %t146 = icmp ne i64 %t144, %t145
+  %t147 = icmp eq i1 %t146, true
+  %t148 = select i1 %t147, ptr @.str.true, ptr @.str.false
+  %t149 = call i32 (ptr, ...) @printf(ptr %t148)
+  %t150 = add i64 97, 1
+  %t151 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t150)
+  ret i32 0
+}
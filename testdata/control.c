@@ -0,0 +1,132 @@
+/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= 1000)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+
+static long long x;
+static long long i;
+static long long k;
+
+int main(void) {
+	x = 3LL;
+	{
+		long long x = 5LL;
+		printf("%lld\n", x);
+	}
+	printf("%lld\n", x);
+	if (x > 4LL) {
+		printf("%lld\n", 1LL);
+	} else if (x > 2LL) {
+		printf("%lld\n", 2LL);
+	} else {
+		printf("%lld\n", 3LL);
+	}
+	if (x > 10LL) {
+		printf("%lld\n", 4LL);
+	}
+	i = 0LL;
+	while (i < 5LL) {
+		i = ss_add(i, 1LL);
+		if (i == 2LL) {
+			continue;
+		}
+		printf("%lld\n", i);
+	}
+	for (long long j = 0LL; j < 10LL; j = ss_add(j, 1LL)) {
+		if (ss_mod(j, 3LL, "8:42: error[E0202]: division by zero\n") == 0LL) {
+			continue;
+		}
+		if (j > 7LL) {
+			break;
+		}
+		printf("%lld\n", j);
+	}
+	k = 0LL;
+	for (;;) {
+		k = ss_add(k, 1LL);
+		if (k == 4LL) {
+			break;
+		}
+	}
+	printf("%lld\n", k);
+	for (long long j = 0LL; j < 3LL; j = ss_add(j, 1LL)) {
+		long long n = 0LL;
+		while (true) {
+			n = ss_add(n, 1LL);
+			if (n > j) {
+				break;
+			}
+		}
+		printf("%lld\n", n);
+	}
+	for (k = 0LL; k < 2LL; k = ss_add(k, 1LL)) {
+	}
+	(void)(ss_mul(k, 2LL));
+	printf("%lld\n", k);
+	return 0;
+}
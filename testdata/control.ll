@@ -0,0 +1,213 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@"g0.x" = internal global i64 0
+@"g1.x" = internal global i64 0
+@"g2.i" = internal global i64 0
+@"g3.j" = internal global i64 0
+@"g4.k" = internal global i64 0
+@"g5.j" = internal global i64 0
+@"g6.n" = internal global i64 0
+@.str.1 = private unnamed_addr constant [38 x i8] c"8:42: error[E0202]: division by zero\0A\00"
+
+define i32 @main() {
+entry:
+  store i64 3, ptr @"g0.x"
+  store i64 5, ptr @"g1.x"
+  %t1 = load i64, ptr @"g1.x"
+  %t2 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t1)
+  %t3 = load i64, ptr @"g0.x"
+  %t4 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t3)
+  %t5 = load i64, ptr @"g0.x"
+  %t6 = icmp sgt i64 %t5, 4
+  br i1 %t6, label %then1, label %else2
+then1:
+  %t7 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 1)
+  br label %endif3
+else2:
+  %t8 = load i64, ptr @"g0.x"
+  %t9 = icmp sgt i64 %t8, 2
+  br i1 %t9, label %then4, label %else5
+then4:
+  %t10 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 2)
+  br label %endif6
+else5:
+  %t11 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 3)
+  br label %endif6
+endif6:
+  br label %endif3
+endif3:
+  %t12 = load i64, ptr @"g0.x"
+  %t13 = icmp sgt i64 %t12, 10
+  br i1 %t13, label %then7, label %else8
+then7:
+  %t14 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 4)
+  br label %endif9
+else8:
+  br label %endif9
+endif9:
+  store i64 0, ptr @"g2.i"
+  br label %while10
+while10:
+  %t15 = load i64, ptr @"g2.i"
+  %t16 = icmp slt i64 %t15, 5
+  br i1 %t16, label %body11, label %endwhile12
+body11:
+  %t17 = load i64, ptr @"g2.i"
+  %t18 = add i64 %t17, 1
+  store i64 %t18, ptr @"g2.i"
+  %t19 = load i64, ptr @"g2.i"
+  %t20 = icmp eq i64 %t19, 2
+  br i1 %t20, label %then13, label %else14
+then13:
+  br label %while10
+dead16:
+  br label %endif15
+else14:
+  br label %endif15
+endif15:
+  %t21 = load i64, ptr @"g2.i"
+  %t22 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t21)
+  br label %while10
+endwhile12:
+  store i64 0, ptr @"g3.j"
+  br label %for17
+for17:
+  %t23 = load i64, ptr @"g3.j"
+  %t24 = icmp slt i64 %t23, 10
+  br i1 %t24, label %body18, label %endfor20
+body18:
+  %t25 = load i64, ptr @"g3.j"
+  %t26 = icmp eq i64 3, 0
+  br i1 %t26, label %error24, label %ok25
+error24:
+  call void @__runtime_error(ptr @.str.1, i64 37)
+  unreachable
+ok25:
+  %t27 = icmp eq i64 3, -1
+  %t28 = select i1 %t27, i64 1, i64 3
+  %t29 = srem i64 %t25, %t28
+  %t30 = select i1 %t27, i64 0, i64 %t29
+  %t31 = icmp eq i64 %t30, 0
+  br i1 %t31, label %then21, label %else22
+then21:
+  br label %step19
+dead26:
+  br label %endif23
+else22:
+  br label %endif23
+endif23:
+  %t32 = load i64, ptr @"g3.j"
+  %t33 = icmp sgt i64 %t32, 7
+  br i1 %t33, label %then27, label %else28
+then27:
+  br label %endfor20
+dead30:
+  br label %endif29
+else28:
+  br label %endif29
+endif29:
+  %t34 = load i64, ptr @"g3.j"
+  %t35 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t34)
+  br label %step19
+step19:
+  %t36 = load i64, ptr @"g3.j"
+  %t37 = add i64 %t36, 1
+  store i64 %t37, ptr @"g3.j"
+  br label %for17
+endfor20:
+  store i64 0, ptr @"g4.k"
+  br label %for31
+for31:
+  br label %body32
+body32:
+  %t38 = load i64, ptr @"g4.k"
+  %t39 = add i64 %t38, 1
+  store i64 %t39, ptr @"g4.k"
+  %t40 = load i64, ptr @"g4.k"
+  %t41 = icmp eq i64 %t40, 4
+  br i1 %t41, label %then35, label %else36
+then35:
+  br label %endfor34
+dead38:
+  br label %endif37
+else36:
+  br label %endif37
+endif37:
+  br label %step33
+step33:
+  br label %for31
+endfor34:
+  %t42 = load i64, ptr @"g4.k"
+  %t43 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t42)
+  store i64 0, ptr @"g5.j"
+  br label %for39
+for39:
+  %t44 = load i64, ptr @"g5.j"
+  %t45 = icmp slt i64 %t44, 3
+  br i1 %t45, label %body40, label %endfor42
+body40:
+  store i64 0, ptr @"g6.n"
+  br label %while43
+while43:
+  br i1 true, label %body44, label %endwhile45
+body44:
+  %t46 = load i64, ptr @"g6.n"
+  %t47 = add i64 %t46, 1
+  store i64 %t47, ptr @"g6.n"
+  %t48 = load i64, ptr @"g6.n"
+  %t49 = load i64, ptr @"g5.j"
+  %t50 = icmp sgt i64 %t48, %t49
+  br i1 %t50, label %then46, label %else47
+then46:
+  br label %endwhile45
+dead49:
+  br label %endif48
+else47:
+  br label %endif48
+endif48:
+  br label %while43
+endwhile45:
+  %t51 = load i64, ptr @"g6.n"
+  %t52 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t51)
+  br label %step41
+step41:
+  %t53 = load i64, ptr @"g5.j"
+  %t54 = add i64 %t53, 1
+  store i64 %t54, ptr @"g5.j"
+  br label %for39
+endfor42:
+  store i64 0, ptr @"g4.k"
+  br label %for50
+for50:
+  %t55 = load i64, ptr @"g4.k"
+  %t56 = icmp slt i64 %t55, 2
+  br i1 %t56, label %body51, label %endfor53
+body51:
+  br label %step52
+step52:
+  %t57 = load i64, ptr @"g4.k"
+  %t58 = add i64 %t57, 1
+  store i64 %t58, ptr @"g4.k"
+  br label %for50
+endfor53:
+  %t59 = load i64, ptr @"g4.k"
+  %t60 = mul i64 %t59, 2
+  %t61 = load i64, ptr @"g4.k"
+  %t62 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t61)
+  ret i32 0
+}
@@ -0,0 +1,79 @@
+/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= 1000)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+
+static long long zero;
+
+int main(void) {
+	zero = 0LL;
+	printf("%lld\n", 1LL);
+	printf("%lld\n", ss_div(10LL, zero, "3:7: error[E0202]: division by zero\n"));
+	printf("%lld\n", 2LL);
+	return 0;
+}
@@ -0,0 +1,40 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@"g0.zero" = internal global i64 0
+@.str.1 = private unnamed_addr constant [37 x i8] c"3:7: error[E0202]: division by zero\0A\00"
+
+define i32 @main() {
+entry:
+  store i64 0, ptr @"g0.zero"
+  %t1 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 1)
+  %t2 = load i64, ptr @"g0.zero"
+  %t3 = icmp eq i64 %t2, 0
+  br i1 %t3, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.1, i64 36)
+  unreachable
+ok2:
+  %t4 = icmp eq i64 %t2, -1
+  %t5 = select i1 %t4, i64 1, i64 %t2
+  %t6 = sdiv i64 10, %t5
+  %t7 = sub i64 0, 10
+  %t8 = select i1 %t4, i64 %t7, i64 %t6
+  %t9 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t8)
+  %t10 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 2)
+  ret i32 0
+}
@@ -0,0 +1,111 @@
+/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= 1000)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+
+static double avg(long long a, long long b);
+static bool flag(long long n);
+
+static double x;
+static double y;
+static bool b;
+static double f;
+
+static double avg(long long a, long long b) {
+	return (double)ss_add(a, b) / 2.0;
+}
+
+static bool flag(long long n) {
+	return ss_mod(n, 2LL, "6:27: error[E0202]: division by zero\n") == 0LL;
+}
+
+int main(void) {
+	x = (double)1LL;
+	y = x / (double)4LL + (double)2LL;
+	printf("%g\n", y);
+	printf("%g\n", ss_leave_float((ss_enter("3:17: error[E0205]: stack overflow: call depth exceeds 1000\n"), avg(3LL, 4LL))));
+	printf("%g\n", 1.0 / (double)3LL);
+	printf("%g\n", 2.5e+10 * (double)4LL);
+	printf("%g\n", 1500.0 + 0.5);
+	b = y > (double)2LL && !(x == 1.5);
+	puts(b ? "true" : "false");
+	printf("%lld\n", (long long)3.9);
+	printf("%g\n", (double)true);
+	puts((bool)0.0 ? "true" : "false");
+	printf("%lld\n", (long long)-2.5);
+	printf("%g\n", (double)7LL / (double)2LL);
+	puts(ss_leave_bool((ss_enter("7:7: error[E0205]: stack overflow: call depth exceeds 1000\n"), flag(4LL))) ? "true" : "false");
+	puts(ss_leave_bool((ss_enter("7:23: error[E0205]: stack overflow: call depth exceeds 1000\n"), flag(3LL))) ? "true" : "false");
+	puts(ss_leave_bool((ss_enter("7:39: error[E0205]: stack overflow: call depth exceeds 1000\n"), flag(2LL))) == ss_leave_bool((ss_enter("7:50: error[E0205]: stack overflow: call depth exceeds 1000\n"), flag(6LL))) ? "true" : "false");
+	f = 0.1;
+	for (long long i = 0LL; i < 3LL; i = ss_add(i, 1LL)) {
+		f = f * (double)3LL;
+	}
+	printf("%g\n", f);
+	return 0;
+}
@@ -0,0 +1,201 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@"g0.x" = internal global double 0.0
+@"g1.y" = internal global double 0.0
+@"g2.b" = internal global i1 false
+@"g3.f" = internal global double 0.0
+@"g4.i" = internal global i64 0
+@.str.1 = private unnamed_addr constant [38 x i8] c"6:27: error[E0202]: division by zero\0A\00"
+@.str.2 = private unnamed_addr constant [61 x i8] c"3:17: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.3 = private unnamed_addr constant [60 x i8] c"7:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.4 = private unnamed_addr constant [61 x i8] c"7:23: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.5 = private unnamed_addr constant [61 x i8] c"7:39: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.6 = private unnamed_addr constant [61 x i8] c"7:50: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+
+define internal double @"fn.avg"(i64 %"a.0", i64 %"b.1") {
+entry:
+  %"a.0.addr" = alloca i64
+  %"b.1.addr" = alloca i64
+  store i64 %"a.0", ptr %"a.0.addr"
+  store i64 %"b.1", ptr %"b.1.addr"
+  %t1 = load i64, ptr %"a.0.addr"
+  %t2 = load i64, ptr %"b.1.addr"
+  %t3 = add i64 %t1, %t2
+  %t4 = sitofp i64 %t3 to double
+  %t5 = fdiv double %t4, 0x4000000000000000
+  ret double %t5
+dead1:
+  ret double 0.0
+}
+
+define internal i1 @"fn.flag"(i64 %"n.0") {
+entry:
+  %"n.0.addr" = alloca i64
+  store i64 %"n.0", ptr %"n.0.addr"
+  %t1 = load i64, ptr %"n.0.addr"
+  %t2 = icmp eq i64 2, 0
+  br i1 %t2, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.1, i64 37)
+  unreachable
+ok2:
+  %t3 = icmp eq i64 2, -1
+  %t4 = select i1 %t3, i64 1, i64 2
+  %t5 = srem i64 %t1, %t4
+  %t6 = select i1 %t3, i64 0, i64 %t5
+  %t7 = icmp eq i64 %t6, 0
+  ret i1 %t7
+dead3:
+  ret i1 false
+}
+
+define i32 @main() {
+entry:
+  %t1 = sitofp i64 1 to double
+  store double %t1, ptr @"g0.x"
+  %t2 = load double, ptr @"g0.x"
+  %t3 = sitofp i64 4 to double
+  %t4 = fdiv double %t2, %t3
+  %t5 = sitofp i64 2 to double
+  %t6 = fadd double %t4, %t5
+  store double %t6, ptr @"g1.y"
+  %t7 = load double, ptr @"g1.y"
+  %t8 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t7)
+  %t9 = load i64, ptr @__depth
+  %t10 = icmp sge i64 %t9, 1000
+  br i1 %t10, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.2, i64 60)
+  unreachable
+ok2:
+  %t11 = add i64 %t9, 1
+  store i64 %t11, ptr @__depth
+  %t12 = call double @"fn.avg"(i64 3, i64 4)
+  store i64 %t9, ptr @__depth
+  %t13 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t12)
+  %t14 = sitofp i64 3 to double
+  %t15 = fdiv double 0x3FF0000000000000, %t14
+  %t16 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t15)
+  %t17 = sitofp i64 4 to double
+  %t18 = fmul double 0x42174876E8000000, %t17
+  %t19 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t18)
+  %t20 = fadd double 0x4097700000000000, 0x3FE0000000000000
+  %t21 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t20)
+  %t22 = load double, ptr @"g1.y"
+  %t23 = sitofp i64 2 to double
+  %t24 = fcmp ogt double %t22, %t23
+  br i1 %t24, label %rhs3, label %endlogic4
+rhs3:
+  %t25 = load double, ptr @"g0.x"
+  %t26 = fcmp oeq double %t25, 0x3FF8000000000000
+  %t27 = xor i1 %t26, true
+  br label %endlogic4
+endlogic4:
+  %t28 = phi i1 [ false, %ok2 ], [ %t27, %rhs3 ]
+  store i1 %t28, ptr @"g2.b"
+  %t29 = load i1, ptr @"g2.b"
+  %t30 = select i1 %t29, ptr @.str.true, ptr @.str.false
+  %t31 = call i32 (ptr, ...) @printf(ptr %t30)
+  %t32 = fptosi double 0x400F333333333333 to i64
+  %t33 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t32)
+  %t34 = uitofp i1 true to double
+  %t35 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t34)
+  %t36 = fcmp une double 0x0000000000000000, 0.0
+  %t37 = select i1 %t36, ptr @.str.true, ptr @.str.false
+  %t38 = call i32 (ptr, ...) @printf(ptr %t37)
+  %t39 = fneg double 0x4004000000000000
+  %t40 = fptosi double %t39 to i64
+  %t41 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t40)
+  %t42 = sitofp i64 7 to double
+  %t43 = sitofp i64 2 to double
+  %t44 = fdiv double %t42, %t43
+  %t45 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t44)
+  %t46 = load i64, ptr @__depth
+  %t47 = icmp sge i64 %t46, 1000
+  br i1 %t47, label %error5, label %ok6
+error5:
+  call void @__runtime_error(ptr @.str.3, i64 59)
+  unreachable
+ok6:
+  %t48 = add i64 %t46, 1
+  store i64 %t48, ptr @__depth
+  %t49 = call i1 @"fn.flag"(i64 4)
+  store i64 %t46, ptr @__depth
+  %t50 = select i1 %t49, ptr @.str.true, ptr @.str.false
+  %t51 = call i32 (ptr, ...) @printf(ptr %t50)
+  %t52 = load i64, ptr @__depth
+  %t53 = icmp sge i64 %t52, 1000
+  br i1 %t53, label %error7, label %ok8
+error7:
+  call void @__runtime_error(ptr @.str.4, i64 60)
+  unreachable
+ok8:
+  %t54 = add i64 %t52, 1
+  store i64 %t54, ptr @__depth
+  %t55 = call i1 @"fn.flag"(i64 3)
+  store i64 %t52, ptr @__depth
+  %t56 = select i1 %t55, ptr @.str.true, ptr @.str.false
+  %t57 = call i32 (ptr, ...) @printf(ptr %t56)
+  %t58 = load i64, ptr @__depth
+  %t59 = icmp sge i64 %t58, 1000
+  br i1 %t59, label %error9, label %ok10
+error9:
+  call void @__runtime_error(ptr @.str.5, i64 60)
+  unreachable
+ok10:
+  %t60 = add i64 %t58, 1
+  store i64 %t60, ptr @__depth
+  %t61 = call i1 @"fn.flag"(i64 2)
+  store i64 %t58, ptr @__depth
+  %t62 = load i64, ptr @__depth
+  %t63 = icmp sge i64 %t62, 1000
+  br i1 %t63, label %error11, label %ok12
+error11:
+  call void @__runtime_error(ptr @.str.6, i64 60)
+  unreachable
+ok12:
+  %t64 = add i64 %t62, 1
+  store i64 %t64, ptr @__depth
+  %t65 = call i1 @"fn.flag"(i64 6)
+  store i64 %t62, ptr @__depth
+  %t66 = icmp eq i1 %t61, %t65
+  %t67 = select i1 %t66, ptr @.str.true, ptr @.str.false
+  %t68 = call i32 (ptr, ...) @printf(ptr %t67)
+  store double 0x3FB999999999999A, ptr @"g3.f"
+  store i64 0, ptr @"g4.i"
+  br label %for13
+for13:
+  %t69 = load i64, ptr @"g4.i"
+  %t70 = icmp slt i64 %t69, 3
+  br i1 %t70, label %body14, label %endfor16
+body14:
+  %t71 = load double, ptr @"g3.f"
+  %t72 = sitofp i64 3 to double
+  %t73 = fmul double %t71, %t72
+  store double %t73, ptr @"g3.f"
+  br label %step15
+step15:
+  %t74 = load i64, ptr @"g4.i"
+  %t75 = add i64 %t74, 1
+  store i64 %t75, ptr @"g4.i"
+  br label %for13
+endfor16:
+  %t76 = load double, ptr @"g3.f"
+  %t77 = call i32 (ptr, ...) @printf(ptr @.fmt.float, double %t76)
+  ret i32 0
+}
@@ -0,0 +1,146 @@
+/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= 1000)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+
+static long long fib(long long n);
+static long long gcd(long long a, long long b);
+static bool isPrime(long long n);
+static void show(long long x);
+static void early(long long x);
+static void add(long long x);
+static long long sum8(long long a, long long b, long long c, long long d, long long e, long long f, long long g, long long h);
+
+static long long total;
+
+static long long fib(long long n) {
+	if (n < 2LL) {
+		return n;
+	}
+	return ss_add(ss_leave_int((ss_enter("1:46: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(ss_sub(n, 1LL)))), ss_leave_int((ss_enter("1:59: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(ss_sub(n, 2LL)))));
+}
+
+static long long gcd(long long a, long long b) {
+	while (b != 0LL) {
+		long long t = ss_mod(a, b, "2:50: error[E0202]: division by zero\n");
+		a = b;
+		b = t;
+	}
+	return a;
+}
+
+static bool isPrime(long long n) {
+	if (n < 2LL) {
+		return false;
+	}
+	for (long long i = 2LL; ss_mul(i, i) <= n; i = ss_add(i, 1LL)) {
+		if (ss_mod(n, i, "5:48: error[E0202]: division by zero\n") == 0LL) {
+			return false;
+		}
+	}
+	return true;
+}
+
+static void show(long long x) {
+	printf("%lld\n", x);
+}
+
+static void early(long long x) {
+	if (x > 0LL) {
+		printf("%lld\n", x);
+		return;
+	}
+	printf("%lld\n", 0LL);
+}
+
+static void add(long long x) {
+	total = ss_add(total, x);
+}
+
+static long long sum8(long long a, long long b, long long c, long long d, long long e, long long f, long long g, long long h) {
+	return ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(a, ss_mul(2LL, b)), ss_mul(3LL, c)), ss_mul(4LL, d)), ss_mul(5LL, e)), ss_mul(6LL, f)), ss_mul(7LL, g)), ss_mul(8LL, h));
+}
+
+int main(void) {
+	total = 0LL;
+	printf("%lld\n", ss_leave_int((ss_enter("13:7: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(20LL))));
+	printf("%lld\n", ss_leave_int((ss_enter("14:7: error[E0205]: stack overflow: call depth exceeds 1000\n"), gcd(1071LL, 462LL))));
+	for (long long i = 0LL; i < 30LL; i = ss_add(i, 1LL)) {
+		if (ss_leave_bool((ss_enter("15:40: error[E0205]: stack overflow: call depth exceeds 1000\n"), isPrime(i)))) {
+			(ss_enter("15:52: error[E0205]: stack overflow: call depth exceeds 1000\n"), show(i), ss_leave());
+		}
+	}
+	(ss_enter("16:1: error[E0205]: stack overflow: call depth exceeds 1000\n"), early(5LL), ss_leave());
+	(ss_enter("16:11: error[E0205]: stack overflow: call depth exceeds 1000\n"), early(ss_neg(5LL)), ss_leave());
+	(ss_enter("17:1: error[E0205]: stack overflow: call depth exceeds 1000\n"), add(3LL), ss_leave());
+	(ss_enter("17:9: error[E0205]: stack overflow: call depth exceeds 1000\n"), add(4LL), ss_leave());
+	printf("%lld\n", total);
+	printf("%lld\n", ss_leave_int((ss_enter("18:7: error[E0205]: stack overflow: call depth exceeds 1000\n"), sum8(1LL, 2LL, 3LL, 4LL, 5LL, 6LL, 7LL, 8LL))));
+	printf("%lld\n", ss_leave_int((ss_enter("19:7: error[E0205]: stack overflow: call depth exceeds 1000\n"), sum8(ss_leave_int((ss_enter("19:12: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(1LL))), ss_leave_int((ss_enter("19:20: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(2LL))), ss_leave_int((ss_enter("19:28: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(3LL))), ss_leave_int((ss_enter("19:36: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(4LL))), ss_leave_int((ss_enter("19:44: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(5LL))), ss_leave_int((ss_enter("19:52: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(6LL))), ss_leave_int((ss_enter("19:60: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(7LL))), ss_leave_int((ss_enter("19:68: error[E0205]: stack overflow: call depth exceeds 1000\n"), fib(8LL)))))));
+	return 0;
+}
@@ -0,0 +1,502 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@"g0.total" = internal global i64 0
+@"g1.i" = internal global i64 0
+@.str.1 = private unnamed_addr constant [61 x i8] c"1:46: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.2 = private unnamed_addr constant [61 x i8] c"1:59: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.3 = private unnamed_addr constant [38 x i8] c"2:50: error[E0202]: division by zero\0A\00"
+@.str.4 = private unnamed_addr constant [38 x i8] c"5:48: error[E0202]: division by zero\0A\00"
+@.str.5 = private unnamed_addr constant [61 x i8] c"13:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.6 = private unnamed_addr constant [61 x i8] c"14:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.7 = private unnamed_addr constant [62 x i8] c"15:40: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.8 = private unnamed_addr constant [62 x i8] c"15:52: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.9 = private unnamed_addr constant [61 x i8] c"16:1: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.10 = private unnamed_addr constant [62 x i8] c"16:11: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.11 = private unnamed_addr constant [61 x i8] c"17:1: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.12 = private unnamed_addr constant [61 x i8] c"17:9: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.13 = private unnamed_addr constant [61 x i8] c"18:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.14 = private unnamed_addr constant [62 x i8] c"19:12: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.15 = private unnamed_addr constant [62 x i8] c"19:20: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.16 = private unnamed_addr constant [62 x i8] c"19:28: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.17 = private unnamed_addr constant [62 x i8] c"19:36: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.18 = private unnamed_addr constant [62 x i8] c"19:44: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.19 = private unnamed_addr constant [62 x i8] c"19:52: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.20 = private unnamed_addr constant [62 x i8] c"19:60: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.21 = private unnamed_addr constant [62 x i8] c"19:68: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.22 = private unnamed_addr constant [61 x i8] c"19:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+
+define internal i64 @"fn.fib"(i64 %"n.0") {
+entry:
+  %"n.0.addr" = alloca i64
+  store i64 %"n.0", ptr %"n.0.addr"
+  %t1 = load i64, ptr %"n.0.addr"
+  %t2 = icmp slt i64 %t1, 2
+  br i1 %t2, label %then1, label %else2
+then1:
+  %t3 = load i64, ptr %"n.0.addr"
+  ret i64 %t3
+dead4:
+  br label %endif3
+else2:
+  br label %endif3
+endif3:
+  %t4 = load i64, ptr %"n.0.addr"
+  %t5 = sub i64 %t4, 1
+  %t6 = load i64, ptr @__depth
+  %t7 = icmp sge i64 %t6, 1000
+  br i1 %t7, label %error5, label %ok6
+error5:
+  call void @__runtime_error(ptr @.str.1, i64 60)
+  unreachable
+ok6:
+  %t8 = add i64 %t6, 1
+  store i64 %t8, ptr @__depth
+  %t9 = call i64 @"fn.fib"(i64 %t5)
+  store i64 %t6, ptr @__depth
+  %t10 = load i64, ptr %"n.0.addr"
+  %t11 = sub i64 %t10, 2
+  %t12 = load i64, ptr @__depth
+  %t13 = icmp sge i64 %t12, 1000
+  br i1 %t13, label %error7, label %ok8
+error7:
+  call void @__runtime_error(ptr @.str.2, i64 60)
+  unreachable
+ok8:
+  %t14 = add i64 %t12, 1
+  store i64 %t14, ptr @__depth
+  %t15 = call i64 @"fn.fib"(i64 %t11)
+  store i64 %t12, ptr @__depth
+  %t16 = add i64 %t9, %t15
+  ret i64 %t16
+dead9:
+  ret i64 0
+}
+
+define internal i64 @"fn.gcd"(i64 %"a.0", i64 %"b.1") {
+entry:
+  %"a.0.addr" = alloca i64
+  %"b.1.addr" = alloca i64
+  %"t.2.addr" = alloca i64
+  store i64 %"a.0", ptr %"a.0.addr"
+  store i64 %"b.1", ptr %"b.1.addr"
+  br label %while1
+while1:
+  %t1 = load i64, ptr %"b.1.addr"
+  %t2 = icmp ne i64 %t1, 0
+  br i1 %t2, label %body2, label %endwhile3
+body2:
+  %t3 = load i64, ptr %"a.0.addr"
+  %t4 = load i64, ptr %"b.1.addr"
+  %t5 = icmp eq i64 %t4, 0
+  br i1 %t5, label %error4, label %ok5
+error4:
+  call void @__runtime_error(ptr @.str.3, i64 37)
+  unreachable
+ok5:
+  %t6 = icmp eq i64 %t4, -1
+  %t7 = select i1 %t6, i64 1, i64 %t4
+  %t8 = srem i64 %t3, %t7
+  %t9 = select i1 %t6, i64 0, i64 %t8
+  store i64 %t9, ptr %"t.2.addr"
+  %t10 = load i64, ptr %"b.1.addr"
+  store i64 %t10, ptr %"a.0.addr"
+  %t11 = load i64, ptr %"t.2.addr"
+  store i64 %t11, ptr %"b.1.addr"
+  br label %while1
+endwhile3:
+  %t12 = load i64, ptr %"a.0.addr"
+  ret i64 %t12
+dead6:
+  ret i64 0
+}
+
+define internal i1 @"fn.isPrime"(i64 %"n.0") {
+entry:
+  %"n.0.addr" = alloca i64
+  %"i.1.addr" = alloca i64
+  store i64 %"n.0", ptr %"n.0.addr"
+  %t1 = load i64, ptr %"n.0.addr"
+  %t2 = icmp slt i64 %t1, 2
+  br i1 %t2, label %then1, label %else2
+then1:
+  ret i1 false
+dead4:
+  br label %endif3
+else2:
+  br label %endif3
+endif3:
+  store i64 2, ptr %"i.1.addr"
+  br label %for5
+for5:
+  %t3 = load i64, ptr %"i.1.addr"
+  %t4 = load i64, ptr %"i.1.addr"
+  %t5 = mul i64 %t3, %t4
+  %t6 = load i64, ptr %"n.0.addr"
+  %t7 = icmp sle i64 %t5, %t6
+  br i1 %t7, label %body6, label %endfor8
+body6:
+  %t8 = load i64, ptr %"n.0.addr"
+  %t9 = load i64, ptr %"i.1.addr"
+  %t10 = icmp eq i64 %t9, 0
+  br i1 %t10, label %error12, label %ok13
+error12:
+  call void @__runtime_error(ptr @.str.4, i64 37)
+  unreachable
+ok13:
+  %t11 = icmp eq i64 %t9, -1
+  %t12 = select i1 %t11, i64 1, i64 %t9
+  %t13 = srem i64 %t8, %t12
+  %t14 = select i1 %t11, i64 0, i64 %t13
+  %t15 = icmp eq i64 %t14, 0
+  br i1 %t15, label %then9, label %else10
+then9:
+  ret i1 false
+dead14:
+  br label %endif11
+else10:
+  br label %endif11
+endif11:
+  br label %step7
+step7:
+  %t16 = load i64, ptr %"i.1.addr"
+  %t17 = add i64 %t16, 1
+  store i64 %t17, ptr %"i.1.addr"
+  br label %for5
+endfor8:
+  ret i1 true
+dead15:
+  ret i1 false
+}
+
+define internal void @"fn.show"(i64 %"x.0") {
+entry:
+  %"x.0.addr" = alloca i64
+  store i64 %"x.0", ptr %"x.0.addr"
+  %t1 = load i64, ptr %"x.0.addr"
+  %t2 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t1)
+  ret void
+}
+
+define internal void @"fn.early"(i64 %"x.0") {
+entry:
+  %"x.0.addr" = alloca i64
+  store i64 %"x.0", ptr %"x.0.addr"
+  %t1 = load i64, ptr %"x.0.addr"
+  %t2 = icmp sgt i64 %t1, 0
+  br i1 %t2, label %then1, label %else2
+then1:
+  %t3 = load i64, ptr %"x.0.addr"
+  %t4 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t3)
+  ret void
+dead4:
+  br label %endif3
+else2:
+  br label %endif3
+endif3:
+  %t5 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 0)
+  ret void
+}
+
+define internal void @"fn.add"(i64 %"x.0") {
+entry:
+  %"x.0.addr" = alloca i64
+  store i64 %"x.0", ptr %"x.0.addr"
+  %t1 = load i64, ptr @"g0.total"
+  %t2 = load i64, ptr %"x.0.addr"
+  %t3 = add i64 %t1, %t2
+  store i64 %t3, ptr @"g0.total"
+  ret void
+}
+
+define internal i64 @"fn.sum8"(i64 %"a.0", i64 %"b.1", i64 %"c.2", i64 %"d.3", i64 %"e.4", i64 %"f.5", i64 %"g.6", i64 %"h.7") {
+entry:
+  %"a.0.addr" = alloca i64
+  %"b.1.addr" = alloca i64
+  %"c.2.addr" = alloca i64
+  %"d.3.addr" = alloca i64
+  %"e.4.addr" = alloca i64
+  %"f.5.addr" = alloca i64
+  %"g.6.addr" = alloca i64
+  %"h.7.addr" = alloca i64
+  store i64 %"a.0", ptr %"a.0.addr"
+  store i64 %"b.1", ptr %"b.1.addr"
+  store i64 %"c.2", ptr %"c.2.addr"
+  store i64 %"d.3", ptr %"d.3.addr"
+  store i64 %"e.4", ptr %"e.4.addr"
+  store i64 %"f.5", ptr %"f.5.addr"
+  store i64 %"g.6", ptr %"g.6.addr"
+  store i64 %"h.7", ptr %"h.7.addr"
+  %t1 = load i64, ptr %"a.0.addr"
+  %t2 = load i64, ptr %"b.1.addr"
+  %t3 = mul i64 2, %t2
+  %t4 = add i64 %t1, %t3
+  %t5 = load i64, ptr %"c.2.addr"
+  %t6 = mul i64 3, %t5
+  %t7 = add i64 %t4, %t6
+  %t8 = load i64, ptr %"d.3.addr"
+  %t9 = mul i64 4, %t8
+  %t10 = add i64 %t7, %t9
+  %t11 = load i64, ptr %"e.4.addr"
+  %t12 = mul i64 5, %t11
+  %t13 = add i64 %t10, %t12
+  %t14 = load i64, ptr %"f.5.addr"
+  %t15 = mul i64 6, %t14
+  %t16 = add i64 %t13, %t15
+  %t17 = load i64, ptr %"g.6.addr"
+  %t18 = mul i64 7, %t17
+  %t19 = add i64 %t16, %t18
+  %t20 = load i64, ptr %"h.7.addr"
+  %t21 = mul i64 8, %t20
+  %t22 = add i64 %t19, %t21
+  ret i64 %t22
+dead1:
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  store i64 0, ptr @"g0.total"
+  %t1 = load i64, ptr @__depth
+  %t2 = icmp sge i64 %t1, 1000
+  br i1 %t2, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.5, i64 60)
+  unreachable
+ok2:
+  %t3 = add i64 %t1, 1
+  store i64 %t3, ptr @__depth
+  %t4 = call i64 @"fn.fib"(i64 20)
+  store i64 %t1, ptr @__depth
+  %t5 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t4)
+  %t6 = load i64, ptr @__depth
+  %t7 = icmp sge i64 %t6, 1000
+  br i1 %t7, label %error3, label %ok4
+error3:
+  call void @__runtime_error(ptr @.str.6, i64 60)
+  unreachable
+ok4:
+  %t8 = add i64 %t6, 1
+  store i64 %t8, ptr @__depth
+  %t9 = call i64 @"fn.gcd"(i64 1071, i64 462)
+  store i64 %t6, ptr @__depth
+  %t10 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t9)
+  store i64 0, ptr @"g1.i"
+  br label %for5
+for5:
+  %t11 = load i64, ptr @"g1.i"
+  %t12 = icmp slt i64 %t11, 30
+  br i1 %t12, label %body6, label %endfor8
+body6:
+  %t13 = load i64, ptr @"g1.i"
+  %t14 = load i64, ptr @__depth
+  %t15 = icmp sge i64 %t14, 1000
+  br i1 %t15, label %error12, label %ok13
+error12:
+  call void @__runtime_error(ptr @.str.7, i64 61)
+  unreachable
+ok13:
+  %t16 = add i64 %t14, 1
+  store i64 %t16, ptr @__depth
+  %t17 = call i1 @"fn.isPrime"(i64 %t13)
+  store i64 %t14, ptr @__depth
+  br i1 %t17, label %then9, label %else10
+then9:
+  %t18 = load i64, ptr @"g1.i"
+  %t19 = load i64, ptr @__depth
+  %t20 = icmp sge i64 %t19, 1000
+  br i1 %t20, label %error14, label %ok15
+error14:
+  call void @__runtime_error(ptr @.str.8, i64 61)
+  unreachable
+ok15:
+  %t21 = add i64 %t19, 1
+  store i64 %t21, ptr @__depth
+  call void @"fn.show"(i64 %t18)
+  store i64 %t19, ptr @__depth
+  br label %endif11
+else10:
+  br label %endif11
+endif11:
+  br label %step7
+step7:
+  %t22 = load i64, ptr @"g1.i"
+  %t23 = add i64 %t22, 1
+  store i64 %t23, ptr @"g1.i"
+  br label %for5
+endfor8:
+  %t24 = load i64, ptr @__depth
+  %t25 = icmp sge i64 %t24, 1000
+  br i1 %t25, label %error16, label %ok17
+error16:
+  call void @__runtime_error(ptr @.str.9, i64 60)
+  unreachable
+ok17:
+  %t26 = add i64 %t24, 1
+  store i64 %t26, ptr @__depth
+  call void @"fn.early"(i64 5)
+  store i64 %t24, ptr @__depth
+  %t27 = sub i64 0, 5
+  %t28 = load i64, ptr @__depth
+  %t29 = icmp sge i64 %t28, 1000
+  br i1 %t29, label %error18, label %ok19
+error18:
+  call void @__runtime_error(ptr @.str.10, i64 61)
+  unreachable
+ok19:
+  %t30 = add i64 %t28, 1
+  store i64 %t30, ptr @__depth
+  call void @"fn.early"(i64 %t27)
+  store i64 %t28, ptr @__depth
+  %t31 = load i64, ptr @__depth
+  %t32 = icmp sge i64 %t31, 1000
+  br i1 %t32, label %error20, label %ok21
+error20:
+  call void @__runtime_error(ptr @.str.11, i64 60)
+  unreachable
+ok21:
+  %t33 = add i64 %t31, 1
+  store i64 %t33, ptr @__depth
+  call void @"fn.add"(i64 3)
+  store i64 %t31, ptr @__depth
+  %t34 = load i64, ptr @__depth
+  %t35 = icmp sge i64 %t34, 1000
+  br i1 %t35, label %error22, label %ok23
+error22:
+  call void @__runtime_error(ptr @.str.12, i64 60)
+  unreachable
+ok23:
+  %t36 = add i64 %t34, 1
+  store i64 %t36, ptr @__depth
+  call void @"fn.add"(i64 4)
+  store i64 %t34, ptr @__depth
+  %t37 = load i64, ptr @"g0.total"
+  %t38 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t37)
+  %t39 = load i64, ptr @__depth
+  %t40 = icmp sge i64 %t39, 1000
+  br i1 %t40, label %error24, label %ok25
+error24:
+  call void @__runtime_error(ptr @.str.13, i64 60)
+  unreachable
+ok25:
+  %t41 = add i64 %t39, 1
+  store i64 %t41, ptr @__depth
+  %t42 = call i64 @"fn.sum8"(i64 1, i64 2, i64 3, i64 4, i64 5, i64 6, i64 7, i64 8)
+  store i64 %t39, ptr @__depth
+  %t43 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t42)
+  %t44 = load i64, ptr @__depth
+  %t45 = icmp sge i64 %t44, 1000
+  br i1 %t45, label %error26, label %ok27
+error26:
+  call void @__runtime_error(ptr @.str.14, i64 61)
+  unreachable
+ok27:
+  %t46 = add i64 %t44, 1
+  store i64 %t46, ptr @__depth
+  %t47 = call i64 @"fn.fib"(i64 1)
+  store i64 %t44, ptr @__depth
+  %t48 = load i64, ptr @__depth
+  %t49 = icmp sge i64 %t48, 1000
+  br i1 %t49, label %error28, label %ok29
+error28:
+  call void @__runtime_error(ptr @.str.15, i64 61)
+  unreachable
+ok29:
+  %t50 = add i64 %t48, 1
+  store i64 %t50, ptr @__depth
+  %t51 = call i64 @"fn.fib"(i64 2)
+  store i64 %t48, ptr @__depth
+  %t52 = load i64, ptr @__depth
+  %t53 = icmp sge i64 %t52, 1000
+  br i1 %t53, label %error30, label %ok31
+error30:
+  call void @__runtime_error(ptr @.str.16, i64 61)
+  unreachable
+ok31:
+  %t54 = add i64 %t52, 1
+  store i64 %t54, ptr @__depth
+  %t55 = call i64 @"fn.fib"(i64 3)
+  store i64 %t52, ptr @__depth
+  %t56 = load i64, ptr @__depth
+  %t57 = icmp sge i64 %t56, 1000
+  br i1 %t57, label %error32, label %ok33
+error32:
+  call void @__runtime_error(ptr @.str.17, i64 61)
+  unreachable
+ok33:
+  %t58 = add i64 %t56, 1
+  store i64 %t58, ptr @__depth
+  %t59 = call i64 @"fn.fib"(i64 4)
+  store i64 %t56, ptr @__depth
+  %t60 = load i64, ptr @__depth
+  %t61 = icmp sge i64 %t60, 1000
+  br i1 %t61, label %error34, label %ok35
+error34:
+  call void @__runtime_error(ptr @.str.18, i64 61)
+  unreachable
+ok35:
+  %t62 = add i64 %t60, 1
+  store i64 %t62, ptr @__depth
+  %t63 = call i64 @"fn.fib"(i64 5)
+  store i64 %t60, ptr @__depth
+  %t64 = load i64, ptr @__depth
+  %t65 = icmp sge i64 %t64, 1000
+  br i1 %t65, label %error36, label %ok37
+error36:
+  call void @__runtime_error(ptr @.str.19, i64 61)
+  unreachable
+ok37:
+  %t66 = add i64 %t64, 1
+  store i64 %t66, ptr @__depth
+  %t67 = call i64 @"fn.fib"(i64 6)
+  store i64 %t64, ptr @__depth
+  %t68 = load i64, ptr @__depth
+  %t69 = icmp sge i64 %t68, 1000
+  br i1 %t69, label %error38, label %ok39
+error38:
+  call void @__runtime_error(ptr @.str.20, i64 61)
+  unreachable
+ok39:
+  %t70 = add i64 %t68, 1
+  store i64 %t70, ptr @__depth
+  %t71 = call i64 @"fn.fib"(i64 7)
+  store i64 %t68, ptr @__depth
+  %t72 = load i64, ptr @__depth
+  %t73 = icmp sge i64 %t72, 1000
+  br i1 %t73, label %error40, label %ok41
+error40:
+  call void @__runtime_error(ptr @.str.21, i64 61)
+  unreachable
+ok41:
+  %t74 = add i64 %t72, 1
+  store i64 %t74, ptr @__depth
+  %t75 = call i64 @"fn.fib"(i64 8)
+  store i64 %t72, ptr @__depth
+  %t76 = load i64, ptr @__depth
+  %t77 = icmp sge i64 %t76, 1000
+  br i1 %t77, label %error42, label %ok43
+error42:
+  call void @__runtime_error(ptr @.str.22, i64 60)
+  unreachable
+ok43:
+  %t78 = add i64 %t76, 1
+  store i64 %t78, ptr @__depth
+  %t79 = call i64 @"fn.sum8"(i64 %t47, i64 %t51, i64 %t55, i64 %t59, i64 %t63, i64 %t67, i64 %t71, i64 %t75)
+  store i64 %t76, ptr @__depth
+  %t80 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t79)
+  ret i32 0
+}
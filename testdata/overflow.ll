@@ -0,0 +1,60 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@.str.1 = private unnamed_addr constant [61 x i8] c"1:26: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.2 = private unnamed_addr constant [60 x i8] c"3:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+
+define internal i64 @"fn.down"(i64 %"n.0") {
+entry:
+  %"n.0.addr" = alloca i64
+  store i64 %"n.0", ptr %"n.0.addr"
+  %t1 = load i64, ptr %"n.0.addr"
+  %t2 = add i64 %t1, 1
+  %t3 = load i64, ptr @__depth
+  %t4 = icmp sge i64 %t3, 1000
+  br i1 %t4, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.1, i64 60)
+  unreachable
+ok2:
+  %t5 = add i64 %t3, 1
+  store i64 %t5, ptr @__depth
+  %t6 = call i64 @"fn.down"(i64 %t2)
+  store i64 %t3, ptr @__depth
+  %t7 = add i64 %t6, 1
+  ret i64 %t7
+dead3:
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %t1 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 1)
+  %t2 = load i64, ptr @__depth
+  %t3 = icmp sge i64 %t2, 1000
+  br i1 %t3, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.2, i64 59)
+  unreachable
+ok2:
+  %t4 = add i64 %t2, 1
+  store i64 %t4, ptr @__depth
+  %t5 = call i64 @"fn.down"(i64 0)
+  store i64 %t2, ptr @__depth
+  %t6 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t5)
+  ret i32 0
+}
@@ -0,0 +1,132 @@
+/* Generated by compiler. */
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static void ss_error(const char *message) {
+	fflush(stdout);
+	fputs(message, stderr);
+	exit(1);
+}
+
+/* Signed overflow is undefined in C: compute in unsigned long long, which
+   wraps around, and convert back. */
+static inline long long ss_neg(long long a) {
+	return (long long)(0ULL - (unsigned long long)a);
+}
+
+static inline long long ss_add(long long a, long long b) {
+	return (long long)((unsigned long long)a + (unsigned long long)b);
+}
+
+static inline long long ss_sub(long long a, long long b) {
+	return (long long)((unsigned long long)a - (unsigned long long)b);
+}
+
+static inline long long ss_mul(long long a, long long b) {
+	return (long long)((unsigned long long)a * (unsigned long long)b);
+}
+
+/* ss_div and ss_mod report a division by zero and return what the
+   interpreter does for the minimum value divided by -1. */
+static inline long long ss_div(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? (long long)(0ULL - (unsigned long long)a) : a / b;
+}
+
+static inline long long ss_mod(long long a, long long b, const char *message) {
+	if (b == 0)
+		ss_error(message);
+	return b == -1 ? 0 : a % b;
+}
+
+static long long ss_depth;
+
+static inline void ss_enter(const char *message) {
+	if (ss_depth >= 1000)
+		ss_error(message);
+	ss_depth++;
+}
+
+static inline void ss_leave(void) {
+	ss_depth--;
+}
+
+static inline long long ss_leave_int(long long result) {
+	ss_depth--;
+	return result;
+}
+
+static inline double ss_leave_float(double result) {
+	ss_depth--;
+	return result;
+}
+
+static inline bool ss_leave_bool(bool result) {
+	ss_depth--;
+	return result;
+}
+
+static long long mix(long long a, long long b, long long c, long long d, long long e, long long f, long long g, long long h);
+static long long pressure(long long n);
+
+static long long mix(long long a, long long b, long long c, long long d, long long e, long long f, long long g, long long h) {
+	return ss_sub(ss_add(ss_sub(ss_add(ss_sub(ss_add(ss_sub(a, b), c), d), e), f), g), h);
+}
+
+static long long pressure(long long n) {
+	long long v1 = ss_add(n, 1LL);
+	long long v2 = ss_add(n, 2LL);
+	long long v3 = ss_add(n, 3LL);
+	long long v4 = ss_add(n, 4LL);
+	long long v5 = ss_add(n, 5LL);
+	long long v6 = ss_add(n, 6LL);
+	long long v7 = ss_add(n, 7LL);
+	long long v8 = ss_add(n, 8LL);
+	long long v9 = ss_add(n, 9LL);
+	long long v10 = ss_add(n, 10LL);
+	long long v11 = ss_mul(v1, 2LL);
+	long long v12 = ss_mul(v2, 2LL);
+	long long v13 = ss_mul(v3, 2LL);
+	long long v14 = ss_mul(v4, 2LL);
+	long long v15 = ss_mul(v5, 2LL);
+	long long v16 = ss_mul(v6, 2LL);
+	long long v17 = ss_mul(v7, 2LL);
+	long long v18 = ss_mul(v8, 2LL);
+	long long v19 = ss_mul(v9, 2LL);
+	long long v20 = ss_mul(v10, 2LL);
+	long long v21 = ss_sub(v11, v1);
+	long long v22 = ss_sub(v12, v2);
+	long long v23 = ss_sub(v13, v3);
+	long long v24 = ss_sub(v14, v4);
+	long long v25 = ss_sub(v15, v5);
+	long long v26 = ss_sub(v16, v6);
+	long long v27 = ss_sub(v17, v7);
+	long long v28 = ss_sub(v18, v8);
+	long long v29 = ss_sub(v19, v9);
+	long long v30 = ss_sub(v20, v10);
+	long long v31 = ss_add(v21, v30);
+	long long v32 = ss_add(v22, v29);
+	long long v33 = ss_add(v23, v28);
+	long long v34 = ss_add(v24, v27);
+	long long v35 = ss_add(v25, v26);
+	long long v36 = ss_mod(v31, 7LL, "10:13: error[E0202]: division by zero\n");
+	long long v37 = ss_mod(v32, 7LL, "10:32: error[E0202]: division by zero\n");
+	long long v38 = ss_mod(v33, 7LL, "10:51: error[E0202]: division by zero\n");
+	long long v39 = ss_mod(v34, 7LL, "10:70: error[E0202]: division by zero\n");
+	long long v40 = ss_mod(v35, 7LL, "10:89: error[E0202]: division by zero\n");
+	long long call = ss_add(ss_leave_int((ss_enter("11:14: error[E0205]: stack overflow: call depth exceeds 1000\n"), mix(v1, v2, v3, v4, v5, v6, v7, v8))), ss_leave_int((ss_enter("11:52: error[E0205]: stack overflow: call depth exceeds 1000\n"), mix(v40, v39, v38, v37, v36, v35, v34, v33))));
+	long long s = 0LL;
+	for (long long i = 0LL; i < 3LL; i = ss_add(i, 1LL)) {
+		s = ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(ss_add(s, v1), v2), v3), v4), v5), v6), v7), v8), v9), v10), v11), v12), v13), v14), v15), v16), v17), v18), v19), v20), v21), v22), v23), v24), v25), v26), v27), v28), v29), v30), v31), v32), v33), v34), v35), v36), v37), v38), v39), v40), ss_mul(ss_leave_int((ss_enter("18:13: error[E0205]: stack overflow: call depth exceeds 1000\n"), mix(v1, v11, v21, v31, v40, v30, v20, v10))), i));
+	}
+	return ss_sub(ss_add(ss_add(ss_mul(s, 1000LL), call), ss_mul(v1, v40)), ss_mul(v20, v21));
+}
+
+int main(void) {
+	printf("%lld\n", ss_leave_int((ss_enter("22:7: error[E0205]: stack overflow: call depth exceeds 1000\n"), pressure(0LL))));
+	printf("%lld\n", ss_leave_int((ss_enter("22:27: error[E0205]: stack overflow: call depth exceeds 1000\n"), pressure(5LL))));
+	printf("%lld\n", ss_leave_int((ss_enter("22:47: error[E0205]: stack overflow: call depth exceeds 1000\n"), pressure(ss_neg(3LL)))));
+	return 0;
+}
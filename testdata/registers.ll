@@ -0,0 +1,511 @@
+; generated by compiler
+@.fmt.int = private unnamed_addr constant [6 x i8] c"%lld\0A\00"
+@.fmt.float = private unnamed_addr constant [4 x i8] c"%g\0A\00"
+@.str.true = private unnamed_addr constant [6 x i8] c"true\0A\00"
+@.str.false = private unnamed_addr constant [7 x i8] c"false\0A\00"
+@__depth = internal global i64 0
+
+declare i32 @printf(ptr, ...)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @__runtime_error(ptr %message, i64 %length) noreturn {
+entry:
+  %written = call i64 @write(i32 2, ptr %message, i64 %length)
+  call void @exit(i32 1)
+  unreachable
+}
+@.str.1 = private unnamed_addr constant [39 x i8] c"10:13: error[E0202]: division by zero\0A\00"
+@.str.2 = private unnamed_addr constant [39 x i8] c"10:32: error[E0202]: division by zero\0A\00"
+@.str.3 = private unnamed_addr constant [39 x i8] c"10:51: error[E0202]: division by zero\0A\00"
+@.str.4 = private unnamed_addr constant [39 x i8] c"10:70: error[E0202]: division by zero\0A\00"
+@.str.5 = private unnamed_addr constant [39 x i8] c"10:89: error[E0202]: division by zero\0A\00"
+@.str.6 = private unnamed_addr constant [62 x i8] c"11:14: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.7 = private unnamed_addr constant [62 x i8] c"11:52: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.8 = private unnamed_addr constant [62 x i8] c"18:13: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.9 = private unnamed_addr constant [61 x i8] c"22:7: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.10 = private unnamed_addr constant [62 x i8] c"22:27: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+@.str.11 = private unnamed_addr constant [62 x i8] c"22:47: error[E0205]: stack overflow: call depth exceeds 1000\0A\00"
+
+define internal i64 @"fn.mix"(i64 %"a.0", i64 %"b.1", i64 %"c.2", i64 %"d.3", i64 %"e.4", i64 %"f.5", i64 %"g.6", i64 %"h.7") {
+entry:
+  %"a.0.addr" = alloca i64
+  %"b.1.addr" = alloca i64
+  %"c.2.addr" = alloca i64
+  %"d.3.addr" = alloca i64
+  %"e.4.addr" = alloca i64
+  %"f.5.addr" = alloca i64
+  %"g.6.addr" = alloca i64
+  %"h.7.addr" = alloca i64
+  store i64 %"a.0", ptr %"a.0.addr"
+  store i64 %"b.1", ptr %"b.1.addr"
+  store i64 %"c.2", ptr %"c.2.addr"
+  store i64 %"d.3", ptr %"d.3.addr"
+  store i64 %"e.4", ptr %"e.4.addr"
+  store i64 %"f.5", ptr %"f.5.addr"
+  store i64 %"g.6", ptr %"g.6.addr"
+  store i64 %"h.7", ptr %"h.7.addr"
+  %t1 = load i64, ptr %"a.0.addr"
+  %t2 = load i64, ptr %"b.1.addr"
+  %t3 = sub i64 %t1, %t2
+  %t4 = load i64, ptr %"c.2.addr"
+  %t5 = add i64 %t3, %t4
+  %t6 = load i64, ptr %"d.3.addr"
+  %t7 = sub i64 %t5, %t6
+  %t8 = load i64, ptr %"e.4.addr"
+  %t9 = add i64 %t7, %t8
+  %t10 = load i64, ptr %"f.5.addr"
+  %t11 = sub i64 %t9, %t10
+  %t12 = load i64, ptr %"g.6.addr"
+  %t13 = add i64 %t11, %t12
+  %t14 = load i64, ptr %"h.7.addr"
+  %t15 = sub i64 %t13, %t14
+  ret i64 %t15
+dead1:
+  ret i64 0
+}
+
+define internal i64 @"fn.pressure"(i64 %"n.0") {
+entry:
+  %"n.0.addr" = alloca i64
+  %"v1.1.addr" = alloca i64
+  %"v2.2.addr" = alloca i64
+  %"v3.3.addr" = alloca i64
+  %"v4.4.addr" = alloca i64
+  %"v5.5.addr" = alloca i64
+  %"v6.6.addr" = alloca i64
+  %"v7.7.addr" = alloca i64
+  %"v8.8.addr" = alloca i64
+  %"v9.9.addr" = alloca i64
+  %"v10.10.addr" = alloca i64
+  %"v11.11.addr" = alloca i64
+  %"v12.12.addr" = alloca i64
+  %"v13.13.addr" = alloca i64
+  %"v14.14.addr" = alloca i64
+  %"v15.15.addr" = alloca i64
+  %"v16.16.addr" = alloca i64
+  %"v17.17.addr" = alloca i64
+  %"v18.18.addr" = alloca i64
+  %"v19.19.addr" = alloca i64
+  %"v20.20.addr" = alloca i64
+  %"v21.21.addr" = alloca i64
+  %"v22.22.addr" = alloca i64
+  %"v23.23.addr" = alloca i64
+  %"v24.24.addr" = alloca i64
+  %"v25.25.addr" = alloca i64
+  %"v26.26.addr" = alloca i64
+  %"v27.27.addr" = alloca i64
+  %"v28.28.addr" = alloca i64
+  %"v29.29.addr" = alloca i64
+  %"v30.30.addr" = alloca i64
+  %"v31.31.addr" = alloca i64
+  %"v32.32.addr" = alloca i64
+  %"v33.33.addr" = alloca i64
+  %"v34.34.addr" = alloca i64
+  %"v35.35.addr" = alloca i64
+  %"v36.36.addr" = alloca i64
+  %"v37.37.addr" = alloca i64
+  %"v38.38.addr" = alloca i64
+  %"v39.39.addr" = alloca i64
+  %"v40.40.addr" = alloca i64
+  %"call.41.addr" = alloca i64
+  %"s.42.addr" = alloca i64
+  %"i.43.addr" = alloca i64
+  store i64 %"n.0", ptr %"n.0.addr"
+  %t1 = load i64, ptr %"n.0.addr"
+  %t2 = add i64 %t1, 1
+  store i64 %t2, ptr %"v1.1.addr"
+  %t3 = load i64, ptr %"n.0.addr"
+  %t4 = add i64 %t3, 2
+  store i64 %t4, ptr %"v2.2.addr"
+  %t5 = load i64, ptr %"n.0.addr"
+  %t6 = add i64 %t5, 3
+  store i64 %t6, ptr %"v3.3.addr"
+  %t7 = load i64, ptr %"n.0.addr"
+  %t8 = add i64 %t7, 4
+  store i64 %t8, ptr %"v4.4.addr"
+  %t9 = load i64, ptr %"n.0.addr"
+  %t10 = add i64 %t9, 5
+  store i64 %t10, ptr %"v5.5.addr"
+  %t11 = load i64, ptr %"n.0.addr"
+  %t12 = add i64 %t11, 6
+  store i64 %t12, ptr %"v6.6.addr"
+  %t13 = load i64, ptr %"n.0.addr"
+  %t14 = add i64 %t13, 7
+  store i64 %t14, ptr %"v7.7.addr"
+  %t15 = load i64, ptr %"n.0.addr"
+  %t16 = add i64 %t15, 8
+  store i64 %t16, ptr %"v8.8.addr"
+  %t17 = load i64, ptr %"n.0.addr"
+  %t18 = add i64 %t17, 9
+  store i64 %t18, ptr %"v9.9.addr"
+  %t19 = load i64, ptr %"n.0.addr"
+  %t20 = add i64 %t19, 10
+  store i64 %t20, ptr %"v10.10.addr"
+  %t21 = load i64, ptr %"v1.1.addr"
+  %t22 = mul i64 %t21, 2
+  store i64 %t22, ptr %"v11.11.addr"
+  %t23 = load i64, ptr %"v2.2.addr"
+  %t24 = mul i64 %t23, 2
+  store i64 %t24, ptr %"v12.12.addr"
+  %t25 = load i64, ptr %"v3.3.addr"
+  %t26 = mul i64 %t25, 2
+  store i64 %t26, ptr %"v13.13.addr"
+  %t27 = load i64, ptr %"v4.4.addr"
+  %t28 = mul i64 %t27, 2
+  store i64 %t28, ptr %"v14.14.addr"
+  %t29 = load i64, ptr %"v5.5.addr"
+  %t30 = mul i64 %t29, 2
+  store i64 %t30, ptr %"v15.15.addr"
+  %t31 = load i64, ptr %"v6.6.addr"
+  %t32 = mul i64 %t31, 2
+  store i64 %t32, ptr %"v16.16.addr"
+  %t33 = load i64, ptr %"v7.7.addr"
+  %t34 = mul i64 %t33, 2
+  store i64 %t34, ptr %"v17.17.addr"
+  %t35 = load i64, ptr %"v8.8.addr"
+  %t36 = mul i64 %t35, 2
+  store i64 %t36, ptr %"v18.18.addr"
+  %t37 = load i64, ptr %"v9.9.addr"
+  %t38 = mul i64 %t37, 2
+  store i64 %t38, ptr %"v19.19.addr"
+  %t39 = load i64, ptr %"v10.10.addr"
+  %t40 = mul i64 %t39, 2
+  store i64 %t40, ptr %"v20.20.addr"
+  %t41 = load i64, ptr %"v11.11.addr"
+  %t42 = load i64, ptr %"v1.1.addr"
+  %t43 = sub i64 %t41, %t42
+  store i64 %t43, ptr %"v21.21.addr"
+  %t44 = load i64, ptr %"v12.12.addr"
+  %t45 = load i64, ptr %"v2.2.addr"
+  %t46 = sub i64 %t44, %t45
+  store i64 %t46, ptr %"v22.22.addr"
+  %t47 = load i64, ptr %"v13.13.addr"
+  %t48 = load i64, ptr %"v3.3.addr"
+  %t49 = sub i64 %t47, %t48
+  store i64 %t49, ptr %"v23.23.addr"
+  %t50 = load i64, ptr %"v14.14.addr"
+  %t51 = load i64, ptr %"v4.4.addr"
+  %t52 = sub i64 %t50, %t51
+  store i64 %t52, ptr %"v24.24.addr"
+  %t53 = load i64, ptr %"v15.15.addr"
+  %t54 = load i64, ptr %"v5.5.addr"
+  %t55 = sub i64 %t53, %t54
+  store i64 %t55, ptr %"v25.25.addr"
+  %t56 = load i64, ptr %"v16.16.addr"
+  %t57 = load i64, ptr %"v6.6.addr"
+  %t58 = sub i64 %t56, %t57
+  store i64 %t58, ptr %"v26.26.addr"
+  %t59 = load i64, ptr %"v17.17.addr"
+  %t60 = load i64, ptr %"v7.7.addr"
+  %t61 = sub i64 %t59, %t60
+  store i64 %t61, ptr %"v27.27.addr"
+  %t62 = load i64, ptr %"v18.18.addr"
+  %t63 = load i64, ptr %"v8.8.addr"
+  %t64 = sub i64 %t62, %t63
+  store i64 %t64, ptr %"v28.28.addr"
+  %t65 = load i64, ptr %"v19.19.addr"
+  %t66 = load i64, ptr %"v9.9.addr"
+  %t67 = sub i64 %t65, %t66
+  store i64 %t67, ptr %"v29.29.addr"
+  %t68 = load i64, ptr %"v20.20.addr"
+  %t69 = load i64, ptr %"v10.10.addr"
+  %t70 = sub i64 %t68, %t69
+  store i64 %t70, ptr %"v30.30.addr"
+  %t71 = load i64, ptr %"v21.21.addr"
+  %t72 = load i64, ptr %"v30.30.addr"
+  %t73 = add i64 %t71, %t72
+  store i64 %t73, ptr %"v31.31.addr"
+  %t74 = load i64, ptr %"v22.22.addr"
+  %t75 = load i64, ptr %"v29.29.addr"
+  %t76 = add i64 %t74, %t75
+  store i64 %t76, ptr %"v32.32.addr"
+  %t77 = load i64, ptr %"v23.23.addr"
+  %t78 = load i64, ptr %"v28.28.addr"
+  %t79 = add i64 %t77, %t78
+  store i64 %t79, ptr %"v33.33.addr"
+  %t80 = load i64, ptr %"v24.24.addr"
+  %t81 = load i64, ptr %"v27.27.addr"
+  %t82 = add i64 %t80, %t81
+  store i64 %t82, ptr %"v34.34.addr"
+  %t83 = load i64, ptr %"v25.25.addr"
+  %t84 = load i64, ptr %"v26.26.addr"
+  %t85 = add i64 %t83, %t84
+  store i64 %t85, ptr %"v35.35.addr"
+  %t86 = load i64, ptr %"v31.31.addr"
+  %t87 = icmp eq i64 7, 0
+  br i1 %t87, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.1, i64 38)
+  unreachable
+ok2:
+  %t88 = icmp eq i64 7, -1
+  %t89 = select i1 %t88, i64 1, i64 7
+  %t90 = srem i64 %t86, %t89
+  %t91 = select i1 %t88, i64 0, i64 %t90
+  store i64 %t91, ptr %"v36.36.addr"
+  %t92 = load i64, ptr %"v32.32.addr"
+  %t93 = icmp eq i64 7, 0
+  br i1 %t93, label %error3, label %ok4
+error3:
+  call void @__runtime_error(ptr @.str.2, i64 38)
+  unreachable
+ok4:
+  %t94 = icmp eq i64 7, -1
+  %t95 = select i1 %t94, i64 1, i64 7
+  %t96 = srem i64 %t92, %t95
+  %t97 = select i1 %t94, i64 0, i64 %t96
+  store i64 %t97, ptr %"v37.37.addr"
+  %t98 = load i64, ptr %"v33.33.addr"
+  %t99 = icmp eq i64 7, 0
+  br i1 %t99, label %error5, label %ok6
+error5:
+  call void @__runtime_error(ptr @.str.3, i64 38)
+  unreachable
+ok6:
+  %t100 = icmp eq i64 7, -1
+  %t101 = select i1 %t100, i64 1, i64 7
+  %t102 = srem i64 %t98, %t101
+  %t103 = select i1 %t100, i64 0, i64 %t102
+  store i64 %t103, ptr %"v38.38.addr"
+  %t104 = load i64, ptr %"v34.34.addr"
+  %t105 = icmp eq i64 7, 0
+  br i1 %t105, label %error7, label %ok8
+error7:
+  call void @__runtime_error(ptr @.str.4, i64 38)
+  unreachable
+ok8:
+  %t106 = icmp eq i64 7, -1
+  %t107 = select i1 %t106, i64 1, i64 7
+  %t108 = srem i64 %t104, %t107
+  %t109 = select i1 %t106, i64 0, i64 %t108
+  store i64 %t109, ptr %"v39.39.addr"
+  %t110 = load i64, ptr %"v35.35.addr"
+  %t111 = icmp eq i64 7, 0
+  br i1 %t111, label %error9, label %ok10
+error9:
+  call void @__runtime_error(ptr @.str.5, i64 38)
+  unreachable
+ok10:
+  %t112 = icmp eq i64 7, -1
+  %t113 = select i1 %t112, i64 1, i64 7
+  %t114 = srem i64 %t110, %t113
+  %t115 = select i1 %t112, i64 0, i64 %t114
+  store i64 %t115, ptr %"v40.40.addr"
+  %t116 = load i64, ptr %"v1.1.addr"
+  %t117 = load i64, ptr %"v2.2.addr"
+  %t118 = load i64, ptr %"v3.3.addr"
+  %t119 = load i64, ptr %"v4.4.addr"
+  %t120 = load i64, ptr %"v5.5.addr"
+  %t121 = load i64, ptr %"v6.6.addr"
+  %t122 = load i64, ptr %"v7.7.addr"
+  %t123 = load i64, ptr %"v8.8.addr"
+  %t124 = load i64, ptr @__depth
+  %t125 = icmp sge i64 %t124, 1000
+  br i1 %t125, label %error11, label %ok12
+error11:
+  call void @__runtime_error(ptr @.str.6, i64 61)
+  unreachable
+ok12:
+  %t126 = add i64 %t124, 1
+  store i64 %t126, ptr @__depth
+  %t127 = call i64 @"fn.mix"(i64 %t116, i64 %t117, i64 %t118, i64 %t119, i64 %t120, i64 %t121, i64 %t122, i64 %t123)
+  store i64 %t124, ptr @__depth
+  %t128 = load i64, ptr %"v40.40.addr"
+  %t129 = load i64, ptr %"v39.39.addr"
+  %t130 = load i64, ptr %"v38.38.addr"
+  %t131 = load i64, ptr %"v37.37.addr"
+  %t132 = load i64, ptr %"v36.36.addr"
+  %t133 = load i64, ptr %"v35.35.addr"
+  %t134 = load i64, ptr %"v34.34.addr"
+  %t135 = load i64, ptr %"v33.33.addr"
+  %t136 = load i64, ptr @__depth
+  %t137 = icmp sge i64 %t136, 1000
+  br i1 %t137, label %error13, label %ok14
+error13:
+  call void @__runtime_error(ptr @.str.7, i64 61)
+  unreachable
+ok14:
+  %t138 = add i64 %t136, 1
+  store i64 %t138, ptr @__depth
+  %t139 = call i64 @"fn.mix"(i64 %t128, i64 %t129, i64 %t130, i64 %t131, i64 %t132, i64 %t133, i64 %t134, i64 %t135)
+  store i64 %t136, ptr @__depth
+  %t140 = add i64 %t127, %t139
+  store i64 %t140, ptr %"call.41.addr"
+  store i64 0, ptr %"s.42.addr"
+  store i64 0, ptr %"i.43.addr"
+  br label %for15
+for15:
+  %t141 = load i64, ptr %"i.43.addr"
+  %t142 = icmp slt i64 %t141, 3
+  br i1 %t142, label %body16, label %endfor18
+body16:
+  %t143 = load i64, ptr %"s.42.addr"
+  %t144 = load i64, ptr %"v1.1.addr"
+  %t145 = add i64 %t143, %t144
+  %t146 = load i64, ptr %"v2.2.addr"
+  %t147 = add i64 %t145, %t146
+  %t148 = load i64, ptr %"v3.3.addr"
+  %t149 = add i64 %t147, %t148
+  %t150 = load i64, ptr %"v4.4.addr"
+  %t151 = add i64 %t149, %t150
+  %t152 = load i64, ptr %"v5.5.addr"
+  %t153 = add i64 %t151, %t152
+  %t154 = load i64, ptr %"v6.6.addr"
+  %t155 = add i64 %t153, %t154
+  %t156 = load i64, ptr %"v7.7.addr"
+  %t157 = add i64 %t155, %t156
+  %t158 = load i64, ptr %"v8.8.addr"
+  %t159 = add i64 %t157, %t158
+  %t160 = load i64, ptr %"v9.9.addr"
+  %t161 = add i64 %t159, %t160
+  %t162 = load i64, ptr %"v10.10.addr"
+  %t163 = add i64 %t161, %t162
+  %t164 = load i64, ptr %"v11.11.addr"
+  %t165 = add i64 %t163, %t164
+  %t166 = load i64, ptr %"v12.12.addr"
+  %t167 = add i64 %t165, %t166
+  %t168 = load i64, ptr %"v13.13.addr"
+  %t169 = add i64 %t167, %t168
+  %t170 = load i64, ptr %"v14.14.addr"
+  %t171 = add i64 %t169, %t170
+  %t172 = load i64, ptr %"v15.15.addr"
+  %t173 = add i64 %t171, %t172
+  %t174 = load i64, ptr %"v16.16.addr"
+  %t175 = add i64 %t173, %t174
+  %t176 = load i64, ptr %"v17.17.addr"
+  %t177 = add i64 %t175, %t176
+  %t178 = load i64, ptr %"v18.18.addr"
+  %t179 = add i64 %t177, %t178
+  %t180 = load i64, ptr %"v19.19.addr"
+  %t181 = add i64 %t179, %t180
+  %t182 = load i64, ptr %"v20.20.addr"
+  %t183 = add i64 %t181, %t182
+  %t184 = load i64, ptr %"v21.21.addr"
+  %t185 = add i64 %t183, %t184
+  %t186 = load i64, ptr %"v22.22.addr"
+  %t187 = add i64 %t185, %t186
+  %t188 = load i64, ptr %"v23.23.addr"
+  %t189 = add i64 %t187, %t188
+  %t190 = load i64, ptr %"v24.24.addr"
+  %t191 = add i64 %t189, %t190
+  %t192 = load i64, ptr %"v25.25.addr"
+  %t193 = add i64 %t191, %t192
+  %t194 = load i64, ptr %"v26.26.addr"
+  %t195 = add i64 %t193, %t194
+  %t196 = load i64, ptr %"v27.27.addr"
+  %t197 = add i64 %t195, %t196
+  %t198 = load i64, ptr %"v28.28.addr"
+  %t199 = add i64 %t197, %t198
+  %t200 = load i64, ptr %"v29.29.addr"
+  %t201 = add i64 %t199, %t200
+  %t202 = load i64, ptr %"v30.30.addr"
+  %t203 = add i64 %t201, %t202
+  %t204 = load i64, ptr %"v31.31.addr"
+  %t205 = add i64 %t203, %t204
+  %t206 = load i64, ptr %"v32.32.addr"
+  %t207 = add i64 %t205, %t206
+  %t208 = load i64, ptr %"v33.33.addr"
+  %t209 = add i64 %t207, %t208
+  %t210 = load i64, ptr %"v34.34.addr"
+  %t211 = add i64 %t209, %t210
+  %t212 = load i64, ptr %"v35.35.addr"
+  %t213 = add i64 %t211, %t212
+  %t214 = load i64, ptr %"v36.36.addr"
+  %t215 = add i64 %t213, %t214
+  %t216 = load i64, ptr %"v37.37.addr"
+  %t217 = add i64 %t215, %t216
+  %t218 = load i64, ptr %"v38.38.addr"
+  %t219 = add i64 %t217, %t218
+  %t220 = load i64, ptr %"v39.39.addr"
+  %t221 = add i64 %t219, %t220
+  %t222 = load i64, ptr %"v40.40.addr"
+  %t223 = add i64 %t221, %t222
+  %t224 = load i64, ptr %"v1.1.addr"
+  %t225 = load i64, ptr %"v11.11.addr"
+  %t226 = load i64, ptr %"v21.21.addr"
+  %t227 = load i64, ptr %"v31.31.addr"
+  %t228 = load i64, ptr %"v40.40.addr"
+  %t229 = load i64, ptr %"v30.30.addr"
+  %t230 = load i64, ptr %"v20.20.addr"
+  %t231 = load i64, ptr %"v10.10.addr"
+  %t232 = load i64, ptr @__depth
+  %t233 = icmp sge i64 %t232, 1000
+  br i1 %t233, label %error19, label %ok20
+error19:
+  call void @__runtime_error(ptr @.str.8, i64 61)
+  unreachable
+ok20:
+  %t234 = add i64 %t232, 1
+  store i64 %t234, ptr @__depth
+  %t235 = call i64 @"fn.mix"(i64 %t224, i64 %t225, i64 %t226, i64 %t227, i64 %t228, i64 %t229, i64 %t230, i64 %t231)
+  store i64 %t232, ptr @__depth
+  %t236 = load i64, ptr %"i.43.addr"
+  %t237 = mul i64 %t235, %t236
+  %t238 = add i64 %t223, %t237
+  store i64 %t238, ptr %"s.42.addr"
+  br label %step17
+step17:
+  %t239 = load i64, ptr %"i.43.addr"
+  %t240 = add i64 %t239, 1
+  store i64 %t240, ptr %"i.43.addr"
+  br label %for15
+endfor18:
+  %t241 = load i64, ptr %"s.42.addr"
+  %t242 = mul i64 %t241, 1000
+  %t243 = load i64, ptr %"call.41.addr"
+  %t244 = add i64 %t242, %t243
+  %t245 = load i64, ptr %"v1.1.addr"
+  %t246 = load i64, ptr %"v40.40.addr"
+  %t247 = mul i64 %t245, %t246
+  %t248 = add i64 %t244, %t247
+  %t249 = load i64, ptr %"v20.20.addr"
+  %t250 = load i64, ptr %"v21.21.addr"
+  %t251 = mul i64 %t249, %t250
+  %t252 = sub i64 %t248, %t251
+  ret i64 %t252
+dead21:
+  ret i64 0
+}
+
+define i32 @main() {
+entry:
+  %t1 = load i64, ptr @__depth
+  %t2 = icmp sge i64 %t1, 1000
+  br i1 %t2, label %error1, label %ok2
+error1:
+  call void @__runtime_error(ptr @.str.9, i64 60)
+  unreachable
+ok2:
+  %t3 = add i64 %t1, 1
+  store i64 %t3, ptr @__depth
+  %t4 = call i64 @"fn.pressure"(i64 0)
+  store i64 %t1, ptr @__depth
+  %t5 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t4)
+  %t6 = load i64, ptr @__depth
+  %t7 = icmp sge i64 %t6, 1000
+  br i1 %t7, label %error3, label %ok4
+error3:
+  call void @__runtime_error(ptr @.str.10, i64 61)
+  unreachable
+ok4:
+  %t8 = add i64 %t6, 1
+  store i64 %t8, ptr @__depth
+  %t9 = call i64 @"fn.pressure"(i64 5)
+  store i64 %t6, ptr @__depth
+  %t10 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t9)
+  %t11 = sub i64 0, 3
+  %t12 = load i64, ptr @__depth
+  %t13 = icmp sge i64 %t12, 1000
+  br i1 %t13, label %error5, label %ok6
+error5:
+  call void @__runtime_error(ptr @.str.11, i64 61)
+  unreachable
+ok6:
+  %t14 = add i64 %t12, 1
+  store i64 %t14, ptr @__depth
+  %t15 = call i64 @"fn.pressure"(i64 %t11)
+  store i64 %t12, ptr @__depth
+  %t16 = call i32 (ptr, ...) @printf(ptr @.fmt.int, i64 %t15)
+  ret i32 0
+}
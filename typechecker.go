@@ -0,0 +1,284 @@
+package main
+
+import "fmt"
+
+// TypeChecker computes the type of every expression of a resolved AST and
+// records it with SetDataType. Implicit conversions, int to float and int to
+// bool in conditions, are made explicit by wrapping the converted expression
+// in a Conversion node, so that the interpreter and backends never see mixed
+// operand types.
+type TypeChecker struct {
+	function    *Symbol
+	diagnostics Diagnostics
+}
+
+func NewTypeChecker() *TypeChecker {
+	return &TypeChecker{}
+}
+
+func (c *TypeChecker) Check(root ASTNoder) Diagnostics {
+	c.function, c.diagnostics = nil, nil
+	c.statements(root)
+	return c.diagnostics
+}
+
+func (c *TypeChecker) report(code DiagnosticCode, message string, span Span) *Diagnostic {
+	d := NewDiagnostic(Severity_Error, code, message, span)
+	c.diagnostics = append(c.diagnostics, d)
+	return d
+}
+
+func (c *TypeChecker) statements(node ASTNoder) {
+	for _, child := range node.GetChildren() {
+		c.statement(child)
+	}
+}
+
+func (c *TypeChecker) statement(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_Block:
+		c.statements(node)
+	case ASTNodeType_FunctionDeclaration:
+		c.function = node.GetSymbol()
+		body := children[len(children)-1]
+		c.statement(body)
+		if c.function.Type != DataType_Void && completes(body) {
+			end := body.GetSpan().End
+			brace := Position{Offset: end.Offset - 1, Line: end.Line, Column: end.Column - 1}
+			c.report(DiagnosticCode_MissingReturn, fmt.Sprintf("function %s may end without returning a %s value", c.function.Name, c.function.Type), Span{Start: brace, End: end})
+		}
+		c.function = nil
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		if len(children) > 0 {
+			c.coerce(node, 0, declaredType(node), "initialize "+node.GetText()+" with")
+		}
+		node.SetDataType(declaredType(node))
+	case ASTNodeType_If, ASTNodeType_While:
+		c.condition(node, 0)
+		for _, child := range children[1:] {
+			c.statement(child)
+		}
+	case ASTNodeType_For:
+		c.statement(children[0])
+		if children[1].GetType() != ASTNodeType_Empty {
+			c.condition(node, 1)
+		}
+		c.statement(children[2])
+		c.statement(children[3])
+	case ASTNodeType_Return:
+		c.returnStatement(node)
+	case ASTNodeType_Print:
+		c.value(children[0])
+	case ASTNodeType_Break, ASTNodeType_Continue, ASTNodeType_Empty, ASTNodeType_Error:
+	default:
+		c.expression(node)
+	}
+}
+
+func (c *TypeChecker) returnStatement(node ASTNoder) {
+	expected := c.function.Type
+	if len(node.GetChildren()) == 0 {
+		if expected != DataType_Void {
+			c.report(DiagnosticCode_InvalidReturn, fmt.Sprintf("function %s must return a %s value", c.function.Name, expected), node.GetSpan())
+		}
+		return
+	}
+	if expected == DataType_Void {
+		c.report(DiagnosticCode_InvalidReturn, fmt.Sprintf("function %s does not return a value", c.function.Name), node.GetSpan())
+		c.expression(node.GetChildren()[0])
+		return
+	}
+	c.coerce(node, 0, expected, "return")
+}
+
+// completes reports whether running node may go on to the statement after
+// it, rather than always returning, breaking or looping forever. Only a
+// literal true condition makes a loop infinite.
+func completes(node ASTNoder) bool {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_Return, ASTNodeType_Break, ASTNodeType_Continue:
+		return false
+	case ASTNodeType_Block:
+		for _, child := range children {
+			if !completes(child) {
+				return false
+			}
+		}
+	case ASTNodeType_If:
+		return len(children) < 3 || completes(children[1]) || completes(children[2])
+	case ASTNodeType_While:
+		return !isTrue(children[0]) || breaks(children[1])
+	case ASTNodeType_For:
+		infinite := children[1].GetType() == ASTNodeType_Empty || isTrue(children[1])
+		return !infinite || breaks(children[3])
+	}
+	return true
+}
+
+func isTrue(node ASTNoder) bool {
+	return node.GetType() == ASTNodeType_BoolLiteral && node.GetText() == "true"
+}
+
+// breaks reports whether node has a break leaving the loop it is the body of.
+func breaks(node ASTNoder) bool {
+	switch node.GetType() {
+	case ASTNodeType_Break:
+		return true
+	case ASTNodeType_While, ASTNodeType_For:
+		return false
+	}
+	for _, child := range node.GetChildren() {
+		if breaks(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// expression checks an expression whose type may be void, which is only
+// allowed for a function call used as a statement.
+func (c *TypeChecker) expression(node ASTNoder) DataType {
+	t := c.check(node)
+	node.SetDataType(t)
+	return t
+}
+
+// value checks an expression that has to produce a value.
+func (c *TypeChecker) value(node ASTNoder) DataType {
+	t := c.expression(node)
+	if t == DataType_Void {
+		c.report(DiagnosticCode_VoidValue, "function "+node.GetText()+" does not return a value", node.GetSpan())
+		return DataType_Error
+	}
+	return t
+}
+
+func (c *TypeChecker) check(node ASTNoder) DataType {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral:
+		return DataType_Int
+	case ASTNodeType_FloatLiteral:
+		return DataType_Float
+	case ASTNodeType_BoolLiteral:
+		return DataType_Bool
+	case ASTNodeType_StringLiteral:
+		return DataType_String
+	case ASTNodeType_Identifier:
+		return node.GetSymbol().Type
+	case ASTNodeType_Assignment:
+		t := node.GetSymbol().Type
+		c.coerce(node, 0, t, "assign to "+node.GetText()+" a")
+		return t
+	case ASTNodeType_FunctionCall:
+		function := node.GetSymbol()
+		for i, param := range function.Params {
+			c.coerce(node, i, param.Type, fmt.Sprintf("pass to parameter %s of %s a", param.Name, function.Name))
+		}
+		return function.Type
+	case ASTNodeType_Conversion:
+		from := c.value(children[0])
+		to := node.GetDataType()
+		if from != DataType_Error && !convertible(from, to) {
+			c.report(DiagnosticCode_InvalidConversion, fmt.Sprintf("cannot convert %s to %s", from, to), node.GetSpan())
+		}
+		return to
+	case ASTNodeType_Unary:
+		if node.GetText() == "!" {
+			c.condition(node, 0)
+			return DataType_Bool
+		}
+		t := c.value(children[0])
+		if t != DataType_Error && !t.isNumeric() {
+			c.report(DiagnosticCode_InvalidOperand, fmt.Sprintf("operator %s is not defined on %s", node.GetText(), t), node.GetSpan())
+			return DataType_Error
+		}
+		return t
+	case ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		c.condition(node, 0)
+		c.condition(node, 1)
+		return DataType_Bool
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		return c.binary(node)
+	}
+	return DataType_Void
+}
+
+// binary unifies the operand types, widening an int operand next to a
+// float one, and returns the type of the operation.
+func (c *TypeChecker) binary(node ASTNoder) DataType {
+	children := node.GetChildren()
+	t1, t2 := c.value(children[0]), c.value(children[1])
+	if t1 == DataType_Error || t2 == DataType_Error {
+		return DataType_Error
+	}
+	if t1 == DataType_Int && t2 == DataType_Float {
+		c.convert(node, 0, DataType_Float)
+		t1 = DataType_Float
+	} else if t1 == DataType_Float && t2 == DataType_Int {
+		c.convert(node, 1, DataType_Float)
+		t2 = DataType_Float
+	}
+	operator := node.GetText()
+	valid := t1 == t2
+	switch node.GetType() {
+	case ASTNodeType_AddtiveExp:
+		valid = valid && (t1.isNumeric() || (t1 == DataType_String && operator == "+"))
+	case ASTNodeType_Multiplicative:
+		valid = valid && (t1 == DataType_Int || (t1 == DataType_Float && operator != "%"))
+	case ASTNodeType_Relational:
+		valid = valid && (t1.isNumeric() || t1 == DataType_String)
+	}
+	if !valid {
+		c.report(DiagnosticCode_InvalidOperand, fmt.Sprintf("operator %s is not defined on %s and %s", operator, t1, t2), node.GetSpan())
+		return DataType_Error
+	}
+	if node.GetType() == ASTNodeType_Relational || node.GetType() == ASTNodeType_Equality {
+		return DataType_Bool
+	}
+	return t1
+}
+
+// condition checks the child at index as a condition. An int condition is
+// true when it is not zero.
+func (c *TypeChecker) condition(parent ASTNoder, index int) {
+	child := parent.GetChildren()[index]
+	t := c.value(child)
+	if t == DataType_Int {
+		c.convert(parent, index, DataType_Bool)
+	} else if t != DataType_Bool && t != DataType_Error {
+		c.report(DiagnosticCode_TypeMismatch, fmt.Sprintf("condition must be bool, not %s", t), child.GetSpan())
+	}
+}
+
+// coerce checks that the child at index can be used where a value of type
+// to is expected, widening it from int to float if necessary.
+func (c *TypeChecker) coerce(parent ASTNoder, index int, to DataType, context string) {
+	child := parent.GetChildren()[index]
+	from := c.value(child)
+	switch {
+	case from == to || from == DataType_Error:
+	case from == DataType_Int && to == DataType_Float:
+		c.convert(parent, index, to)
+	default:
+		c.report(DiagnosticCode_TypeMismatch, fmt.Sprintf("cannot %s %s value, expected %s", context, from, to), child.GetSpan())
+	}
+}
+
+// convert wraps the child at index in an implicit Conversion node.
+func (c *TypeChecker) convert(parent ASTNoder, index int, to DataType) {
+	child := parent.GetChildren()[index]
+	node := NewASTNoder(ASTNodeType_Conversion, string(to), child.GetSpan())
+	node.AddChild(child)
+	node.SetDataType(to)
+	parent.SetChild(index, node)
+}
+
+// convertible lists the explicit conversions: between all value types, with
+// conversions from string checked when the program runs.
+func convertible(from, to DataType) bool {
+	valueTypes := map[DataType]bool{DataType_Int: true, DataType_Float: true, DataType_Bool: true, DataType_String: true}
+	return valueTypes[from] && valueTypes[to]
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestMissingReturn(t *testing.T) {
+	tests := []struct {
+		function string
+		missing  bool
+	}{
+		{"int f(int a) { if (a > 0) { return 1; } }", true},
+		{"int f(int a) { if (a > 0) { return 1; } else { return 2; } }", false},
+		{"int f(int a) { if (a > 0) return 1; return 2; }", false},
+		{"int f() { }", true},
+		{"int f() { { return 1; } }", false},
+		{"int f(int a) { while (a > 0) { return 1; } }", true},
+		{"int f() { while (true) { } }", false},
+		{"int f() { while (true) { break; } }", true},
+		{"int f() { while (true) { while (true) { break; } } }", false},
+		{"int f() { for (;;) { if (true) { return 1; } } }", false},
+		{"int f() { for (;;) { break; } }", true},
+		{"float f(int a) { if (a > 0) { return 1; } else if (a < 0) { return 2; } }", true},
+		{"function f() { }", false},
+	}
+	for _, test := range tests {
+		t.Run(test.function, func(t *testing.T) {
+			parser := SimpleParser{Origin: NewPosition()}
+			root, err := parser.Parse(test.function)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diagnostics := NewResolver().Resolve(*root)
+			diagnostics = append(diagnostics, NewTypeChecker().Check(*root)...)
+			missing := false
+			for _, d := range diagnostics {
+				if d.Code == DiagnosticCode_MissingReturn {
+					missing = true
+				} else {
+					t.Errorf("unexpected %v", d)
+				}
+			}
+			if missing != test.missing {
+				t.Errorf("missing return reported: %v, want %v", missing, test.missing)
+			}
+		})
+	}
+}
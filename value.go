@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type DataType string
+
+const (
+	DataType_Int    = DataType("int")
+	DataType_Float  = DataType("float")
+	DataType_Bool   = DataType("bool")
+	DataType_String = DataType("string")
+	DataType_Void   = DataType("void")
+	// DataType_Error marks an expression that already failed to type check,
+	// so that one mistake is not reported again by every enclosing node.
+	DataType_Error = DataType("<error>")
+)
+
+func (t DataType) isNumeric() bool {
+	return t == DataType_Int || t == DataType_Float
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+// Value is a tagged runtime value. Only the field matching Type is used.
+type Value struct {
+	Type  DataType
+	Int   int
+	Float float64
+	Bool  bool
+	Str   string
+}
+
+func IntValue(i int) Value {
+	return Value{Type: DataType_Int, Int: i}
+}
+
+func FloatValue(f float64) Value {
+	return Value{Type: DataType_Float, Float: f}
+}
+
+func BoolValue(b bool) Value {
+	return Value{Type: DataType_Bool, Bool: b}
+}
+
+func StringValue(s string) Value {
+	return Value{Type: DataType_String, Str: s}
+}
+
+// zeroValue is the value of a declared but uninitialized variable.
+func zeroValue(t DataType) Value {
+	return Value{Type: t}
+}
+
+func (v Value) String() string {
+	switch v.Type {
+	case DataType_Int:
+		return strconv.Itoa(v.Int)
+	case DataType_Float:
+		return formatFloat(v.Float)
+	case DataType_Bool:
+		return strconv.FormatBool(v.Bool)
+	case DataType_String:
+		return v.Str
+	}
+	return string(v.Type)
+}
+
+// formatFloat prints like C's "%g", so native backends can match it.
+func formatFloat(f float64) string {
+	return fmt.Sprintf("%.6g", f)
+}
+
+// convertValue implements explicit conversions, and the implicit int to
+// float widening inserted by the TypeChecker.
+func convertValue(v Value, to DataType) (Value, error) {
+	if v.Type == to {
+		return v, nil
+	}
+	switch to {
+	case DataType_String:
+		return StringValue(v.String()), nil
+	case DataType_Int:
+		switch v.Type {
+		case DataType_Float:
+			return IntValue(int(v.Float)), nil
+		case DataType_Bool:
+			if v.Bool {
+				return IntValue(1), nil
+			}
+			return IntValue(0), nil
+		case DataType_String:
+			i, err := strconv.Atoi(v.Str)
+			if err != nil {
+				return v, fmt.Errorf("cannot convert %q to int", v.Str)
+			}
+			return IntValue(i), nil
+		}
+	case DataType_Float:
+		switch v.Type {
+		case DataType_Int:
+			return FloatValue(float64(v.Int)), nil
+		case DataType_Bool:
+			if v.Bool {
+				return FloatValue(1), nil
+			}
+			return FloatValue(0), nil
+		case DataType_String:
+			f, err := strconv.ParseFloat(v.Str, 64)
+			if err != nil {
+				return v, fmt.Errorf("cannot convert %q to float", v.Str)
+			}
+			return FloatValue(f), nil
+		}
+	case DataType_Bool:
+		switch v.Type {
+		case DataType_Int:
+			return BoolValue(v.Int != 0), nil
+		case DataType_Float:
+			return BoolValue(v.Float != 0), nil
+		case DataType_String:
+			b, err := strconv.ParseBool(v.Str)
+			if err != nil {
+				return v, fmt.Errorf("cannot convert %q to bool", v.Str)
+			}
+			return BoolValue(b), nil
+		}
+	}
+	return v, fmt.Errorf("cannot convert %s to %s", v.Type, to)
+}
+
+// binaryOp applies an arithmetic, relational or equality operator. Both
+// operands have the same type, as guaranteed by the TypeChecker.
+func binaryOp(operator string, a, b Value) (Value, error) {
+	switch operator {
+	case "==":
+		return BoolValue(a == b), nil
+	case "!=":
+		return BoolValue(a != b), nil
+	}
+	switch a.Type {
+	case DataType_Int:
+		switch operator {
+		case "+":
+			return IntValue(a.Int + b.Int), nil
+		case "-":
+			return IntValue(a.Int - b.Int), nil
+		case "*":
+			return IntValue(a.Int * b.Int), nil
+		case "/", "%":
+			if b.Int == 0 {
+				return a, errDivisionByZero
+			}
+			if operator == "/" {
+				return IntValue(a.Int / b.Int), nil
+			}
+			return IntValue(a.Int % b.Int), nil
+		case "<":
+			return BoolValue(a.Int < b.Int), nil
+		case "<=":
+			return BoolValue(a.Int <= b.Int), nil
+		case ">":
+			return BoolValue(a.Int > b.Int), nil
+		case ">=":
+			return BoolValue(a.Int >= b.Int), nil
+		}
+	case DataType_Float:
+		switch operator {
+		case "+":
+			return FloatValue(a.Float + b.Float), nil
+		case "-":
+			return FloatValue(a.Float - b.Float), nil
+		case "*":
+			return FloatValue(a.Float * b.Float), nil
+		case "/":
+			return FloatValue(a.Float / b.Float), nil
+		case "<":
+			return BoolValue(a.Float < b.Float), nil
+		case "<=":
+			return BoolValue(a.Float <= b.Float), nil
+		case ">":
+			return BoolValue(a.Float > b.Float), nil
+		case ">=":
+			return BoolValue(a.Float >= b.Float), nil
+		}
+	case DataType_String:
+		switch operator {
+		case "+":
+			return StringValue(a.Str + b.Str), nil
+		case "<":
+			return BoolValue(a.Str < b.Str), nil
+		case "<=":
+			return BoolValue(a.Str <= b.Str), nil
+		case ">":
+			return BoolValue(a.Str > b.Str), nil
+		case ">=":
+			return BoolValue(a.Str >= b.Str), nil
+		}
+	}
+	return a, fmt.Errorf("operator %s is not defined on %s", operator, a.Type)
+}
+
+func unaryOp(operator string, v Value) Value {
+	switch {
+	case operator == "!":
+		return BoolValue(!v.Bool)
+	case operator == "-" && v.Type == DataType_Int:
+		return IntValue(-v.Int)
+	case operator == "-":
+		return FloatValue(-v.Float)
+	}
+	return v
+}
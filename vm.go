@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// vmFrame is the activation record of a chunk being run. Its parameters and
+// locals are the stack slots starting at base.
+type vmFrame struct {
+	chunk *Chunk
+	ip    int
+	base  int
+}
+
+// VM runs the bytecode produced by a BytecodeCompiler on a value stack.
+// Globals are kept between calls to Run.
+type VM struct {
+	globals      []Value
+	stack        []Value
+	frames       []*vmFrame
+	MaxCallDepth int
+	verbose      bool
+}
+
+func NewVM(verbose bool) *VM {
+	return &VM{MaxCallDepth: DefaultMaxCallDepth, verbose: verbose}
+}
+
+// Run executes chunk, calling into functions. It returns the last value
+// echoed by a top-level statement. Runtime errors are reported as a
+// *Diagnostic.
+func (vm *VM) Run(chunk *Chunk, functions []*Chunk) (result Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			result, err = Value{}, d
+			vm.stack, vm.frames = nil, nil
+		}
+	}()
+	vm.stack, vm.frames = vm.stack[:0], []*vmFrame{{chunk: chunk}}
+	return vm.run(functions), nil
+}
+
+func (vm *VM) fail(frame *vmFrame, code DiagnosticCode, message string) {
+	panic(NewDiagnostic(Severity_Error, code, message, frame.chunk.Spans[frame.ip]))
+}
+
+func (vm *VM) push(v Value) {
+	vm.stack = append(vm.stack, v)
+}
+
+func (vm *VM) pop() Value {
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v
+}
+
+func (vm *VM) global(index int) *Value {
+	for len(vm.globals) <= index {
+		vm.globals = append(vm.globals, Value{})
+	}
+	return &vm.globals[index]
+}
+
+func (vm *VM) run(functions []*Chunk) Value {
+	var result Value
+	for {
+		frame := vm.frames[len(vm.frames)-1]
+		chunk := frame.chunk
+		op := OpCode(chunk.Code[frame.ip])
+		operand := 0
+		switch operandSize(op) {
+		case 1:
+			operand = int(chunk.Code[frame.ip+1])
+		case 2:
+			operand = chunk.read16(frame.ip + 1)
+		}
+		if vm.verbose {
+			fmt.Printf("%v\t", vm.stack)
+			disassembleInstruction(os.Stdout, chunk, functions, frame.ip)
+		}
+		next := frame.ip + 1 + operandSize(op)
+		switch op {
+		case OpCode_Const:
+			vm.push(chunk.Constants[operand])
+		case OpCode_Pop:
+			vm.pop()
+		case OpCode_Dup:
+			vm.push(vm.stack[len(vm.stack)-1])
+		case OpCode_LoadGlobal:
+			vm.push(*vm.global(operand))
+		case OpCode_StoreGlobal:
+			*vm.global(operand) = vm.pop()
+		case OpCode_LoadLocal:
+			vm.push(vm.stack[frame.base+operand])
+		case OpCode_StoreLocal:
+			vm.stack[frame.base+operand] = vm.pop()
+		case OpCode_Add, OpCode_Sub, OpCode_Mul, OpCode_Div, OpCode_Mod,
+			OpCode_LT, OpCode_LE, OpCode_GT, OpCode_GE, OpCode_EQ, OpCode_NE:
+			b, a := vm.pop(), vm.pop()
+			v, err := binaryOp(binaryOperators[op], a, b)
+			if err == errDivisionByZero {
+				vm.fail(frame, DiagnosticCode_DivisionByZero, err.Error())
+			} else if err != nil {
+				vm.fail(frame, DiagnosticCode_InvalidNode, err.Error())
+			}
+			vm.push(v)
+		case OpCode_Neg:
+			vm.push(unaryOp("-", vm.pop()))
+		case OpCode_Not:
+			vm.push(unaryOp("!", vm.pop()))
+		case OpCode_Convert:
+			v, err := convertValue(vm.pop(), conversionTypes[operand])
+			if err != nil {
+				vm.fail(frame, DiagnosticCode_ConversionError, err.Error())
+			}
+			vm.push(v)
+		case OpCode_Jump:
+			next = operand
+		case OpCode_JumpIfFalse:
+			if !vm.pop().Bool {
+				next = operand
+			}
+		case OpCode_JumpIfTrue:
+			if vm.pop().Bool {
+				next = operand
+			}
+		case OpCode_Call:
+			if len(vm.frames) > vm.MaxCallDepth {
+				vm.fail(frame, DiagnosticCode_StackOverflow, fmt.Sprintf("stack overflow: call depth exceeds %d", vm.MaxCallDepth))
+			}
+			callee := functions[operand]
+			base := len(vm.stack) - callee.Params
+			for i := callee.Params; i < callee.Locals; i++ {
+				vm.push(Value{})
+			}
+			frame.ip = next
+			vm.frames = append(vm.frames, &vmFrame{chunk: callee, base: base})
+			continue
+		case OpCode_Return:
+			v := vm.pop()
+			vm.stack = append(vm.stack[:frame.base], v)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			continue
+		case OpCode_Trace:
+			fmt.Println("varName: ", chunk.Constants[operand].Str, "  varValue: ", vm.stack[len(vm.stack)-1])
+		case OpCode_Echo:
+			v := vm.pop()
+			if name := chunk.Constants[operand].Str; name != "" {
+				fmt.Println(name, "result:", v)
+			} else if v.Type != "" && v.Type != DataType_Void {
+				fmt.Println("result:", v)
+			}
+			result = v
+		case OpCode_Print:
+			fmt.Println(vm.pop())
+		case OpCode_Halt:
+			return result
+		default:
+			vm.fail(frame, DiagnosticCode_InvalidNode, "invalid opcode "+op.String())
+		}
+		frame.ip = next
+	}
+}
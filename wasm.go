@@ -0,0 +1,629 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// WebAssembly value types, block types and section ids.
+const (
+	wasmI32   = 0x7f
+	wasmI64   = 0x7e
+	wasmF64   = 0x7c
+	wasmEmpty = 0x40
+	wasmFunc  = 0x60
+
+	wasmSectionType     = 1
+	wasmSectionImport   = 2
+	wasmSectionFunction = 3
+	wasmSectionGlobal   = 6
+	wasmSectionExport   = 7
+	wasmSectionCode     = 10
+)
+
+// The WebAssembly instructions used by the WasmGenerator.
+const (
+	wasmUnreachable    = 0x00
+	wasmBlock          = 0x02
+	wasmLoop           = 0x03
+	wasmIf             = 0x04
+	wasmElse           = 0x05
+	wasmEnd            = 0x0b
+	wasmBr             = 0x0c
+	wasmBrIf           = 0x0d
+	wasmReturn         = 0x0f
+	wasmCall           = 0x10
+	wasmDrop           = 0x1a
+	wasmLocalGet       = 0x20
+	wasmLocalSet       = 0x21
+	wasmGlobalGet      = 0x23
+	wasmGlobalSet      = 0x24
+	wasmI32Const       = 0x41
+	wasmI64Const       = 0x42
+	wasmF64Const       = 0x44
+	wasmI32Eqz         = 0x45
+	wasmI32Eq          = 0x46
+	wasmI32Ne          = 0x47
+	wasmI64Eqz         = 0x50
+	wasmI64Eq          = 0x51
+	wasmI64Ne          = 0x52
+	wasmI64LtS         = 0x53
+	wasmI64GtS         = 0x55
+	wasmI64LeS         = 0x57
+	wasmI64GeS         = 0x59
+	wasmF64Eq          = 0x61
+	wasmF64Ne          = 0x62
+	wasmF64Lt          = 0x63
+	wasmF64Gt          = 0x64
+	wasmF64Le          = 0x65
+	wasmF64Ge          = 0x66
+	wasmI64Add         = 0x7c
+	wasmI64Sub         = 0x7d
+	wasmI64Mul         = 0x7e
+	wasmI64DivS        = 0x7f
+	wasmI64RemS        = 0x81
+	wasmF64Neg         = 0x9a
+	wasmF64Add         = 0xa0
+	wasmF64Sub         = 0xa1
+	wasmF64Mul         = 0xa2
+	wasmF64Div         = 0xa3
+	wasmI64ExtendI32U  = 0xad
+	wasmI64TruncF64S   = 0xb0
+	wasmF64ConvertI32U = 0xb8
+	wasmF64ConvertI64S = 0xb9
+)
+
+// wasmImports are the host functions imported from the module "env" to
+// print values. They take the first function indices.
+var wasmImports = []struct {
+	name  string
+	param byte
+}{
+	{"print_int", wasmI64},
+	{"print_float", wasmF64},
+	{"print_bool", wasmI32},
+}
+
+// wasmOperators maps the binary operators to their instruction for i64, f64
+// and i32 operands.
+var wasmOperators = map[string][3]byte{
+	"+":  {wasmI64Add, wasmF64Add, 0},
+	"-":  {wasmI64Sub, wasmF64Sub, 0},
+	"*":  {wasmI64Mul, wasmF64Mul, 0},
+	"/":  {wasmI64DivS, wasmF64Div, 0},
+	"%":  {wasmI64RemS, 0, 0},
+	"<":  {wasmI64LtS, wasmF64Lt, 0},
+	"<=": {wasmI64LeS, wasmF64Le, 0},
+	">":  {wasmI64GtS, wasmF64Gt, 0},
+	">=": {wasmI64GeS, wasmF64Ge, 0},
+	"==": {wasmI64Eq, wasmF64Eq, wasmI32Eq},
+	"!=": {wasmI64Ne, wasmF64Ne, wasmI32Ne},
+}
+
+func appendUleb(b []byte, v uint64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendSleb(b []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendName(b []byte, name string) []byte {
+	return append(appendUleb(b, uint64(len(name))), name...)
+}
+
+// wasmLoopLabels records the label depths that break and continue branch to.
+type wasmLoopLabels struct {
+	breakDepth    int
+	continueDepth int
+}
+
+// WasmGenerator encodes an AST annotated by a Resolver and a TypeChecker as a
+// WebAssembly module. Global variables are Wasm globals, parameters and
+// local variables are Wasm locals numbered like the Resolver numbers them.
+// The top-level statements make up the exported function main. Ints are
+// i64, floats f64 and bools i32; strings are not supported. Division by
+// zero and too deep recursion trap.
+type WasmGenerator struct {
+	types     []WasmFuncType
+	functions []uint32
+	bodies    [][]byte
+	globals   []byte
+	index     map[*Symbol]uint32
+	global    map[*Symbol]uint32
+	code      []byte
+	function  *Symbol
+	scratch   uint32
+	depth     int
+	loops     []wasmLoopLabels
+}
+
+func NewWasmGenerator() *WasmGenerator {
+	return &WasmGenerator{}
+}
+
+// Generate returns the binary module of the whole program root.
+func (g *WasmGenerator) Generate(root ASTNoder) (module []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			module, err = nil, d
+		}
+	}()
+	g.types, g.functions, g.bodies, g.globals = nil, nil, nil, nil
+	g.index = make(map[*Symbol]uint32)
+	g.global = make(map[*Symbol]uint32)
+	for _, imported := range wasmImports {
+		g.typeIndex([]byte{imported.param}, nil)
+	}
+	var declarations []ASTNoder
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			g.index[child.GetSymbol()] = uint32(len(wasmImports) + len(declarations))
+			declarations = append(declarations, child)
+		}
+	}
+	g.declareGlobals(root)
+	for _, node := range declarations {
+		g.functionDeclare(node)
+	}
+	main := uint32(len(wasmImports) + len(declarations))
+	g.begin(nil, nil, 0)
+	g.statements(root)
+	g.finish(nil)
+	return g.encode(main), nil
+}
+
+func (g *WasmGenerator) unsupported(node ASTNoder, t DataType) {
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_Unsupported, fmt.Sprintf("the wasm backend does not support %s values", t), node.GetSpan()))
+}
+
+func (g *WasmGenerator) valueType(node ASTNoder, t DataType) byte {
+	switch t {
+	case DataType_Int:
+		return wasmI64
+	case DataType_Float:
+		return wasmF64
+	case DataType_Bool:
+		return wasmI32
+	}
+	g.unsupported(node, t)
+	return 0
+}
+
+// results is the result type list of a function returning t.
+func (g *WasmGenerator) results(node ASTNoder, t DataType) []byte {
+	if t == DataType_Void {
+		return nil
+	}
+	return []byte{g.valueType(node, t)}
+}
+
+// typeIndex returns the index of the function type, adding it if needed.
+func (g *WasmGenerator) typeIndex(params, results []byte) uint32 {
+	for i, t := range g.types {
+		if bytes.Equal(t.Params, params) && bytes.Equal(t.Results, results) {
+			return uint32(i)
+		}
+	}
+	g.types = append(g.types, WasmFuncType{Params: params, Results: results})
+	return uint32(len(g.types) - 1)
+}
+
+func (g *WasmGenerator) declareGlobals(node ASTNoder) {
+	if isVariableDeclaration(node.GetType()) && node.GetSymbol().IsGlobal() {
+		g.global[node.GetSymbol()] = uint32(len(g.globals))
+		g.globals = append(g.globals, g.valueType(node, node.GetSymbol().Type))
+	}
+	for _, child := range node.GetChildren() {
+		g.declareGlobals(child)
+	}
+}
+
+// localTypes fills in the types of the local variables declared in node.
+func (g *WasmGenerator) localTypes(node ASTNoder, types []byte) {
+	if isVariableDeclaration(node.GetType()) {
+		types[node.GetSymbol().Index] = g.valueType(node, node.GetSymbol().Type)
+	}
+	for _, child := range node.GetChildren() {
+		g.localTypes(child, types)
+	}
+}
+
+func (g *WasmGenerator) functionDeclare(node ASTNoder) {
+	symbol := node.GetSymbol()
+	types := make([]byte, symbol.Locals)
+	for i, param := range symbol.Params {
+		types[i] = g.valueType(param.Decl, param.Type)
+	}
+	children := node.GetChildren()
+	body := children[len(children)-1]
+	g.localTypes(body, types)
+	g.function = symbol
+	g.begin(types[:len(symbol.Params)], g.results(node, symbol.Type), len(types))
+	g.statement(body)
+	if symbol.Type != DataType_Void {
+		g.zero(symbol.Type)
+	}
+	g.finish(types[len(symbol.Params):])
+	g.function = nil
+}
+
+// begin starts the code of a function with the given signature. Two i64
+// scratch locals follow the numLocals locals of the function.
+func (g *WasmGenerator) begin(params, results []byte, numLocals int) {
+	g.functions = append(g.functions, g.typeIndex(params, results))
+	g.code = nil
+	g.scratch = uint32(numLocals)
+	g.depth = 0
+}
+
+// finish ends the code of the function, whose locals after the parameters
+// have the given types.
+func (g *WasmGenerator) finish(locals []byte) {
+	locals = append(locals, wasmI64, wasmI64)
+	var body []byte
+	var groups [][2]int
+	for i, t := range locals {
+		if i > 0 && locals[i-1] == t {
+			groups[len(groups)-1][0]++
+		} else {
+			groups = append(groups, [2]int{1, int(t)})
+		}
+	}
+	body = appendUleb(body, uint64(len(groups)))
+	for _, group := range groups {
+		body = appendUleb(body, uint64(group[0]))
+		body = append(body, byte(group[1]))
+	}
+	body = append(body, g.code...)
+	body = append(body, wasmEnd)
+	g.bodies = append(g.bodies, body)
+}
+
+func (g *WasmGenerator) emit(ops ...byte) {
+	g.code = append(g.code, ops...)
+}
+
+func (g *WasmGenerator) emitIndex(op byte, index uint32) {
+	g.code = appendUleb(append(g.code, op), uint64(index))
+}
+
+// open starts a structured instruction, which adds a branch label.
+func (g *WasmGenerator) open(op byte, blockType byte) {
+	g.emit(op, blockType)
+	g.depth++
+}
+
+func (g *WasmGenerator) close() {
+	g.emit(wasmEnd)
+	g.depth--
+}
+
+func (g *WasmGenerator) zero(t DataType) {
+	switch t {
+	case DataType_Int:
+		g.emit(wasmI64Const, 0)
+	case DataType_Float:
+		g.f64Const(0)
+	default:
+		g.emit(wasmI32Const, 0)
+	}
+}
+
+func (g *WasmGenerator) f64Const(f float64) {
+	g.emit(wasmF64Const)
+	g.code = binary.LittleEndian.AppendUint64(g.code, math.Float64bits(f))
+}
+
+func (g *WasmGenerator) load(symbol *Symbol) {
+	if symbol.IsGlobal() {
+		g.emitIndex(wasmGlobalGet, g.global[symbol])
+	} else {
+		g.emitIndex(wasmLocalGet, uint32(symbol.Index))
+	}
+}
+
+func (g *WasmGenerator) store(symbol *Symbol) {
+	if symbol.IsGlobal() {
+		g.emitIndex(wasmGlobalSet, g.global[symbol])
+	} else {
+		g.emitIndex(wasmLocalSet, uint32(symbol.Index))
+	}
+}
+
+func (g *WasmGenerator) statements(node ASTNoder) {
+	for _, child := range node.GetChildren() {
+		g.statement(child)
+	}
+}
+
+func (g *WasmGenerator) statement(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_FunctionDeclaration, ASTNodeType_Empty:
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		if len(children) > 0 {
+			g.expression(children[0])
+		} else {
+			g.valueType(node, declaredType(node))
+			g.zero(declaredType(node))
+		}
+		g.store(node.GetSymbol())
+	case ASTNodeType_Assignment:
+		g.expression(children[0])
+		g.store(node.GetSymbol())
+	case ASTNodeType_Block:
+		g.statements(node)
+	case ASTNodeType_If:
+		g.expression(children[0])
+		g.open(wasmIf, wasmEmpty)
+		g.statement(children[1])
+		if len(children) > 2 {
+			g.emit(wasmElse)
+			g.statement(children[2])
+		}
+		g.close()
+	case ASTNodeType_While:
+		g.open(wasmBlock, wasmEmpty)
+		g.open(wasmLoop, wasmEmpty)
+		g.expression(children[0])
+		g.emit(wasmI32Eqz)
+		g.emitIndex(wasmBrIf, 1)
+		g.loopBody(children[1], g.depth-1, g.depth)
+		g.emitIndex(wasmBr, 0)
+		g.close()
+		g.close()
+	case ASTNodeType_For:
+		g.statement(children[0])
+		g.open(wasmBlock, wasmEmpty)
+		g.open(wasmLoop, wasmEmpty)
+		if children[1].GetType() != ASTNodeType_Empty {
+			g.expression(children[1])
+			g.emit(wasmI32Eqz)
+			g.emitIndex(wasmBrIf, 1)
+		}
+		// continue leaves this block to run the step.
+		g.open(wasmBlock, wasmEmpty)
+		g.loopBody(children[3], g.depth-2, g.depth)
+		g.close()
+		g.statement(children[2])
+		g.emitIndex(wasmBr, 0)
+		g.close()
+		g.close()
+	case ASTNodeType_Break:
+		g.emitIndex(wasmBr, uint32(g.depth-g.loops[len(g.loops)-1].breakDepth))
+	case ASTNodeType_Continue:
+		g.emitIndex(wasmBr, uint32(g.depth-g.loops[len(g.loops)-1].continueDepth))
+	case ASTNodeType_Return:
+		if len(children) > 0 {
+			g.expression(children[0])
+		}
+		g.emit(wasmReturn)
+	case ASTNodeType_Print:
+		g.expression(children[0])
+		for i, imported := range wasmImports {
+			if imported.param == g.valueType(children[0], children[0].GetDataType()) {
+				g.emitIndex(wasmCall, uint32(i))
+			}
+		}
+	case ASTNodeType_Error:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, "cannot compile a statement with syntax errors", node.GetSpan()))
+	default:
+		g.expression(node)
+		if node.GetDataType() != DataType_Void {
+			g.emit(wasmDrop)
+		}
+	}
+}
+
+// loopBody compiles the body of a loop whose break and continue labels are
+// at the given depths.
+func (g *WasmGenerator) loopBody(node ASTNoder, breakDepth, continueDepth int) {
+	g.loops = append(g.loops, wasmLoopLabels{breakDepth, continueDepth})
+	g.statement(node)
+	g.loops = g.loops[:len(g.loops)-1]
+}
+
+func (g *WasmGenerator) expression(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_FloatLiteral, ASTNodeType_BoolLiteral, ASTNodeType_StringLiteral:
+		v := node.GetValue()
+		switch v.Type {
+		case DataType_Int:
+			g.code = appendSleb(append(g.code, wasmI64Const), int64(v.Int))
+		case DataType_Float:
+			g.f64Const(v.Float)
+		case DataType_Bool:
+			if v.Bool {
+				g.emit(wasmI32Const, 1)
+			} else {
+				g.emit(wasmI32Const, 0)
+			}
+		default:
+			g.unsupported(node, v.Type)
+		}
+	case ASTNodeType_Identifier:
+		g.load(node.GetSymbol())
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		g.binary(node)
+	case ASTNodeType_LogicalAnd:
+		g.expression(children[0])
+		g.open(wasmIf, wasmI32)
+		g.expression(children[1])
+		g.emit(wasmElse, wasmI32Const, 0)
+		g.close()
+	case ASTNodeType_LogicalOr:
+		g.expression(children[0])
+		g.open(wasmIf, wasmI32)
+		g.emit(wasmI32Const, 1)
+		g.emit(wasmElse)
+		g.expression(children[1])
+		g.close()
+	case ASTNodeType_Unary:
+		switch {
+		case node.GetText() == "!":
+			g.expression(children[0])
+			g.emit(wasmI32Eqz)
+		case node.GetText() == "-" && node.GetDataType() == DataType_Float:
+			g.expression(children[0])
+			g.emit(wasmF64Neg)
+		case node.GetText() == "-":
+			g.emit(wasmI64Const, 0)
+			g.expression(children[0])
+			g.emit(wasmI64Sub)
+		default:
+			g.expression(children[0])
+		}
+	case ASTNodeType_Conversion:
+		g.conversion(node)
+	case ASTNodeType_FunctionCall:
+		for _, arg := range children {
+			g.expression(arg)
+		}
+		g.emitIndex(wasmCall, g.index[node.GetSymbol()])
+	default:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, fmt.Sprintf("cannot compile %s as an expression", node.GetType()), node.GetSpan()))
+	}
+}
+
+func (g *WasmGenerator) binary(node ASTNoder) {
+	children := node.GetChildren()
+	operandType := g.valueType(children[0], children[0].GetDataType())
+	g.expression(children[0])
+	g.expression(children[1])
+	operator := node.GetText()
+	ops := wasmOperators[operator]
+	switch {
+	case operandType == wasmF64:
+		g.emit(ops[1])
+	case operandType == wasmI32:
+		g.emit(ops[2])
+	case operator == "/" || operator == "%":
+		// The minimum int divided by -1 traps in Wasm but not in the
+		// interpreter: negate, or return 0 for the remainder, instead.
+		left, right := g.scratch, g.scratch+1
+		g.emitIndex(wasmLocalSet, right)
+		g.emitIndex(wasmLocalSet, left)
+		g.emitIndex(wasmLocalGet, right)
+		g.emit(wasmI64Const, 0x7f, wasmI64Eq)
+		g.open(wasmIf, wasmI64)
+		if operator == "/" {
+			g.emit(wasmI64Const, 0)
+			g.emitIndex(wasmLocalGet, left)
+			g.emit(wasmI64Sub)
+		} else {
+			g.emit(wasmI64Const, 0)
+		}
+		g.emit(wasmElse)
+		g.emitIndex(wasmLocalGet, left)
+		g.emitIndex(wasmLocalGet, right)
+		g.emit(ops[0])
+		g.close()
+	default:
+		g.emit(ops[0])
+	}
+}
+
+func (g *WasmGenerator) conversion(node ASTNoder) {
+	child := node.GetChildren()[0]
+	from := g.valueType(child, child.GetDataType())
+	to := g.valueType(node, node.GetDataType())
+	g.expression(child)
+	switch {
+	case from == to:
+	case from == wasmI64 && to == wasmF64:
+		g.emit(wasmF64ConvertI64S)
+	case from == wasmF64 && to == wasmI64:
+		g.emit(wasmI64TruncF64S)
+	case from == wasmI64 && to == wasmI32:
+		g.emit(wasmI64Eqz, wasmI32Eqz)
+	case from == wasmF64 && to == wasmI32:
+		g.f64Const(0)
+		g.emit(wasmF64Ne)
+	case from == wasmI32 && to == wasmI64:
+		g.emit(wasmI64ExtendI32U)
+	case from == wasmI32 && to == wasmF64:
+		g.emit(wasmF64ConvertI32U)
+	}
+}
+
+// encode writes the module sections in their required order.
+func (g *WasmGenerator) encode(main uint32) []byte {
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	section := func(id byte, count int, content []byte) {
+		payload := appendUleb(nil, uint64(count))
+		payload = append(payload, content...)
+		module = append(module, id)
+		module = appendUleb(module, uint64(len(payload)))
+		module = append(module, payload...)
+	}
+
+	var types []byte
+	for _, t := range g.types {
+		types = append(types, wasmFunc)
+		types = append(appendUleb(types, uint64(len(t.Params))), t.Params...)
+		types = append(appendUleb(types, uint64(len(t.Results))), t.Results...)
+	}
+	section(wasmSectionType, len(g.types), types)
+
+	var imports []byte
+	for i, imported := range wasmImports {
+		imports = appendName(appendName(imports, "env"), imported.name)
+		imports = appendUleb(append(imports, 0x00), uint64(i))
+	}
+	section(wasmSectionImport, len(wasmImports), imports)
+
+	var functions []byte
+	for _, t := range g.functions {
+		functions = appendUleb(functions, uint64(t))
+	}
+	section(wasmSectionFunction, len(g.functions), functions)
+
+	if len(g.globals) > 0 {
+		var globals []byte
+		for _, t := range g.globals {
+			globals = append(globals, t, 0x01)
+			switch t {
+			case wasmI64:
+				globals = append(globals, wasmI64Const, 0)
+			case wasmF64:
+				globals = append(globals, wasmF64Const, 0, 0, 0, 0, 0, 0, 0, 0)
+			default:
+				globals = append(globals, wasmI32Const, 0)
+			}
+			globals = append(globals, wasmEnd)
+		}
+		section(wasmSectionGlobal, len(g.globals), globals)
+	}
+
+	exports := appendUleb(append(appendName(nil, "main"), 0x00), uint64(main))
+	section(wasmSectionExport, 1, exports)
+
+	var code []byte
+	for _, body := range g.bodies {
+		code = append(appendUleb(code, uint64(len(body))), body...)
+	}
+	section(wasmSectionCode, len(g.bodies), code)
+	return module
+}
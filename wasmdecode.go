@@ -0,0 +1,731 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+type WasmFuncType struct {
+	Params  []byte
+	Results []byte
+}
+
+type WasmImport struct {
+	Module string
+	Name   string
+	Type   uint32
+}
+
+type WasmGlobal struct {
+	Type    byte
+	Mutable bool
+}
+
+type WasmExport struct {
+	Name  string
+	Kind  byte
+	Index uint32
+}
+
+type WasmCode struct {
+	Locals []byte
+	Body   []byte
+}
+
+// WasmModule is a decoded WebAssembly module. Only the sections and
+// instructions produced by the WasmGenerator are supported.
+type WasmModule struct {
+	Types     []WasmFuncType
+	Imports   []WasmImport
+	Functions []uint32
+	Globals   []WasmGlobal
+	Exports   []WasmExport
+	Codes     []WasmCode
+}
+
+// funcType returns the type of the function index, imports first.
+func (m *WasmModule) funcType(index uint32) (WasmFuncType, bool) {
+	if int(index) < len(m.Imports) {
+		return m.Types[m.Imports[index].Type], true
+	}
+	index -= uint32(len(m.Imports))
+	if int(index) < len(m.Functions) {
+		return m.Types[m.Functions[index]], true
+	}
+	return WasmFuncType{}, false
+}
+
+// wasmReader reads the primitive encodings of the binary format.
+type wasmReader struct {
+	data   []byte
+	offset int
+}
+
+var errWasmEOF = errors.New("unexpected end of module")
+
+func (r *wasmReader) done() bool {
+	return r.offset >= len(r.data)
+}
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.done() {
+		return 0, errWasmEOF
+	}
+	r.offset++
+	return r.data[r.offset-1], nil
+}
+
+func (r *wasmReader) bytes(n uint64) ([]byte, error) {
+	if uint64(len(r.data)-r.offset) < n {
+		return nil, errWasmEOF
+	}
+	r.offset += int(n)
+	return r.data[r.offset-int(n) : r.offset], nil
+}
+
+func (r *wasmReader) uleb(bits uint) (uint64, error) {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		c, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits {
+			return 0, fmt.Errorf("integer too long at offset %d", r.offset-1)
+		}
+		v |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, nil
+		}
+	}
+}
+
+func (r *wasmReader) u32() (uint32, error) {
+	v, err := r.uleb(35)
+	if v > math.MaxUint32 {
+		return 0, fmt.Errorf("integer too large at offset %d", r.offset)
+	}
+	return uint32(v), err
+}
+
+func (r *wasmReader) sleb(bits uint) (int64, error) {
+	var v int64
+	shift := uint(0)
+	for {
+		c, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits {
+			return 0, fmt.Errorf("integer too long at offset %d", r.offset-1)
+		}
+		v |= int64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v, nil
+		}
+	}
+}
+
+func (r *wasmReader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(uint64(n))
+	return string(b), err
+}
+
+func (r *wasmReader) valueType() (byte, error) {
+	t, err := r.byte()
+	if err == nil && t != wasmI32 && t != wasmI64 && t != wasmF64 {
+		err = fmt.Errorf("unsupported value type 0x%02x at offset %d", t, r.offset-1)
+	}
+	return t, err
+}
+
+func (r *wasmReader) valueTypes() ([]byte, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	types := make([]byte, 0, n)
+	for i := uint32(0); i < n; i++ {
+		t, err := r.valueType()
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// vector reads a count and calls item that many times.
+func (r *wasmReader) vector(item func() error) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if err := item(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeWasm decodes and validates a binary module.
+func DecodeWasm(data []byte) (*WasmModule, error) {
+	r := &wasmReader{data: data}
+	header, err := r.bytes(8)
+	if err != nil || !bytes.Equal(header, []byte("\x00asm\x01\x00\x00\x00")) {
+		return nil, errors.New("not a WebAssembly version 1 module")
+	}
+	m := &WasmModule{}
+	last := byte(0)
+	for !r.done() {
+		id, _ := r.byte()
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes(uint64(size))
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
+			continue
+		}
+		if id <= last {
+			return nil, fmt.Errorf("section %d out of order", id)
+		}
+		last = id
+		section := &wasmReader{data: content}
+		if err := m.decodeSection(id, section); err != nil {
+			return nil, fmt.Errorf("section %d: %v", id, err)
+		}
+		if !section.done() {
+			return nil, fmt.Errorf("section %d: %d bytes left over", id, len(content)-section.offset)
+		}
+	}
+	if len(m.Functions) != len(m.Codes) {
+		return nil, fmt.Errorf("%d functions but %d code entries", len(m.Functions), len(m.Codes))
+	}
+	for i, code := range m.Codes {
+		if err := m.validateCode(uint32(i), code); err != nil {
+			return nil, fmt.Errorf("function %d: %v", len(m.Imports)+i, err)
+		}
+	}
+	return m, nil
+}
+
+func (m *WasmModule) decodeSection(id byte, r *wasmReader) error {
+	switch id {
+	case wasmSectionType:
+		return r.vector(func() error {
+			form, err := r.byte()
+			if err != nil {
+				return err
+			}
+			if form != wasmFunc {
+				return fmt.Errorf("unsupported type form 0x%02x", form)
+			}
+			var t WasmFuncType
+			if t.Params, err = r.valueTypes(); err != nil {
+				return err
+			}
+			if t.Results, err = r.valueTypes(); err != nil {
+				return err
+			}
+			if len(t.Results) > 1 {
+				return errors.New("multiple results are not supported")
+			}
+			m.Types = append(m.Types, t)
+			return nil
+		})
+	case wasmSectionImport:
+		return r.vector(func() error {
+			var imported WasmImport
+			var err error
+			if imported.Module, err = r.name(); err != nil {
+				return err
+			}
+			if imported.Name, err = r.name(); err != nil {
+				return err
+			}
+			if kind, err := r.byte(); err != nil || kind != 0x00 {
+				return fmt.Errorf("import %s.%s: only functions can be imported", imported.Module, imported.Name)
+			}
+			if imported.Type, err = m.typeIndex(r); err != nil {
+				return err
+			}
+			m.Imports = append(m.Imports, imported)
+			return nil
+		})
+	case wasmSectionFunction:
+		return r.vector(func() error {
+			t, err := m.typeIndex(r)
+			m.Functions = append(m.Functions, t)
+			return err
+		})
+	case wasmSectionGlobal:
+		return r.vector(func() error {
+			t, err := r.valueType()
+			if err != nil {
+				return err
+			}
+			mutable, err := r.byte()
+			if err != nil || mutable > 1 {
+				return errors.New("invalid global mutability")
+			}
+			if err := m.validateConstant(r, t); err != nil {
+				return err
+			}
+			m.Globals = append(m.Globals, WasmGlobal{Type: t, Mutable: mutable == 1})
+			return nil
+		})
+	case wasmSectionExport:
+		names := make(map[string]bool)
+		return r.vector(func() error {
+			var export WasmExport
+			var err error
+			if export.Name, err = r.name(); err != nil {
+				return err
+			}
+			if names[export.Name] {
+				return fmt.Errorf("duplicate export %s", export.Name)
+			}
+			names[export.Name] = true
+			if export.Kind, err = r.byte(); err != nil {
+				return err
+			}
+			if export.Index, err = r.u32(); err != nil {
+				return err
+			}
+			switch export.Kind {
+			case 0x00:
+				if _, ok := m.funcType(export.Index); !ok {
+					return fmt.Errorf("export %s: unknown function %d", export.Name, export.Index)
+				}
+			case 0x03:
+				if int(export.Index) >= len(m.Globals) {
+					return fmt.Errorf("export %s: unknown global %d", export.Name, export.Index)
+				}
+			default:
+				return fmt.Errorf("export %s: unsupported kind %d", export.Name, export.Kind)
+			}
+			m.Exports = append(m.Exports, export)
+			return nil
+		})
+	case wasmSectionCode:
+		return r.vector(func() error {
+			size, err := r.u32()
+			if err != nil {
+				return err
+			}
+			body, err := r.bytes(uint64(size))
+			if err != nil {
+				return err
+			}
+			code := &wasmReader{data: body}
+			var locals []byte
+			err = code.vector(func() error {
+				n, err := code.u32()
+				if err != nil {
+					return err
+				}
+				t, err := code.valueType()
+				if uint64(len(locals))+uint64(n) > 50000 {
+					return errors.New("too many locals")
+				}
+				for i := uint32(0); i < n; i++ {
+					locals = append(locals, t)
+				}
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			m.Codes = append(m.Codes, WasmCode{Locals: locals, Body: body[code.offset:]})
+			return nil
+		})
+	}
+	return errors.New("unsupported section")
+}
+
+func (m *WasmModule) typeIndex(r *wasmReader) (uint32, error) {
+	t, err := r.u32()
+	if err == nil && int(t) >= len(m.Types) {
+		err = fmt.Errorf("unknown type %d", t)
+	}
+	return t, err
+}
+
+// validateConstant checks the initializer of a global of type t.
+func (m *WasmModule) validateConstant(r *wasmReader, t byte) error {
+	op, err := r.byte()
+	if err != nil {
+		return err
+	}
+	var actual byte
+	switch op {
+	case wasmI32Const:
+		actual, err = wasmI32, r.skipSleb(32)
+	case wasmI64Const:
+		actual, err = wasmI64, r.skipSleb(64)
+	case wasmF64Const:
+		actual = wasmF64
+		_, err = r.bytes(8)
+	default:
+		return fmt.Errorf("unsupported constant instruction 0x%02x", op)
+	}
+	if err != nil {
+		return err
+	}
+	if actual != t {
+		return errors.New("global initializer has the wrong type")
+	}
+	if end, err := r.byte(); err != nil || end != wasmEnd {
+		return errors.New("global initializer is not a single constant")
+	}
+	return nil
+}
+
+func (r *wasmReader) skipSleb(bits uint) error {
+	_, err := r.sleb(bits)
+	return err
+}
+
+// wasmControl is an entry of the control stack of the validator.
+type wasmControl struct {
+	op          byte
+	result      []byte
+	height      int
+	unreachable bool
+}
+
+// wasmValidator type checks a function body with an operand stack of value
+// types, following the validation algorithm of the specification.
+type wasmValidator struct {
+	module   *WasmModule
+	locals   []byte
+	operands []byte
+	controls []wasmControl
+}
+
+// wasmAnyType marks an operand popped from the stack of unreachable code.
+const wasmAnyType = 0
+
+func (v *wasmValidator) push(types ...byte) {
+	v.operands = append(v.operands, types...)
+}
+
+func (v *wasmValidator) pop(expected byte) error {
+	frame := &v.controls[len(v.controls)-1]
+	if len(v.operands) == frame.height {
+		if frame.unreachable {
+			return nil
+		}
+		return errors.New("operand stack underflow")
+	}
+	actual := v.operands[len(v.operands)-1]
+	v.operands = v.operands[:len(v.operands)-1]
+	if actual != expected && actual != wasmAnyType && expected != wasmAnyType {
+		return fmt.Errorf("expected %s operand, found %s", wasmTypeName(expected), wasmTypeName(actual))
+	}
+	return nil
+}
+
+func (v *wasmValidator) popAll(types []byte) error {
+	for i := len(types) - 1; i >= 0; i-- {
+		if err := v.pop(types[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *wasmValidator) open(op byte, result []byte) {
+	v.controls = append(v.controls, wasmControl{op: op, result: result, height: len(v.operands)})
+}
+
+func (v *wasmValidator) close() (wasmControl, error) {
+	frame := v.controls[len(v.controls)-1]
+	if err := v.popAll(frame.result); err != nil {
+		return frame, err
+	}
+	if len(v.operands) != frame.height {
+		return frame, errors.New("values left on the operand stack at the end of a block")
+	}
+	v.controls = v.controls[:len(v.controls)-1]
+	return frame, nil
+}
+
+// unreachable marks the rest of the current block as unreachable after an
+// unconditional branch.
+func (v *wasmValidator) unreachable() {
+	frame := &v.controls[len(v.controls)-1]
+	v.operands = v.operands[:frame.height]
+	frame.unreachable = true
+}
+
+// labelTypes are the types a branch to the label depth carries: none for a
+// loop, the results otherwise.
+func (v *wasmValidator) labelTypes(depth uint32) ([]byte, error) {
+	if int(depth) >= len(v.controls) {
+		return nil, fmt.Errorf("unknown label %d", depth)
+	}
+	frame := v.controls[len(v.controls)-1-int(depth)]
+	if frame.op == wasmLoop {
+		return nil, nil
+	}
+	return frame.result, nil
+}
+
+// wasmSignatures are the operand and result types of the simple
+// instructions.
+var wasmSignatures = map[byte][2][]byte{
+	wasmI32Eqz:         {{wasmI32}, {wasmI32}},
+	wasmI32Eq:          {{wasmI32, wasmI32}, {wasmI32}},
+	wasmI32Ne:          {{wasmI32, wasmI32}, {wasmI32}},
+	wasmI64Eqz:         {{wasmI64}, {wasmI32}},
+	wasmI64Eq:          {{wasmI64, wasmI64}, {wasmI32}},
+	wasmI64Ne:          {{wasmI64, wasmI64}, {wasmI32}},
+	wasmI64LtS:         {{wasmI64, wasmI64}, {wasmI32}},
+	wasmI64GtS:         {{wasmI64, wasmI64}, {wasmI32}},
+	wasmI64LeS:         {{wasmI64, wasmI64}, {wasmI32}},
+	wasmI64GeS:         {{wasmI64, wasmI64}, {wasmI32}},
+	wasmF64Eq:          {{wasmF64, wasmF64}, {wasmI32}},
+	wasmF64Ne:          {{wasmF64, wasmF64}, {wasmI32}},
+	wasmF64Lt:          {{wasmF64, wasmF64}, {wasmI32}},
+	wasmF64Gt:          {{wasmF64, wasmF64}, {wasmI32}},
+	wasmF64Le:          {{wasmF64, wasmF64}, {wasmI32}},
+	wasmF64Ge:          {{wasmF64, wasmF64}, {wasmI32}},
+	wasmI64Add:         {{wasmI64, wasmI64}, {wasmI64}},
+	wasmI64Sub:         {{wasmI64, wasmI64}, {wasmI64}},
+	wasmI64Mul:         {{wasmI64, wasmI64}, {wasmI64}},
+	wasmI64DivS:        {{wasmI64, wasmI64}, {wasmI64}},
+	wasmI64RemS:        {{wasmI64, wasmI64}, {wasmI64}},
+	wasmF64Neg:         {{wasmF64}, {wasmF64}},
+	wasmF64Add:         {{wasmF64, wasmF64}, {wasmF64}},
+	wasmF64Sub:         {{wasmF64, wasmF64}, {wasmF64}},
+	wasmF64Mul:         {{wasmF64, wasmF64}, {wasmF64}},
+	wasmF64Div:         {{wasmF64, wasmF64}, {wasmF64}},
+	wasmI64ExtendI32U:  {{wasmI32}, {wasmI64}},
+	wasmI64TruncF64S:   {{wasmF64}, {wasmI64}},
+	wasmF64ConvertI32U: {{wasmI32}, {wasmF64}},
+	wasmF64ConvertI64S: {{wasmI64}, {wasmF64}},
+}
+
+func (m *WasmModule) validateCode(index uint32, code WasmCode) error {
+	t := m.Types[m.Functions[index]]
+	v := &wasmValidator{module: m, locals: append(append([]byte(nil), t.Params...), code.Locals...)}
+	v.open(wasmBlock, t.Results)
+	r := &wasmReader{data: code.Body}
+	for len(v.controls) > 0 {
+		offset := r.offset
+		op, err := r.byte()
+		if err != nil {
+			return errors.New("missing end of function")
+		}
+		if err := v.instruction(op, r); err != nil {
+			return fmt.Errorf("offset %d, instruction 0x%02x: %v", offset, op, err)
+		}
+	}
+	if !r.done() {
+		return errors.New("code after the end of the function")
+	}
+	return nil
+}
+
+func (v *wasmValidator) instruction(op byte, r *wasmReader) error {
+	if signature, ok := wasmSignatures[op]; ok {
+		if err := v.popAll(signature[0]); err != nil {
+			return err
+		}
+		v.push(signature[1]...)
+		return nil
+	}
+	switch op {
+	case wasmUnreachable:
+		v.unreachable()
+	case wasmBlock, wasmLoop, wasmIf:
+		blockType, err := r.byte()
+		if err != nil {
+			return err
+		}
+		var result []byte
+		switch blockType {
+		case wasmEmpty:
+		case wasmI32, wasmI64, wasmF64:
+			result = []byte{blockType}
+		default:
+			return fmt.Errorf("unsupported block type 0x%02x", blockType)
+		}
+		if op == wasmIf {
+			if err := v.pop(wasmI32); err != nil {
+				return err
+			}
+		}
+		v.open(op, result)
+	case wasmElse:
+		frame, err := v.close()
+		if err != nil {
+			return err
+		}
+		if frame.op != wasmIf {
+			return errors.New("else without if")
+		}
+		v.open(wasmElse, frame.result)
+	case wasmEnd:
+		frame, err := v.close()
+		if err != nil {
+			return err
+		}
+		if frame.op == wasmIf && len(frame.result) > 0 {
+			return errors.New("if with a result needs an else")
+		}
+		v.push(frame.result...)
+	case wasmBr, wasmBrIf:
+		depth, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if op == wasmBrIf {
+			if err := v.pop(wasmI32); err != nil {
+				return err
+			}
+		}
+		types, err := v.labelTypes(depth)
+		if err != nil {
+			return err
+		}
+		if err := v.popAll(types); err != nil {
+			return err
+		}
+		if op == wasmBr {
+			v.unreachable()
+		} else {
+			v.push(types...)
+		}
+	case wasmReturn:
+		if err := v.popAll(v.controls[0].result); err != nil {
+			return err
+		}
+		v.unreachable()
+	case wasmCall:
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		t, ok := v.module.funcType(index)
+		if !ok {
+			return fmt.Errorf("unknown function %d", index)
+		}
+		if err := v.popAll(t.Params); err != nil {
+			return err
+		}
+		v.push(t.Results...)
+	case wasmDrop:
+		return v.pop(wasmAnyType)
+	case wasmLocalGet, wasmLocalSet:
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if int(index) >= len(v.locals) {
+			return fmt.Errorf("unknown local %d", index)
+		}
+		if op == wasmLocalGet {
+			v.push(v.locals[index])
+		} else {
+			return v.pop(v.locals[index])
+		}
+	case wasmGlobalGet, wasmGlobalSet:
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if int(index) >= len(v.module.Globals) {
+			return fmt.Errorf("unknown global %d", index)
+		}
+		global := v.module.Globals[index]
+		if op == wasmGlobalGet {
+			v.push(global.Type)
+		} else if !global.Mutable {
+			return fmt.Errorf("global %d is immutable", index)
+		} else {
+			return v.pop(global.Type)
+		}
+	case wasmI32Const:
+		v.push(wasmI32)
+		return r.skipSleb(32)
+	case wasmI64Const:
+		v.push(wasmI64)
+		return r.skipSleb(64)
+	case wasmF64Const:
+		v.push(wasmF64)
+		_, err := r.bytes(8)
+		return err
+	default:
+		return errors.New("unsupported instruction")
+	}
+	return nil
+}
+
+func wasmTypeName(t byte) string {
+	switch t {
+	case wasmI32:
+		return "i32"
+	case wasmI64:
+		return "i64"
+	case wasmF64:
+		return "f64"
+	}
+	return "any"
+}
+
+// Dump prints a summary of the sections of the module.
+func (m *WasmModule) Dump(w io.Writer) {
+	signature := func(t WasmFuncType) string {
+		var params, results []string
+		for _, p := range t.Params {
+			params = append(params, wasmTypeName(p))
+		}
+		for _, r := range t.Results {
+			results = append(results, wasmTypeName(r))
+		}
+		return fmt.Sprintf("(%s) -> (%s)", strings.Join(params, ", "), strings.Join(results, ", "))
+	}
+	fmt.Fprintf(w, "types: %d\n", len(m.Types))
+	for i, t := range m.Types {
+		fmt.Fprintf(w, "  type %d %s\n", i, signature(t))
+	}
+	fmt.Fprintf(w, "imports: %d\n", len(m.Imports))
+	for i, imported := range m.Imports {
+		fmt.Fprintf(w, "  func %d %s.%s type %d\n", i, imported.Module, imported.Name, imported.Type)
+	}
+	fmt.Fprintf(w, "functions: %d\n", len(m.Functions))
+	for i, t := range m.Functions {
+		fmt.Fprintf(w, "  func %d type %d, %d locals, %d bytes of code\n", len(m.Imports)+i, t, len(m.Codes[i].Locals), len(m.Codes[i].Body))
+	}
+	fmt.Fprintf(w, "globals: %d\n", len(m.Globals))
+	for i, global := range m.Globals {
+		mutability := "const"
+		if global.Mutable {
+			mutability = "mut"
+		}
+		fmt.Fprintf(w, "  global %d %s %s\n", i, mutability, wasmTypeName(global.Type))
+	}
+	fmt.Fprintf(w, "exports: %d\n", len(m.Exports))
+	for _, export := range m.Exports {
+		fmt.Fprintf(w, "  %s kind %d index %d\n", export.Name, export.Kind, export.Index)
+	}
+}
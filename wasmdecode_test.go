@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// declaredFunctions counts the function declarations of the program file.
+func declaredFunctions(t *testing.T, file string) int {
+	t.Helper()
+	source, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := SimpleParser{Origin: NewPosition()}
+	root, err := parser.Parse(string(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, node := range (*root).GetChildren() {
+		if node.GetType() == ASTNodeType_FunctionDeclaration {
+			count++
+		}
+	}
+	return count
+}
+
+func TestWasmSections(t *testing.T) {
+	for _, file := range samples(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			module, err := DecodeWasm(build(t, "wasm", file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(module.Types) == 0 {
+				t.Fatal("no type section")
+			}
+			if len(module.Imports) != len(wasmImports) {
+				t.Fatalf("%d imports, want %d", len(module.Imports), len(wasmImports))
+			}
+			for i, imported := range module.Imports {
+				want := WasmFuncType{Params: []byte{wasmImports[i].param}}
+				if imported.Module != "env" || imported.Name != wasmImports[i].name || !sameFuncType(module.Types[imported.Type], want) {
+					t.Errorf("import %d is %+v", i, imported)
+				}
+			}
+			functions := declaredFunctions(t, file) + 1
+			if len(module.Functions) != functions || len(module.Codes) != functions {
+				t.Fatalf("%d functions and %d bodies, want %d", len(module.Functions), len(module.Codes), functions)
+			}
+			for i, typeIndex := range module.Functions {
+				if int(typeIndex) >= len(module.Types) {
+					t.Errorf("function %d has type %d of %d", i, typeIndex, len(module.Types))
+				}
+			}
+			if len(module.Exports) != 1 {
+				t.Fatalf("%d exports, want only main", len(module.Exports))
+			}
+			main := module.Exports[0]
+			if main.Name != "main" || main.Kind != 0 || int(main.Index) != len(module.Imports)+functions-1 {
+				t.Errorf("export %+v, want the function main last", main)
+			}
+			if mainType, ok := module.funcType(main.Index); !ok || !sameFuncType(mainType, WasmFuncType{}) {
+				t.Errorf("main has type %+v, want no parameter nor result", mainType)
+			}
+		})
+	}
+}
+
+func sameFuncType(a, b WasmFuncType) bool {
+	return bytes.Equal(a.Params, b.Params) && bytes.Equal(a.Results, b.Results)
+}
+
+func TestWasmInvalidModules(t *testing.T) {
+	module := build(t, "wasm", filepath.Join("testdata", "functions.ss"))
+	if _, err := DecodeWasm(module); err != nil {
+		t.Fatal(err)
+	}
+	// The type section comes first, right after the header.
+	swapped := append([]byte(nil), module...)
+	swapped[8] = wasmSectionCode
+	// The last body ends the module; make its final end a nop.
+	unterminated := append([]byte(nil), module...)
+	unterminated[len(unterminated)-1] = 0x01
+	tests := map[string][]byte{
+		"empty":             nil,
+		"bad magic":         append([]byte("\x00wasm"), module[5:]...),
+		"truncated":         module[:len(module)-3],
+		"section order":     swapped,
+		"trailing garbage":  append(append([]byte(nil), module...), 0x0b),
+		"unterminated body": unterminated,
+	}
+	for name, data := range tests {
+		if _, err := DecodeWasm(data); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// x86Loop holds the labels that break and continue jump to.
+type x86Loop struct {
+	breakLabel    string
+	continueLabel string
+}
+
+// X86Generator translates an AST annotated by a Resolver and a TypeChecker to
+// x86-64 GNU assembly for Linux. The program is linked without libc: output
+// and exit go through raw system calls. Code is generated for a stack
+// machine: every expression leaves its value in %rax, and the left operand
+// of a binary operator waits on the stack while the right one is computed.
+// Only int and bool values are supported.
+type X86Generator struct {
+	MaxCallDepth int
+	out          strings.Builder
+	data         strings.Builder
+	labels       int
+	functions    map[*Symbol]string
+	function     *Symbol
+	returnLabel  string
+	loops        []x86Loop
+}
+
+func NewX86Generator() *X86Generator {
+	return &X86Generator{MaxCallDepth: DefaultMaxCallDepth}
+}
+
+// Generate returns the assembly of the whole program root.
+func (g *X86Generator) Generate(root ASTNoder) (asm string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			asm, err = "", d
+		}
+	}()
+	g.out.Reset()
+	g.data.Reset()
+	g.labels = 0
+	g.functions = make(map[*Symbol]string)
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			g.functions[child.GetSymbol()] = fmt.Sprintf("fn_%d", len(g.functions))
+		}
+	}
+
+	g.emit("\t.text")
+	g.emit("\t.globl _start")
+	g.emit("_start:")
+	for _, child := range root.GetChildren() {
+		g.statement(child)
+	}
+	g.emit("\tmovq $60, %%rax")
+	g.emit("\txorq %%rdi, %%rdi")
+	g.emit("\tsyscall")
+	for _, child := range root.GetChildren() {
+		if child.GetType() == ASTNodeType_FunctionDeclaration {
+			g.functionDeclare(child)
+		}
+	}
+	g.emitRuntime(globalCount(root))
+	return g.out.String(), nil
+}
+
+// emitRuntime emits the runtime routines, the messages of runtime errors
+// and the storage of the globals.
+func (g *X86Generator) emitRuntime(globals int) {
+	g.out.WriteString(x86Runtime)
+	g.emit("\t.section .rodata")
+	g.out.WriteString(g.data.String())
+	g.emit("\t.bss")
+	g.emit("\t.align 8")
+	g.emit("__globals:")
+	g.emit("\t.zero %d", 8*(globals+1))
+	g.emit("__depth:")
+	g.emit("\t.zero 8")
+	g.emit("__buffer:")
+	g.emit("\t.zero 32")
+}
+
+// globalCount is the number of global slots used by root.
+func globalCount(root ASTNoder) int {
+	count := 0
+	if symbol := root.GetSymbol(); symbol != nil && symbol.Kind != SymbolKind_Function && symbol.IsGlobal() && symbol.Index >= count {
+		count = symbol.Index + 1
+	}
+	for _, child := range root.GetChildren() {
+		if n := globalCount(child); n > count {
+			count = n
+		}
+	}
+	return count
+}
+
+func (g *X86Generator) emit(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format+"\n", args...)
+}
+
+func (g *X86Generator) label() string {
+	g.labels++
+	return fmt.Sprintf(".L%d", g.labels)
+}
+
+func (g *X86Generator) unsupported(node ASTNoder, t DataType) {
+	panic(NewDiagnostic(Severity_Error, DiagnosticCode_Unsupported, fmt.Sprintf("the native backend does not support %s values", t), node.GetSpan()))
+}
+
+func (g *X86Generator) checkType(node ASTNoder, t DataType) {
+	if t == DataType_Float || t == DataType_String {
+		g.unsupported(node, t)
+	}
+}
+
+// runtimeError jumps to a routine reporting d on the standard error and
+// exiting with status 1 when the flags satisfy the condition jump.
+func (g *X86Generator) runtimeError(jump string, d *Diagnostic) {
+	message := d.Error() + "\n"
+	g.labels++
+	label := fmt.Sprintf("__message%d", g.labels)
+	fmt.Fprintf(&g.data, "%s:\n\t.ascii %s\n", label, asmString(message))
+	ok := g.label()
+	g.emit("\t%s %s", invertJump[jump], ok)
+	g.emit("\tleaq %s(%%rip), %%rsi", label)
+	g.emit("\tmovq $%d, %%rdx", len(message))
+	g.emit("\tjmp __runtime_error")
+	g.emit("%s:", ok)
+}
+
+var invertJump = map[string]string{"je": "jne", "jne": "je", "jge": "jl"}
+
+// asmString quotes s for the .ascii directive.
+func asmString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// address is the memory operand of a variable or parameter. Locals are
+// below %rbp, globals in __globals.
+func address(symbol *Symbol) string {
+	if symbol.IsGlobal() {
+		return fmt.Sprintf("__globals+%d(%%rip)", 8*symbol.Index)
+	}
+	return fmt.Sprintf("%d(%%rbp)", -8*(symbol.Index+1))
+}
+
+// functionDeclare emits a function. Arguments are pushed by the caller from
+// left to right and copied to local slots on entry.
+func (g *X86Generator) functionDeclare(node ASTNoder) {
+	symbol := node.GetSymbol()
+	g.checkType(node, symbol.Type)
+	g.function = symbol
+	g.returnLabel = g.label()
+	g.emit("%s:\t# %s", g.functions[symbol], symbol.Name)
+	g.emit("\tpushq %%rbp")
+	g.emit("\tmovq %%rsp, %%rbp")
+	if symbol.Locals > 0 {
+		g.emit("\tsubq $%d, %%rsp", 8*symbol.Locals)
+	}
+	g.emit("\tincq __depth(%%rip)")
+	for i, param := range symbol.Params {
+		g.checkType(param.Decl, param.Type)
+		g.emit("\tmovq %d(%%rbp), %%rax", 16+8*(len(symbol.Params)-1-i))
+		g.emit("\tmovq %%rax, %s", address(param))
+	}
+	children := node.GetChildren()
+	g.statement(children[len(children)-1])
+	g.emit("\txorq %%rax, %%rax")
+	g.emit("%s:", g.returnLabel)
+	g.emit("\tdecq __depth(%%rip)")
+	g.emit("\tleave")
+	g.emit("\tret")
+	g.function = nil
+}
+
+func (g *X86Generator) statement(node ASTNoder) {
+	children := node.GetChildren()
+	switch node.GetType() {
+	case ASTNodeType_FunctionDeclaration, ASTNodeType_Empty:
+	case ASTNodeType_IntDeclaration, ASTNodeType_FloatDeclaration, ASTNodeType_BoolDeclaration, ASTNodeType_StringDeclaration:
+		g.checkType(node, declaredType(node))
+		if len(children) > 0 {
+			g.expression(children[0])
+		} else {
+			g.emit("\txorq %%rax, %%rax")
+		}
+		g.emit("\tmovq %%rax, %s", address(node.GetSymbol()))
+	case ASTNodeType_Block:
+		for _, child := range children {
+			g.statement(child)
+		}
+	case ASTNodeType_If:
+		elseLabel, end := g.label(), g.label()
+		g.expression(children[0])
+		g.emit("\ttestq %%rax, %%rax")
+		g.emit("\tje %s", elseLabel)
+		g.statement(children[1])
+		g.emit("\tjmp %s", end)
+		g.emit("%s:", elseLabel)
+		if len(children) > 2 {
+			g.statement(children[2])
+		}
+		g.emit("%s:", end)
+	case ASTNodeType_While:
+		start, end := g.label(), g.label()
+		g.emit("%s:", start)
+		g.expression(children[0])
+		g.emit("\ttestq %%rax, %%rax")
+		g.emit("\tje %s", end)
+		g.loopBody(children[1], end, start)
+		g.emit("\tjmp %s", start)
+		g.emit("%s:", end)
+	case ASTNodeType_For:
+		start, step, end := g.label(), g.label(), g.label()
+		g.statement(children[0])
+		g.emit("%s:", start)
+		if children[1].GetType() != ASTNodeType_Empty {
+			g.expression(children[1])
+			g.emit("\ttestq %%rax, %%rax")
+			g.emit("\tje %s", end)
+		}
+		g.loopBody(children[3], end, step)
+		g.emit("%s:", step)
+		g.statement(children[2])
+		g.emit("\tjmp %s", start)
+		g.emit("%s:", end)
+	case ASTNodeType_Break:
+		g.emit("\tjmp %s", g.loops[len(g.loops)-1].breakLabel)
+	case ASTNodeType_Continue:
+		g.emit("\tjmp %s", g.loops[len(g.loops)-1].continueLabel)
+	case ASTNodeType_Return:
+		if len(children) > 0 {
+			g.expression(children[0])
+		} else {
+			g.emit("\txorq %%rax, %%rax")
+		}
+		g.emit("\tjmp %s", g.returnLabel)
+	case ASTNodeType_Print:
+		g.expression(children[0])
+		if children[0].GetDataType() == DataType_Bool {
+			g.emit("\tcall __print_bool")
+		} else {
+			g.emit("\tcall __print_int")
+		}
+	case ASTNodeType_Error:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, "cannot compile a statement with syntax errors", node.GetSpan()))
+	default:
+		g.expression(node)
+	}
+}
+
+func (g *X86Generator) loopBody(node ASTNoder, breakLabel, continueLabel string) {
+	g.loops = append(g.loops, x86Loop{breakLabel, continueLabel})
+	g.statement(node)
+	g.loops = g.loops[:len(g.loops)-1]
+}
+
+var x86Conditions = map[string]string{"<": "l", "<=": "le", ">": "g", ">=": "ge", "==": "e", "!=": "ne"}
+
+// expression leaves the value of node in %rax. Bools are 0 or 1.
+func (g *X86Generator) expression(node ASTNoder) {
+	children := node.GetChildren()
+	g.checkType(node, node.GetDataType())
+	switch node.GetType() {
+	case ASTNodeType_IntLiteral, ASTNodeType_BoolLiteral:
+		v := node.GetValue()
+		if v.Bool {
+			v.Int = 1
+		}
+		if int(int32(v.Int)) == v.Int {
+			g.emit("\tmovq $%d, %%rax", v.Int)
+		} else {
+			g.emit("\tmovabsq $%d, %%rax", v.Int)
+		}
+	case ASTNodeType_Identifier:
+		g.emit("\tmovq %s, %%rax", address(node.GetSymbol()))
+	case ASTNodeType_Assignment:
+		g.expression(children[0])
+		g.emit("\tmovq %%rax, %s", address(node.GetSymbol()))
+	case ASTNodeType_AddtiveExp, ASTNodeType_Multiplicative, ASTNodeType_Relational, ASTNodeType_Equality:
+		g.binary(node)
+	case ASTNodeType_LogicalAnd, ASTNodeType_LogicalOr:
+		end := g.label()
+		g.expression(children[0])
+		g.emit("\ttestq %%rax, %%rax")
+		if node.GetType() == ASTNodeType_LogicalAnd {
+			g.emit("\tje %s", end)
+		} else {
+			g.emit("\tjne %s", end)
+		}
+		g.expression(children[1])
+		g.emit("%s:", end)
+	case ASTNodeType_Unary:
+		g.expression(children[0])
+		switch node.GetText() {
+		case "-":
+			g.emit("\tnegq %%rax")
+		case "!":
+			g.emit("\txorq $1, %%rax")
+		}
+	case ASTNodeType_Conversion:
+		g.expression(children[0])
+		if node.GetDataType() == DataType_Bool && children[0].GetDataType() == DataType_Int {
+			g.emit("\ttestq %%rax, %%rax")
+			g.emit("\tsetne %%al")
+			g.emit("\tmovzbq %%al, %%rax")
+		}
+	case ASTNodeType_FunctionCall:
+		for _, arg := range children {
+			g.expression(arg)
+			g.emit("\tpushq %%rax")
+		}
+		g.emit("\tcmpq $%d, __depth(%%rip)", g.MaxCallDepth)
+		g.runtimeError("jge", NewDiagnostic(Severity_Error, DiagnosticCode_StackOverflow,
+			fmt.Sprintf("stack overflow: call depth exceeds %d", g.MaxCallDepth), node.GetSpan()))
+		g.emit("\tcall %s", g.functions[node.GetSymbol()])
+		if len(children) > 0 {
+			g.emit("\taddq $%d, %%rsp", 8*len(children))
+		}
+	default:
+		panic(NewDiagnostic(Severity_Error, DiagnosticCode_InvalidNode, fmt.Sprintf("cannot compile %s as an expression", node.GetType()), node.GetSpan()))
+	}
+}
+
+func (g *X86Generator) binary(node ASTNoder) {
+	children := node.GetChildren()
+	g.expression(children[0])
+	g.emit("\tpushq %%rax")
+	g.expression(children[1])
+	g.emit("\tmovq %%rax, %%rcx")
+	g.emit("\tpopq %%rax")
+	operator := node.GetText()
+	switch operator {
+	case "+":
+		g.emit("\taddq %%rcx, %%rax")
+	case "-":
+		g.emit("\tsubq %%rcx, %%rax")
+	case "*":
+		g.emit("\timulq %%rcx, %%rax")
+	case "/", "%":
+		g.divide(operator, node.GetSpan())
+	default:
+		g.emit("\tcmpq %%rcx, %%rax")
+		g.emit("\tset%s %%al", x86Conditions[operator])
+		g.emit("\tmovzbq %%al, %%rax")
+	}
+}
+
+// divide leaves in %rax the quotient, for /, or the remainder, for %, of
+// %rax by %rcx, and fails with a division by zero error at span.
+func (g *X86Generator) divide(operator string, span Span) {
+	g.emit("\ttestq %%rcx, %%rcx")
+	g.runtimeError("je", NewDiagnostic(Severity_Error, DiagnosticCode_DivisionByZero, errDivisionByZero.Error(), span))
+	// The minimum int divided by -1 overflows and traps in idiv.
+	divide, end := g.label(), g.label()
+	g.emit("\tcmpq $-1, %%rcx")
+	g.emit("\tjne %s", divide)
+	if operator == "/" {
+		g.emit("\tnegq %%rax")
+	} else {
+		g.emit("\txorq %%rax, %%rax")
+	}
+	g.emit("\tjmp %s", end)
+	g.emit("%s:", divide)
+	g.emit("\tcqo")
+	g.emit("\tidivq %%rcx")
+	if operator == "%" {
+		g.emit("\tmovq %%rdx, %%rax")
+	}
+	g.emit("%s:", end)
+}
+
+// x86Runtime implements print and runtime errors with system calls.
+const x86Runtime = `
+__print_int:
+	pushq %rbx
+	movq %rax, %rbx
+	leaq __buffer+32(%rip), %rsi
+	decq %rsi
+	movb $10, (%rsi)
+	testq %rax, %rax
+	jns 1f
+	negq %rax
+1:	movq $10, %rcx
+2:	xorq %rdx, %rdx
+	divq %rcx
+	addb $48, %dl
+	decq %rsi
+	movb %dl, (%rsi)
+	testq %rax, %rax
+	jnz 2b
+	testq %rbx, %rbx
+	jns 3f
+	decq %rsi
+	movb $45, (%rsi)
+3:	leaq __buffer+32(%rip), %rdx
+	subq %rsi, %rdx
+	movq $1, %rax
+	movq $1, %rdi
+	syscall
+	popq %rbx
+	ret
+
+__print_bool:
+	testq %rax, %rax
+	je 1f
+	leaq __true(%rip), %rsi
+	movq $5, %rdx
+	jmp 2f
+1:	leaq __false(%rip), %rsi
+	movq $6, %rdx
+2:	movq $1, %rax
+	movq $1, %rdi
+	syscall
+	ret
+
+__runtime_error:
+	movq $1, %rax
+	movq $2, %rdi
+	syscall
+	movq $60, %rax
+	movq $1, %rdi
+	syscall
+
+	.section .rodata
+__true:
+	.ascii "true\n"
+__false:
+	.ascii "false\n"
+`
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// errorLine is the first line of the error reported in stderr, or "".
+func errorLine(stderr string) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.Contains(line, ": error[") {
+			return line
+		}
+	}
+	return ""
+}
+
+// checkBinary runs the program binary, built at level, and compares what it
+// prints and its exit status with what the interpreter showed.
+func checkBinary(t *testing.T, level string, binary string, want result) {
+	t.Helper()
+	var out, errs bytes.Buffer
+	cmd := exec.Command(binary)
+	cmd.Stdout, cmd.Stderr = &out, &errs
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatal(err)
+	}
+	if out.String() != want.stdout {
+		t.Errorf("%s: printed\n%s\nwant\n%s", level, out.String(), want.stdout)
+	}
+	if got := cmd.ProcessState.ExitCode(); got != want.status {
+		t.Errorf("%s: exit status %d, want %d", level, got, want.status)
+	}
+	if got := strings.TrimSpace(errs.String()); got != errorLine(want.stderr) {
+		t.Errorf("%s: error %q, want %q", level, got, errorLine(want.stderr))
+	}
+}
+
+func TestNativeMatchesInterpreter(t *testing.T) {
+	for _, tool := range []string{"as", "ld"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skip(tool + " is not installed")
+		}
+	}
+	for _, file := range samples(t) {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			want := runProgram(t, file)
+			for _, level := range []string{"-O0", "-O1", "-O2"} {
+				binary := filepath.Join(t.TempDir(), "prog")
+				var status int
+				_, stderr := capture(t, func() {
+					status = buildCommand([]string{level, file, "-o", binary})
+				})
+				if status != 0 && strings.Contains(stderr, string(DiagnosticCode_Unsupported)) {
+					t.Skip("not supported by the native backend: " + errorLine(stderr))
+				}
+				if status != 0 {
+					t.Fatalf("%s: build failed: %s", level, stderr)
+				}
+				checkBinary(t, level, binary, want)
+			}
+		})
+	}
+}
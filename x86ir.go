@@ -0,0 +1,450 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// x86Registers are the machine registers given to the registers of the IR.
+// %rax, %rcx and %rdx are left for division, the results of calls and spill
+// code. A function saves the callee-saved ones it uses, x86CalleeSaved,
+// which are the only ones that keep their value across a call.
+var x86Registers = []string{"%rsi", "%rdi", "%r8", "%r9", "%r10", "%r11", "%rbx", "%r12", "%r13", "%r14", "%r15"}
+
+var x86CalleeSaved = map[string]bool{"%rbx": true, "%r12": true, "%r13": true, "%r14": true, "%r15": true}
+
+var x86Inverse = map[string]string{"l": "ge", "ge": "l", "le": "g", "g": "le", "e": "ne", "ne": "e"}
+
+// x86Move is a copy of src to dst, part of the copies of the phis on an
+// edge.
+type x86Move struct {
+	dst, src string
+}
+
+// X86IRGenerator translates an IR program to x86-64 GNU assembly with the
+// runtime of X86Generator. The registers of each function are assigned to
+// machine registers by allocateRegisters; the spilled ones stay in the
+// stack frame and go through %rax and %rcx when an instruction cannot take
+// them from memory. Phis become copies at the end of their predecessors,
+// after the critical edges are split. Arguments are still pushed on the
+// stack and results returned in %rax. Only int and bool values are
+// supported.
+type X86IRGenerator struct {
+	X86Generator
+	alloc  *regAllocation
+	saved  []string
+	blocks map[*IRBlock]string
+	uses   map[*IRReg]int
+}
+
+func NewX86IRGenerator() *X86IRGenerator {
+	return &X86IRGenerator{X86Generator: X86Generator{MaxCallDepth: DefaultMaxCallDepth}}
+}
+
+// Generate returns the assembly of program. The critical edges of its
+// functions are split in place.
+func (g *X86IRGenerator) Generate(program *IRProgram) (asm string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d, ok := r.(*Diagnostic)
+			if !ok {
+				panic(r)
+			}
+			asm, err = "", d
+		}
+	}()
+	g.out.Reset()
+	g.data.Reset()
+	g.labels = 0
+	g.functions = make(map[*Symbol]string)
+	for i, f := range program.Functions {
+		g.functions[f.Symbol] = fmt.Sprintf("fn_%d", i)
+	}
+
+	g.emit("\t.text")
+	g.emit("\t.globl _start")
+	g.emit("_start:")
+	g.functionDefine(program.Main)
+	for _, f := range program.Functions {
+		g.functionDefine(f)
+	}
+	globals := 0
+	for _, global := range program.Globals {
+		if global.Index >= globals {
+			globals = global.Index + 1
+		}
+	}
+	g.emitRuntime(globals)
+	return g.out.String(), nil
+}
+
+// functionDefine emits f, or the entry point for the top-level statements.
+// The used callee-saved registers are pushed below the saved %rbp and the
+// spill slots come next.
+func (g *X86IRGenerator) functionDefine(f *IRFunction) {
+	splitCriticalEdges(f)
+	g.function = f.Symbol
+	g.alloc = allocateRegisters(f, x86Registers, x86CalleeSaved)
+	g.saved = nil
+	if f.Symbol != nil {
+		for _, r := range x86Registers {
+			if x86CalleeSaved[r] && g.alloc.used[r] {
+				g.saved = append(g.saved, r)
+			}
+		}
+	}
+	g.blocks = make(map[*IRBlock]string)
+	g.uses = make(map[*IRReg]int)
+	for _, b := range f.Blocks {
+		g.blocks[b] = g.label()
+		for _, instr := range b.Instrs {
+			for _, arg := range instr.Args {
+				if r, ok := arg.(*IRReg); ok {
+					g.uses[r]++
+				}
+			}
+		}
+	}
+
+	if f.Symbol != nil {
+		g.returnLabel = g.label()
+		g.emit("%s:\t# %s", g.functions[f.Symbol], f.Name)
+		g.emit("\tpushq %%rbp")
+	}
+	g.emit("\tmovq %%rsp, %%rbp")
+	for _, r := range g.saved {
+		g.emit("\tpushq %s", r)
+	}
+	if g.alloc.slotCount > 0 {
+		g.emit("\tsubq $%d, %%rsp", 8*g.alloc.slotCount)
+	}
+	if f.Symbol != nil {
+		g.emit("\tincq __depth(%%rip)")
+		for i, param := range f.Params {
+			g.move(fmt.Sprintf("%d(%%rbp)", 16+8*(len(f.Params)-1-i)), g.location(param))
+		}
+	}
+	for i, b := range f.Blocks {
+		var next *IRBlock
+		if i+1 < len(f.Blocks) {
+			next = f.Blocks[i+1]
+		}
+		g.block(b, next)
+	}
+	if f.Symbol != nil {
+		g.emit("%s:", g.returnLabel)
+		g.emit("\tdecq __depth(%%rip)")
+		for i := len(g.saved) - 1; i >= 0; i-- {
+			g.emit("\tmovq %d(%%rbp), %s", -8*(i+1), g.saved[i])
+		}
+		g.emit("\tleave")
+		g.emit("\tret")
+	}
+	g.function = nil
+}
+
+// location is the register or the stack slot of r.
+func (g *X86IRGenerator) location(r *IRReg) string {
+	if register, ok := g.alloc.registers[r]; ok {
+		return register
+	}
+	return fmt.Sprintf("%d(%%rbp)", -8*(len(g.saved)+g.alloc.slots[r]+1))
+}
+
+func (g *X86IRGenerator) operand(v IRValue) string {
+	if r, ok := v.(*IRReg); ok {
+		return g.location(r)
+	}
+	c := v.(IRConst).Value
+	if c.Type == DataType_Bool {
+		if c.Bool {
+			return "$1"
+		}
+		return "$0"
+	}
+	return "$" + strconv.Itoa(c.Int)
+}
+
+func isMemory(operand string) bool {
+	return strings.HasSuffix(operand, ")")
+}
+
+func isImmediate(operand string) bool {
+	return strings.HasPrefix(operand, "$")
+}
+
+// isWide tells whether operand is an immediate that only movabsq takes.
+func isWide(operand string) bool {
+	if !isImmediate(operand) {
+		return false
+	}
+	n, _ := strconv.ParseInt(operand[1:], 10, 64)
+	return int64(int32(n)) != n
+}
+
+// move copies src to dst, through %rax when neither is a register.
+func (g *X86IRGenerator) move(src, dst string) {
+	switch {
+	case src == dst:
+	case isWide(src) && isMemory(dst):
+		g.emit("\tmovabsq %s, %%rax", src)
+		g.emit("\tmovq %%rax, %s", dst)
+	case isWide(src):
+		g.emit("\tmovabsq %s, %s", src, dst)
+	case isMemory(src) && isMemory(dst):
+		g.emit("\tmovq %s, %%rax", src)
+		g.emit("\tmovq %%rax, %s", dst)
+	default:
+		g.emit("\tmovq %s, %s", src, dst)
+	}
+}
+
+// source returns operand as the source of an arithmetic instruction, moved
+// to scratch if it is too wide an immediate.
+func (g *X86IRGenerator) source(operand, scratch string) string {
+	if isWide(operand) {
+		g.move(operand, scratch)
+		return scratch
+	}
+	return operand
+}
+
+func (g *X86IRGenerator) checkInstr(instr *IRInstr) {
+	values := instr.Args
+	if instr.Dest != nil {
+		values = append([]IRValue{instr.Dest}, values...)
+	}
+	for _, v := range values {
+		if t := v.GetDataType(); t == DataType_Float || t == DataType_String {
+			panic(NewDiagnostic(Severity_Error, DiagnosticCode_Unsupported, fmt.Sprintf("the native backend does not support %s values", t), instr.Span))
+		}
+	}
+}
+
+// fused tells whether the comparison at index k of b only decides the
+// branch that follows it, which then jumps on the flags.
+func (g *X86IRGenerator) fused(b *IRBlock, k int) bool {
+	instr := b.Instrs[k]
+	if instr.Op != IROp_Binary || x86Conditions[instr.Operator] == "" || k+1 >= len(b.Instrs) {
+		return false
+	}
+	next := b.Instrs[k+1]
+	return next.Op == IROp_Branch && next.Args[0] == instr.Dest && g.uses[instr.Dest] == 1
+}
+
+func (g *X86IRGenerator) block(b *IRBlock, next *IRBlock) {
+	g.emit("%s:", g.blocks[b])
+	for k, instr := range b.Instrs {
+		g.checkInstr(instr)
+		switch instr.Op {
+		case IROp_Copy:
+			g.move(g.operand(instr.Args[0]), g.location(instr.Dest))
+		case IROp_Binary:
+			if !g.fused(b, k) {
+				g.binary(instr)
+			}
+		case IROp_Unary:
+			dest := g.location(instr.Dest)
+			g.move(g.operand(instr.Args[0]), dest)
+			if instr.Operator == "-" {
+				g.emit("\tnegq %s", dest)
+			} else {
+				g.emit("\txorq $1, %s", dest)
+			}
+		case IROp_Convert:
+			x := g.operand(instr.Args[0])
+			if instr.Dest.Type == DataType_Bool && instr.Args[0].GetDataType() == DataType_Int {
+				if isImmediate(x) {
+					g.move(x, "%rax")
+					x = "%rax"
+				}
+				g.emit("\tcmpq $0, %s", x)
+				g.setCondition("ne", g.location(instr.Dest))
+			} else {
+				g.move(x, g.location(instr.Dest))
+			}
+		case IROp_LoadGlobal:
+			g.move(address(instr.Global), g.location(instr.Dest))
+		case IROp_StoreGlobal:
+			g.move(g.operand(instr.Args[0]), address(instr.Global))
+		case IROp_Call:
+			for _, arg := range instr.Args {
+				g.emit("\tpushq %s", g.source(g.operand(arg), "%rax"))
+			}
+			g.emit("\tcmpq $%d, __depth(%%rip)", g.MaxCallDepth)
+			g.runtimeError("jge", NewDiagnostic(Severity_Error, DiagnosticCode_StackOverflow,
+				fmt.Sprintf("stack overflow: call depth exceeds %d", g.MaxCallDepth), instr.Span))
+			g.emit("\tcall %s", g.functions[instr.Callee])
+			if len(instr.Args) > 0 {
+				g.emit("\taddq $%d, %%rsp", 8*len(instr.Args))
+			}
+			if instr.Dest != nil {
+				g.move("%rax", g.location(instr.Dest))
+			}
+		case IROp_Print:
+			g.move(g.operand(instr.Args[0]), "%rax")
+			if instr.Args[0].GetDataType() == DataType_Bool {
+				g.emit("\tcall __print_bool")
+			} else {
+				g.emit("\tcall __print_int")
+			}
+		case IROp_Phi:
+		case IROp_Jump:
+			g.phiCopies(b, instr.Blocks[0])
+			if instr.Blocks[0] != next {
+				g.emit("\tjmp %s", g.blocks[instr.Blocks[0]])
+			}
+		case IROp_Branch:
+			g.branch(b, k, next)
+		case IROp_Return:
+			if g.function == nil {
+				g.emit("\tmovq $60, %%rax")
+				g.emit("\txorq %%rdi, %%rdi")
+				g.emit("\tsyscall")
+				break
+			}
+			if len(instr.Args) > 0 {
+				g.move(g.operand(instr.Args[0]), "%rax")
+			}
+			if next != nil {
+				g.emit("\tjmp %s", g.returnLabel)
+			}
+		}
+	}
+}
+
+func (g *X86IRGenerator) binary(instr *IRInstr) {
+	x, y := g.operand(instr.Args[0]), g.operand(instr.Args[1])
+	dest := g.location(instr.Dest)
+	// The interval of the result starts where the ones of the operands end,
+	// so dest is neither x nor y.
+	switch instr.Operator {
+	case "+", "-", "*":
+		mnemonic := map[string]string{"+": "addq", "-": "subq", "*": "imulq"}[instr.Operator]
+		y = g.source(y, "%rcx")
+		if isMemory(dest) {
+			g.move(x, "%rax")
+			g.emit("\t%s %s, %%rax", mnemonic, y)
+			g.move("%rax", dest)
+		} else {
+			g.move(x, dest)
+			g.emit("\t%s %s, %s", mnemonic, y, dest)
+		}
+	case "/", "%":
+		g.move(y, "%rcx")
+		g.move(x, "%rax")
+		g.divide(instr.Operator, instr.Span)
+		g.move("%rax", dest)
+	default:
+		g.setCondition(g.compare(instr), dest)
+	}
+}
+
+// compare sets the flags for the comparison instr and returns the
+// condition that holds when it is true.
+func (g *X86IRGenerator) compare(instr *IRInstr) string {
+	x, y := g.operand(instr.Args[0]), g.operand(instr.Args[1])
+	y = g.source(y, "%rcx")
+	if isImmediate(x) || isMemory(x) && isMemory(y) {
+		g.move(x, "%rax")
+		x = "%rax"
+	}
+	g.emit("\tcmpq %s, %s", y, x)
+	return x86Conditions[instr.Operator]
+}
+
+// setCondition stores 1 in dest if the flags satisfy condition, else 0.
+func (g *X86IRGenerator) setCondition(condition, dest string) {
+	g.emit("\tset%s %%al", condition)
+	if isMemory(dest) {
+		g.emit("\tmovzbq %%al, %%rax")
+		g.move("%rax", dest)
+	} else {
+		g.emit("\tmovzbq %%al, %s", dest)
+	}
+}
+
+// branch emits the branch at index k of b, falling through to next when it
+// is a target.
+func (g *X86IRGenerator) branch(b *IRBlock, k int, next *IRBlock) {
+	instr := b.Instrs[k]
+	then, otherwise := instr.Blocks[0], instr.Blocks[1]
+	var condition string
+	if k > 0 && g.fused(b, k-1) {
+		condition = g.compare(b.Instrs[k-1])
+	} else {
+		x := g.operand(instr.Args[0])
+		if isImmediate(x) {
+			target := otherwise
+			if x != "$0" {
+				target = then
+			}
+			if target != next {
+				g.emit("\tjmp %s", g.blocks[target])
+			}
+			return
+		}
+		g.emit("\tcmpq $0, %s", x)
+		condition = "ne"
+	}
+	if then == next {
+		g.emit("\tj%s %s", x86Inverse[condition], g.blocks[otherwise])
+		return
+	}
+	g.emit("\tj%s %s", condition, g.blocks[then])
+	if otherwise != next {
+		g.emit("\tjmp %s", g.blocks[otherwise])
+	}
+}
+
+// phiCopies emits the copies to the phis of s on the edge from pred.
+func (g *X86IRGenerator) phiCopies(pred, s *IRBlock) {
+	var moves []x86Move
+	for _, phi := range s.Instrs {
+		if phi.Op != IROp_Phi {
+			break
+		}
+		for i, from := range phi.Blocks {
+			if from == pred {
+				moves = append(moves, x86Move{g.location(phi.Dest), g.operand(phi.Args[i])})
+			}
+		}
+	}
+	g.parallelMove(moves)
+}
+
+// parallelMove emits moves as if all their sources were read before any
+// destination is written. A move waits while its destination is the source
+// of another one. When the moves left all wait on each other, they form
+// cycles, and the destination of one is saved to %rcx to break its cycle.
+func (g *X86IRGenerator) parallelMove(moves []x86Move) {
+	for len(moves) > 0 {
+		ready := -1
+		for i, m := range moves {
+			blocked := false
+			for j, other := range moves {
+				if j != i && other.src == m.dst && m.src != m.dst {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = i
+				break
+			}
+		}
+		if ready < 0 {
+			saved := moves[0].dst
+			g.move(saved, "%rcx")
+			for j := range moves {
+				if moves[j].src == saved {
+					moves[j].src = "%rcx"
+				}
+			}
+			continue
+		}
+		g.move(moves[ready].src, moves[ready].dst)
+		moves = append(moves[:ready], moves[ready+1:]...)
+	}
+}